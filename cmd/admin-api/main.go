@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/cache"
 	"github.com/saas-single-db-api/internal/config"
 	"github.com/saas-single-db-api/internal/database"
@@ -15,6 +17,7 @@ import (
 	"github.com/saas-single-db-api/internal/middleware"
 	adminRepo "github.com/saas-single-db-api/internal/repository/admin"
 	adminSvc "github.com/saas-single-db-api/internal/services/admin"
+	"github.com/saas-single-db-api/internal/utils"
 
 	_ "github.com/saas-single-db-api/docs/admin"
 )
@@ -40,11 +43,19 @@ func main() {
 	redisClient := cache.NewRedisClient(cfg.RedisURL)
 	defer redisClient.Close()
 
+	keys, err := utils.LoadKeySet(cfg.JWTKeysDir)
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %v", err)
+	}
+
 	// Repositories
 	repo := adminRepo.NewRepository(db)
 
 	// Services
-	service := adminSvc.NewService(repo, cfg.JWTSecret, cfg.JWTExpiryHours)
+	sessionSvc := auth.NewSessionService(db, redisClient.Inner(), time.Duration(cfg.JWTExpiryMinutes)*time.Minute, time.Duration(cfg.RefreshExpiryDays)*24*time.Hour)
+	mfaSvc := auth.NewMFAService(db, redisClient.Inner(), cfg.AppName)
+	loginGuard := auth.NewLoginGuard(redisClient.Inner())
+	service := adminSvc.NewService(repo, redisClient.Inner(), sessionSvc, mfaSvc, loginGuard, keys, cfg.JWTExpiryMinutes)
 
 	// Handlers
 	handler := adminHandler.NewHandler(service, redisClient.Inner())
@@ -54,35 +65,55 @@ func main() {
 	r.Use(middleware.CORSMiddleware())
 	r.Use(gin.Recovery())
 
+	// Every route is registered through access with the permission it
+	// requires; startup fails if one was registered any other way.
+	access := middleware.NewAccess(service)
+
 	api := r.Group("/api/v1/admin")
 	{
 		// Auth (public)
-		auth := api.Group("/auth")
+		auth := access.Group(api, "/auth", middleware.NoPermission)
 		{
 			auth.POST("/login", handler.Login)
+			auth.POST("/refresh", handler.Refresh)
+			auth.POST("/2fa/verify", handler.VerifyMFA)
+			auth.POST("/2fa/enroll", handler.EnrollMFA)
 		}
 
 		// Auth (protected)
 		protected := api.Group("")
-		protected.Use(middleware.AdminAuthMiddleware(cfg.JWTSecret, redisClient.Inner()))
+		protected.Use(middleware.AdminAuthMiddleware(keys, sessionSvc))
 		{
-			protectedAuth := protected.Group("/auth")
+			protectedAuth := access.Group(protected, "/auth", middleware.NoPermission)
 			{
 				protectedAuth.POST("/logout", handler.Logout)
 				protectedAuth.GET("/me", handler.Me)
+				protectedAuth.GET("/sessions", handler.ListSessions)
+				protectedAuth.DELETE("/sessions", handler.RevokeOtherSessions)
+				protectedAuth.DELETE("/sessions/:id", handler.RevokeSession)
+				protectedAuth.POST("/2fa/setup", handler.SetupMFA)
+				protectedAuth.POST("/2fa/enable", handler.EnableMFA)
+				protectedAuth.POST("/2fa/disable", handler.DisableMFA)
+				protectedAuth.POST("/2fa/recovery-codes", handler.RegenerateRecoveryCodes)
 				protectedAuth.PUT("/password", handler.ChangePassword)
 			}
 
+			// Own profile
+			myProfile := access.Group(protected, "/sys-users/profile", middleware.NoPermission)
+			{
+				myProfile.GET("", handler.GetMyProfile)
+				myProfile.PUT("", handler.UpdateMyProfile)
+			}
+
 			// Sys Users
-			sysUsers := protected.Group("/sys-users")
+			sysUsers := access.Group(protected, "/sys-users", middleware.Require("manage_sys_users"))
 			{
 				sysUsers.GET("", handler.ListSysUsers)
 				sysUsers.POST("", handler.CreateSysUser)
-				sysUsers.GET("/profile", handler.GetMyProfile)
-				sysUsers.PUT("/profile", handler.UpdateMyProfile)
 				sysUsers.GET("/:id", handler.GetSysUser)
 				sysUsers.PUT("/:id", handler.UpdateSysUser)
 				sysUsers.DELETE("/:id", handler.DeleteSysUser)
+				sysUsers.POST("/:id/unlock", handler.UnlockSysUser)
 				sysUsers.GET("/:id/profile", handler.GetSysUserProfile)
 				sysUsers.PUT("/:id/profile", handler.UpdateSysUserProfile)
 				sysUsers.POST("/:id/roles", handler.AssignRole)
@@ -90,7 +121,7 @@ func main() {
 			}
 
 			// Roles
-			roles := protected.Group("/roles")
+			roles := access.Group(protected, "/roles", middleware.Require("manage_sys_users"))
 			{
 				roles.GET("", handler.ListRoles)
 				roles.POST("", handler.CreateRole)
@@ -100,10 +131,13 @@ func main() {
 			}
 
 			// Permissions
-			protected.GET("/permissions", handler.ListPermissions)
+			permissions := access.Group(protected, "/permissions", middleware.Require("manage_sys_users"))
+			{
+				permissions.GET("", handler.ListPermissions)
+			}
 
 			// Tenants
-			tenants := protected.Group("/tenants")
+			tenants := access.Group(protected, "/tenants", middleware.Require("view_tenants", "manage_tenants").ForWrites("manage_tenants"))
 			{
 				tenants.GET("", handler.ListTenants)
 				tenants.POST("", handler.CreateTenant)
@@ -111,13 +145,17 @@ func main() {
 				tenants.PUT("/:id", handler.UpdateTenant)
 				tenants.DELETE("/:id", handler.DeleteTenant)
 				tenants.PUT("/:id/status", handler.UpdateTenantStatus)
-				tenants.PUT("/:id/plan", handler.ChangeTenantPlan)
+				tenants.PUT("/:id/owner", handler.TransferTenantOwnership)
 				tenants.GET("/:id/plan-history", handler.GetTenantPlanHistory)
 				tenants.GET("/:id/members", handler.GetTenantMembers)
 			}
+			tenantPlans := access.Group(protected, "/tenants", middleware.Require("manage_plans"))
+			{
+				tenantPlans.PUT("/:id/plan", handler.ChangeTenantPlan)
+			}
 
 			// Plans
-			plans := protected.Group("/plans")
+			plans := access.Group(protected, "/plans", middleware.Require("manage_plans", "view_analytics").ForWrites("manage_plans"))
 			{
 				plans.GET("", handler.ListPlans)
 				plans.POST("", handler.CreatePlan)
@@ -129,7 +167,7 @@ func main() {
 			}
 
 			// Features
-			features := protected.Group("/features")
+			features := access.Group(protected, "/features", middleware.Require("manage_features", "manage_plans", "view_analytics").ForWrites("manage_features"))
 			{
 				features.GET("", handler.ListFeatures)
 				features.POST("", handler.CreateFeature)
@@ -139,7 +177,7 @@ func main() {
 			}
 
 			// Promotions
-			promos := protected.Group("/promotions")
+			promos := access.Group(protected, "/promotions", middleware.Require("manage_plans", "manage_billing", "view_analytics").ForWrites("manage_plans", "manage_billing"))
 			{
 				promos.GET("", handler.ListPromotions)
 				promos.POST("", handler.CreatePromotion)
@@ -150,8 +188,16 @@ func main() {
 		}
 	}
 
+	public := access.Group(&r.RouterGroup, "", middleware.NoPermission)
+	// Public keys that verify the access tokens
+	public.GET("/.well-known/jwks.json", utils.JWKSHandler(keys))
+
 	// Swagger UI
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	public.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	if err := access.Verify(r.Routes()); err != nil {
+		log.Fatalf("Admin API route permissions: %v", err)
+	}
 
 	fmt.Printf("🚀 Admin API starting on port %s\n", cfg.AdminAPIPort)
 	if err := r.Run(":" + cfg.AdminAPIPort); err != nil {
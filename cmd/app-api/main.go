@@ -3,19 +3,26 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/cache"
 	"github.com/saas-single-db-api/internal/config"
 	"github.com/saas-single-db-api/internal/database"
+	"github.com/saas-single-db-api/internal/email"
 	appHandler "github.com/saas-single-db-api/internal/handlers/app"
+	"github.com/saas-single-db-api/internal/imagequeue"
 	"github.com/saas-single-db-api/internal/middleware"
+	"github.com/saas-single-db-api/internal/profileimage"
 	appRepo "github.com/saas-single-db-api/internal/repository/app"
 	appSvc "github.com/saas-single-db-api/internal/services/app"
 	"github.com/saas-single-db-api/internal/storage"
+	"github.com/saas-single-db-api/internal/utils"
+	"github.com/saas-single-db-api/internal/webhooks"
 
 	_ "github.com/saas-single-db-api/docs/app"
 )
@@ -46,11 +53,35 @@ func main() {
 		log.Fatalf("Failed to create storage provider: %v", err)
 	}
 
+	// Email service
+	emailSvc := email.NewService(email.Config{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		User:     cfg.SMTPUser,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+		AppName:  cfg.AppName,
+		BaseURL:  cfg.AppBaseURL,
+	}, db)
+
+	keys, err := utils.LoadKeySet(cfg.JWTKeysDir)
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %v", err)
+	}
+
 	// Repositories
 	repo := appRepo.NewRepository(db)
 
 	// Services
-	service := appSvc.NewService(repo, cfg.JWTSecret, cfg.JWTExpiryHours)
+	sessionSvc := auth.NewSessionService(db, redisClient.Inner(), time.Duration(cfg.JWTExpiryMinutes)*time.Minute, time.Duration(cfg.RefreshExpiryDays)*24*time.Hour)
+	loginGuard := auth.NewLoginGuard(redisClient.Inner())
+	webhookSvc := webhooks.NewService(db)
+	imageQueue := imagequeue.NewQueue(db, imagequeue.Config{
+		MaxAttempts: cfg.ImageMaxAttempts,
+		RetryBase:   time.Duration(cfg.ImageRetryBaseSeconds) * time.Second,
+	})
+	profileImages := profileimage.NewService(db, storageProvider, cfg.StorageProvider, imageQueue)
+	service := appSvc.NewService(repo, redisClient, emailSvc, sessionSvc, loginGuard, webhookSvc, profileImages, keys, cfg.JWTExpiryMinutes)
 
 	// Handlers
 	handler := appHandler.NewHandler(service, repo, storageProvider, redisClient)
@@ -60,51 +91,19 @@ func main() {
 	r.Use(middleware.CORSMiddleware())
 	r.Use(gin.Recovery())
 
-	api := r.Group("/api/v1/:url_code")
-	api.Use(middleware.TenantMiddleware(db, redisClient.Inner()))
-	{
-		// ─── Auth (Public) ────────────────────────────────
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", handler.Register)
-			auth.POST("/login", handler.Login)
-			auth.POST("/forgot-password", handler.ForgotPassword)
-			auth.POST("/reset-password", handler.ResetPassword)
-		}
-
-		// ─── Auth (Protected) ─────────────────────────────
-		protectedAuth := api.Group("/auth")
-		protectedAuth.Use(
-			middleware.AppAuthMiddleware(cfg.JWTSecret, redisClient.Inner()),
-			middleware.TenantAccessMiddleware(),
-		)
-		{
-			protectedAuth.POST("/logout", handler.Logout)
-			protectedAuth.GET("/me", handler.Me)
-		}
-
-		// ─── Profile (Protected) ──────────────────────────
-		profile := api.Group("/profile")
-		profile.Use(
-			middleware.AppAuthMiddleware(cfg.JWTSecret, redisClient.Inner()),
-			middleware.TenantAccessMiddleware(),
-		)
-		{
-			profile.GET("", handler.GetProfile)
-			profile.PUT("", handler.UpdateProfile)
-			profile.PUT("/password", handler.ChangePassword)
-			profile.POST("/avatar", handler.UploadAvatar)
-		}
-
-		// ─── Catalog (Public) ─────────────────────────────
-		catalog := api.Group("/catalog")
-		{
-			catalog.GET("/products", handler.ListProducts)
-			catalog.GET("/products/:id", handler.GetProduct)
-			catalog.GET("/services", handler.ListServices)
-			catalog.GET("/services/:id", handler.GetServiceDetail)
-		}
-	}
+	// Storefront addressed by url_code: /api/v1/{url_code}/...
+	byCode := r.Group("/api/v1/:url_code")
+	byCode.Use(middleware.TenantMiddleware(db, redisClient.Inner()))
+	registerRoutes(byCode, handler, keys, sessionSvc)
+
+	// Storefront addressed by host, {subdomain}.TENANT_BASE_DOMAIN or a
+	// verified custom domain: /api/v1/...
+	byHost := r.Group("/api/v1")
+	byHost.Use(middleware.HostTenantMiddleware(db, redisClient.Inner(), cfg.TenantBaseDomain))
+	registerRoutes(byHost, handler, keys, sessionSvc)
+
+	// Public keys that verify the access tokens
+	r.GET("/.well-known/jwks.json", utils.JWKSHandler(keys))
 
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -114,3 +113,53 @@ func main() {
 		log.Fatalf("Failed to start app-api: %v", err)
 	}
 }
+
+// registerRoutes registers the app routes on a group whose middleware has
+// already resolved the tenant
+func registerRoutes(api *gin.RouterGroup, handler *appHandler.Handler, keys *utils.KeySet, sessionSvc *auth.SessionService) {
+	// ─── Auth (Public) ────────────────────────────────
+	auth := api.Group("/auth")
+	{
+		auth.POST("/register", handler.Register)
+		auth.POST("/login", handler.Login)
+		auth.POST("/refresh", handler.Refresh)
+		auth.POST("/forgot-password", handler.ForgotPassword)
+		auth.POST("/reset-password", handler.ResetPassword)
+	}
+
+	// ─── Auth (Protected) ─────────────────────────────
+	protectedAuth := api.Group("/auth")
+	protectedAuth.Use(
+		middleware.AppAuthMiddleware(keys, sessionSvc),
+		middleware.TenantAccessMiddleware(),
+	)
+	{
+		protectedAuth.POST("/logout", handler.Logout)
+		protectedAuth.GET("/me", handler.Me)
+	}
+
+	// ─── Profile (Protected) ──────────────────────────
+	profile := api.Group("/profile")
+	profile.Use(
+		middleware.AppAuthMiddleware(keys, sessionSvc),
+		middleware.TenantAccessMiddleware(),
+	)
+	{
+		profile.GET("", handler.GetProfile)
+		profile.PUT("", handler.UpdateProfile)
+		profile.PUT("/password", handler.ChangePassword)
+		profile.GET("/sessions", handler.ListSessions)
+		profile.DELETE("/sessions", handler.RevokeOtherSessions)
+		profile.DELETE("/sessions/:id", handler.RevokeSession)
+		profile.POST("/avatar", handler.UploadAvatar)
+	}
+
+	// ─── Catalog (Public) ─────────────────────────────
+	catalog := api.Group("/catalog")
+	{
+		catalog.GET("/products", handler.ListProducts)
+		catalog.GET("/products/:id", handler.GetProduct)
+		catalog.GET("/services", handler.ListServices)
+		catalog.GET("/services/:id", handler.GetServiceDetail)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saas-single-db-api/internal/config"
+	"github.com/saas-single-db-api/internal/utils"
+)
+
+// jwt-keys manages the JWT signing keys shared by the APIs (JWT_KEYS_DIR).
+//
+//	jwt-keys rotate [-alg EdDSA|RS256]  create a new key; it becomes the signing key
+//	jwt-keys list                       show the keys and which one signs
+//	jwt-keys prune [-grace 24h]         remove keys superseded longer than grace ago
+//
+// Running APIs pick up a rotated key within a minute. Tokens signed with the
+// previous key keep verifying until that key is pruned, so prune only after
+// the grace period has covered the access token lifetime.
+func main() {
+	cfg := config.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "rotate":
+		fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+		alg := fs.String("alg", utils.AlgEdDSA, "signing algorithm (EdDSA or RS256)")
+		fs.Parse(os.Args[2:])
+		rotate(cfg.JWTKeysDir, *alg)
+	case "list":
+		list(cfg.JWTKeysDir)
+	case "prune":
+		fs := flag.NewFlagSet("prune", flag.ExitOnError)
+		grace := fs.Duration("grace", 24*time.Hour, "how long a superseded key stays valid for verification")
+		fs.Parse(os.Args[2:])
+		prune(cfg.JWTKeysDir, *grace)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jwt-keys rotate [-alg EdDSA|RS256] | list | prune [-grace 24h]")
+	os.Exit(2)
+}
+
+func rotate(dir, alg string) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		log.Fatalf("Unable to create %s: %v", dir, err)
+	}
+
+	data, err := utils.GenerateKeyPEM(alg)
+	if err != nil {
+		log.Fatalf("Unable to generate key: %v", err)
+	}
+
+	kid := time.Now().UTC().Format(utils.KeyIDLayout)
+	path := filepath.Join(dir, kid+".pem")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		log.Fatalf("Unable to write %s: %v", path, err)
+	}
+	fmt.Printf("✓ New %s signing key %s\n", alg, kid)
+}
+
+func list(dir string) {
+	kids := keyIDs(dir)
+	if len(kids) == 0 {
+		fmt.Printf("No keys in %s\n", dir)
+		return
+	}
+	for i, kid := range kids {
+		data, err := os.ReadFile(filepath.Join(dir, kid+".pem"))
+		if err != nil {
+			log.Fatalf("Unable to read key %s: %v", kid, err)
+		}
+		_, method, err := utils.ParsePrivateKeyPEM(data)
+		if err != nil {
+			log.Fatalf("Invalid key %s: %v", kid, err)
+		}
+		status := "verify only"
+		if i == len(kids)-1 {
+			status = "signing"
+		}
+		fmt.Printf("%s  %-6s  %s\n", kid, method.Alg(), status)
+	}
+}
+
+func prune(dir string, grace time.Duration) {
+	kids := keyIDs(dir)
+	removed := 0
+	// A key is superseded when the next one was created; the newest key is
+	// never removed.
+	for i := 0; i < len(kids)-1; i++ {
+		supersededAt, err := time.Parse(utils.KeyIDLayout, kids[i+1])
+		if err != nil {
+			fmt.Printf("- skipping %s: next key id is not a timestamp\n", kids[i])
+			continue
+		}
+		if time.Since(supersededAt) < grace {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, kids[i]+".pem")); err != nil {
+			log.Fatalf("Unable to remove key %s: %v", kids[i], err)
+		}
+		fmt.Printf("- removed %s\n", kids[i])
+		removed++
+	}
+	fmt.Printf("✓ Pruned %d key(s)\n", removed)
+}
+
+// keyIDs returns the key ids in dir, oldest first
+func keyIDs(dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		log.Fatalf("Unable to list %s: %v", dir, err)
+	}
+	kids := make([]string, len(files))
+	for i, f := range files {
+		kids[i] = strings.TrimSuffix(filepath.Base(f), ".pem")
+	}
+	sort.Strings(kids)
+	return kids
+}
@@ -3,21 +3,30 @@ package main
 import (
 	"fmt"
 	"log"
+	"net"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/cache"
 	"github.com/saas-single-db-api/internal/config"
 	"github.com/saas-single-db-api/internal/database"
+	"github.com/saas-single-db-api/internal/domains"
 	"github.com/saas-single-db-api/internal/email"
 	tenantHandler "github.com/saas-single-db-api/internal/handlers/tenant"
 	"github.com/saas-single-db-api/internal/i18n"
+	"github.com/saas-single-db-api/internal/imageevents"
+	"github.com/saas-single-db-api/internal/imagequeue"
 	"github.com/saas-single-db-api/internal/middleware"
+	"github.com/saas-single-db-api/internal/profileimage"
 	tenantRepo "github.com/saas-single-db-api/internal/repository/tenant"
 	tenantSvc "github.com/saas-single-db-api/internal/services/tenant"
 	"github.com/saas-single-db-api/internal/storage"
+	"github.com/saas-single-db-api/internal/utils"
+	"github.com/saas-single-db-api/internal/webhooks"
 
 	_ "github.com/saas-single-db-api/docs/tenant"
 )
@@ -48,6 +57,11 @@ func main() {
 		log.Fatalf("Failed to create storage provider: %v", err)
 	}
 
+	keys, err := utils.LoadKeySet(cfg.JWTKeysDir)
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %v", err)
+	}
+
 	// Repositories
 	repo := tenantRepo.NewRepository(db)
 
@@ -63,10 +77,21 @@ func main() {
 	}, db)
 
 	// Services
-	service := tenantSvc.NewService(repo, redisClient, emailSvc, cfg.JWTSecret, cfg.JWTExpiryHours)
+	sessionSvc := auth.NewSessionService(db, redisClient.Inner(), time.Duration(cfg.JWTExpiryMinutes)*time.Minute, time.Duration(cfg.RefreshExpiryDays)*24*time.Hour)
+	mfaSvc := auth.NewMFAService(db, redisClient.Inner(), cfg.AppName)
+	loginGuard := auth.NewLoginGuard(redisClient.Inner())
+	domainVerifier := domains.NewVerifier(net.DefaultResolver, cfg.TenantBaseDomain)
+	webhookSvc := webhooks.NewService(db)
+	imageQueue := imagequeue.NewQueue(db, imagequeue.Config{
+		MaxAttempts: cfg.ImageMaxAttempts,
+		RetryBase:   time.Duration(cfg.ImageRetryBaseSeconds) * time.Second,
+	})
+	imageEvents := imageevents.NewLog(db, redisClient.Inner())
+	profileImages := profileimage.NewService(db, storageProvider, cfg.StorageProvider, imageQueue)
+	service := tenantSvc.NewService(repo, redisClient, emailSvc, sessionSvc, mfaSvc, loginGuard, domainVerifier, webhookSvc, imageQueue, imageEvents, profileImages, keys, cfg.JWTExpiryMinutes)
 
 	// Handlers
-	handler := tenantHandler.NewHandler(service, repo, storageProvider, redisClient, cfg.JWTSecret, cfg.JWTExpiryHours)
+	handler := tenantHandler.NewHandler(service, repo, storageProvider, cfg.StorageProvider, redisClient)
 
 	// Router
 	r := gin.Default()
@@ -83,34 +108,59 @@ func main() {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", handler.Login)
+			auth.POST("/refresh", handler.Refresh)
+			auth.POST("/2fa/verify", handler.VerifyMFA)
 			auth.GET("/verify-email", handler.VerifyEmail)
+			auth.POST("/forgot-password", handler.ForgotPassword)
+			auth.POST("/reset-password", handler.ResetPassword)
+		}
+
+		// ─── Invitations (Public) ─────────────────────────
+		invitations := api.Group("/invitations")
+		{
+			invitations.POST("/accept", handler.AcceptInvitation)
+			invitations.GET("/:token", handler.GetInvitation)
 		}
 
 		// ─── Auth (Protected) ─────────────────────────────
 		protectedAuth := api.Group("/auth")
-		protectedAuth.Use(middleware.UserAuthMiddleware(cfg.JWTSecret, redisClient.Inner()))
+		protectedAuth.Use(middleware.UserAuthMiddleware(keys, sessionSvc))
 		{
 			protectedAuth.POST("/logout", handler.Logout)
 			protectedAuth.GET("/me", handler.Me)
 			protectedAuth.POST("/switch/:url_code", handler.SelectTenant)
 			protectedAuth.POST("/resend-verification", handler.ResendVerification)
+			protectedAuth.POST("/2fa/setup", handler.SetupMFA)
+			protectedAuth.POST("/2fa/enable", handler.EnableMFA)
+			protectedAuth.POST("/2fa/disable", handler.DisableMFA)
+			protectedAuth.POST("/2fa/recovery-codes", handler.RegenerateRecoveryCodes)
 		}
 
 		// ─── Profile (Protected) ──────────────────────────
 		profile := api.Group("/profile")
-		profile.Use(middleware.UserAuthMiddleware(cfg.JWTSecret, redisClient.Inner()))
+		profile.Use(middleware.UserAuthMiddleware(keys, sessionSvc))
 		{
 			profile.GET("", handler.GetProfile)
 			profile.PUT("", handler.UpdateProfile)
 			profile.PUT("/password", handler.ChangePassword)
+			profile.GET("/sessions", handler.ListSessions)
+			profile.DELETE("/sessions", handler.RevokeOtherSessions)
+			profile.DELETE("/sessions/:id", handler.RevokeSession)
 			profile.POST("/avatar", handler.UploadAvatar)
 		}
 
+		// ─── Ownership Transfer (Protected) ───────────────
+		ownershipTransfer := api.Group("/ownership-transfer")
+		ownershipTransfer.Use(middleware.UserAuthMiddleware(keys, sessionSvc))
+		{
+			ownershipTransfer.POST("/confirm", handler.ConfirmOwnershipTransfer)
+		}
+
 		// ─── Tenant-scoped routes (with TenantMiddleware) ─
 		tenantScoped := api.Group("/:url_code")
 		tenantScoped.Use(
 			middleware.TenantMiddleware(db, redisClient.Inner()),
-			middleware.UserAuthMiddleware(cfg.JWTSecret, redisClient.Inner()),
+			middleware.UserAuthMiddleware(keys, sessionSvc),
 			middleware.TenantAccessMiddleware(),
 		)
 		{
@@ -121,6 +171,33 @@ func main() {
 			tenantScoped.GET("/tenant", handler.GetTenantProfile)
 			tenantScoped.PUT("/tenant/profile", handler.UpdateTenantProfile)
 			tenantScoped.POST("/tenant/logo", handler.UploadLogo)
+			tenantScoped.GET("/tenant/ownership-transfer", handler.GetOwnershipTransfer)
+			tenantScoped.POST("/tenant/ownership-transfer", handler.StartOwnershipTransfer)
+			tenantScoped.DELETE("/tenant/ownership-transfer", handler.CancelOwnershipTransfer)
+
+			// Domains
+			customDomains := tenantScoped.Group("/domains")
+			{
+				customDomains.GET("", handler.ListDomains)
+				customDomains.POST("", handler.AddDomain)
+				customDomains.POST("/:id/verify", handler.VerifyDomain)
+				customDomains.DELETE("/:id", handler.RemoveDomain)
+			}
+
+			// Webhooks
+			hooks := tenantScoped.Group("/webhooks")
+			{
+				hooks.GET("/events", handler.ListWebhookEvents)
+				hooks.GET("", handler.ListWebhooks)
+				hooks.POST("", handler.CreateWebhook)
+				hooks.GET("/:id", handler.GetWebhook)
+				hooks.PUT("/:id", handler.UpdateWebhook)
+				hooks.DELETE("/:id", handler.DeleteWebhook)
+				hooks.POST("/:id/rotate-secret", handler.RotateWebhookSecret)
+				hooks.GET("/:id/deliveries", handler.ListWebhookDeliveries)
+				hooks.GET("/:id/deliveries/:deliveryId", handler.GetWebhookDelivery)
+				hooks.POST("/:id/deliveries/:deliveryId/redeliver", handler.RedeliverWebhook)
+			}
 
 			// Members
 			members := tenantScoped.Group("/members")
@@ -128,8 +205,12 @@ func main() {
 				members.GET("", handler.ListMembers)
 				members.GET("/can-add", handler.CanAddMember)
 				members.POST("", handler.InviteMember)
+				members.GET("/invitations", handler.ListInvitations)
+				members.POST("/invitations/:id/resend", handler.ResendInvitation)
+				members.DELETE("/invitations/:id", handler.RevokeInvitation)
 				members.GET("/:id", handler.GetMember)
 				members.PUT("/:id/role", handler.UpdateMemberRole)
+				members.POST("/:id/unlock", handler.UnlockMember)
 				members.DELETE("/:id", handler.RemoveMember)
 			}
 
@@ -166,7 +247,9 @@ func main() {
 				products.PUT("/:id", handler.UpdateProduct)
 				products.DELETE("/:id", handler.DeleteProduct)
 				products.POST("/:id/images", handler.UploadProductImage)
+				products.POST("/:id/images/direct", handler.CreateProductDirectUpload)
 				products.GET("/:id/images", handler.ListProductImages)
+				products.PUT("/:id/images/order", handler.ReorderProductImages)
 			}
 
 			// Services
@@ -178,17 +261,27 @@ func main() {
 				services.PUT("/:id", handler.UpdateService)
 				services.DELETE("/:id", handler.DeleteService)
 				services.POST("/:id/images", handler.UploadServiceImage)
+				services.POST("/:id/images/direct", handler.CreateServiceDirectUpload)
 				services.GET("/:id/images", handler.ListServiceImages)
+				services.PUT("/:id/images/order", handler.ReorderServiceImages)
 			}
 
 			// Images
 			images := tenantScoped.Group("/images")
 			{
+				images.GET("/events", handler.StreamTenantImageEvents)
 				images.GET("/:id/events", handler.StreamImageEvents)
 				images.PUT("/:id", handler.UpdateImageTitle)
 				images.DELETE("/:id", handler.DeleteImage)
+				images.POST("/:id/reprocess", handler.ReprocessImage)
+				images.POST("/:id/complete", handler.CompleteDirectUpload)
+				images.PUT("/:id/visibility", handler.UpdateImageVisibility)
+				images.POST("/reprocess-failed", handler.ReprocessFailedImages)
 			}
 
+			// Media downloads, restricted to the tenant's own files
+			tenantScoped.GET("/media/*filepath", handler.DownloadMedia)
+
 			// Settings
 			settings := tenantScoped.Group("/settings")
 			{
@@ -197,6 +290,9 @@ func main() {
 				settings.GET("", handler.GetSettings)
 				settings.PUT("", handler.UpdateSettings)
 				settings.PUT("/language", handler.UpdateLanguage)
+				settings.GET("/image-variants", handler.GetImageVariants)
+				settings.PUT("/image-variants", handler.UpdateImageVariants)
+				settings.POST("/image-variants/rerender", handler.RerenderImages)
 			}
 
 			// App Users (managed from backoffice)
@@ -205,13 +301,20 @@ func main() {
 				appUsers.GET("", handler.ListAppUsers)
 				appUsers.GET("/:id", handler.GetAppUser)
 				appUsers.PUT("/:id/status", handler.UpdateAppUserStatus)
+				appUsers.POST("/:id/unlock", handler.UnlockAppUser)
 				appUsers.DELETE("/:id", handler.DeleteAppUser)
 			}
 		}
 	}
 
-	// Serve uploaded files
-	r.Static("/uploads", cfg.StorageLocalPath)
+	// Serve uploaded files; private ones need a signed URL
+	r.GET("/uploads/*filepath", handler.ServeUpload)
+	r.HEAD("/uploads/*filepath", handler.ServeUpload)
+	// Direct uploads to local storage, authorized by their signed URL
+	r.PUT("/uploads/*filepath", handler.PutLocalUpload)
+
+	// Public keys that verify the access tokens
+	r.GET("/.well-known/jwks.json", utils.JWKSHandler(keys))
 
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -1,17 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"image"
 	_ "image/gif"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"path"
 	"strings"
 	"syscall"
 	"time"
@@ -23,21 +26,19 @@ import (
 
 	"github.com/saas-single-db-api/internal/config"
 	"github.com/saas-single-db-api/internal/database"
+	"github.com/saas-single-db-api/internal/imageevents"
+	"github.com/saas-single-db-api/internal/imagequeue"
+	"github.com/saas-single-db-api/internal/imagevariant"
+	"github.com/saas-single-db-api/internal/placeholder"
+	"github.com/saas-single-db-api/internal/profileimage"
 	"github.com/saas-single-db-api/internal/storage"
+	"github.com/saas-single-db-api/internal/storagegc"
+	"github.com/saas-single-db-api/internal/upload"
+	"github.com/saas-single-db-api/internal/webhooks"
 )
 
-// Variant config
-type variantConfig struct {
-	Name      string
-	MaxWidth  int
-	MaxHeight int
-}
-
-var variants = []variantConfig{
-	{Name: "medium", MaxWidth: 800, MaxHeight: 800},
-	{Name: "small", MaxWidth: 350, MaxHeight: 350},
-	{Name: "thumb", MaxWidth: 100, MaxHeight: 100},
-}
+// originalQuality is the WebP quality used when converting originals
+const originalQuality = 85
 
 // imageRow holds the fields we read from DB
 type imageRow struct {
@@ -53,22 +54,90 @@ type imageRow struct {
 	OriginalURL      *string
 	ProcessingStatus string
 	FileSize         *int64
+	Variants         map[string]imagevariant.Variant
+	ProcessedAt      *time.Time
 }
 
+const (
+	// pollInterval is the longest an idle worker waits before looking at the
+	// queue again; a NOTIFY on imagequeue.Channel wakes it up sooner
+	pollInterval = 5 * time.Second
+	// sweepInterval is how often lost jobs and stale images are recovered
+	sweepInterval = time.Minute
+	// staleAfter is how long an image may wait without a job before the
+	// sweep queues it
+	staleAfter = 2 * time.Minute
+)
+
 type worker struct {
 	db      *pgxpool.Pool
 	storage storage.Provider
 	rdb     *redis.Client
+	hooks   *webhooks.Service
+	queue   *imagequeue.Queue
+	events  *imageevents.Log
 }
 
+// worker-images processes uploaded images from the image_jobs queue.
+//
+//	worker-images                    process jobs until SIGINT/SIGTERM
+//	worker-images dead [-limit 50]   show the dead-letter list
+//	worker-images retry-dead [id]    requeue one dead job, or all of them
+//	worker-images gc [-tenant id] [-grace 24h] [-dry-run]
+//	                                 delete images of deleted products,
+//	                                 services and owners, and files no
+//	                                 image or profile refers to
+//	worker-images placeholders [-tenant id]
+//	                                 compute the BlurHash and color of
+//	                                 images processed before they existed
+//
+// Several workers can run against the same database; each job is handed to
+// one of them at a time.
 func main() {
-	log.Println("🖼️  Image Worker starting...")
-
 	cfg := config.Load()
 
 	db := database.NewPostgresPool(cfg.DatabaseURL)
 	defer db.Close()
 
+	queue := imagequeue.NewQueue(db, imagequeue.Config{
+		MaxAttempts: cfg.ImageMaxAttempts,
+		RetryBase:   time.Duration(cfg.ImageRetryBaseSeconds) * time.Second,
+	})
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "dead":
+			fs := flag.NewFlagSet("dead", flag.ExitOnError)
+			limit := fs.Int("limit", 50, "how many jobs to show")
+			fs.Parse(os.Args[2:])
+			listDead(queue, *limit)
+		case "retry-dead":
+			jobID := ""
+			if len(os.Args) > 2 {
+				jobID = os.Args[2]
+			}
+			retryDead(queue, jobID)
+		case "gc":
+			fs := flag.NewFlagSet("gc", flag.ExitOnError)
+			tenantID := fs.String("tenant", "", "only collect this tenant's images and files")
+			grace := fs.Duration("grace", 24*time.Hour, "leave images and files younger than this alone")
+			dryRun := fs.Bool("dry-run", false, "report what would be deleted without deleting")
+			fs.Parse(os.Args[2:])
+			collectGarbage(cfg, db, storagegc.Options{TenantID: *tenantID, Grace: *grace, DryRun: *dryRun})
+		case "placeholders":
+			fs := flag.NewFlagSet("placeholders", flag.ExitOnError)
+			tenantID := fs.String("tenant", "", "only backfill this tenant's images")
+			fs.Parse(os.Args[2:])
+			backfillPlaceholders(cfg, db, *tenantID)
+		default:
+			fmt.Fprintln(os.Stderr, "usage: worker-images [dead [-limit 50] | retry-dead [job-id] | gc [-tenant id] [-grace 24h] [-dry-run] | placeholders [-tenant id]]")
+			os.Exit(2)
+		}
+		return
+	}
+
+	log.Println("🖼️  Image Worker starting...")
+
 	storageProvider, err := storage.NewProvider(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create storage provider: %v", err)
@@ -88,7 +157,7 @@ func main() {
 	}
 	log.Println("✓ Connected to Redis")
 
-	w := &worker{db: db, storage: storageProvider, rdb: rdb}
+	w := &worker{db: db, storage: storageProvider, rdb: rdb, hooks: webhooks.NewService(db), queue: queue, events: imageevents.NewLog(db, rdb)}
 
 	// Handle graceful shutdown
 	sigCh := make(chan os.Signal, 1)
@@ -103,49 +172,255 @@ func main() {
 		bgCancel()
 	}()
 
-	w.subscribe(bgCtx)
+	w.run(bgCtx)
+}
+
+func listDead(queue *imagequeue.Queue, limit int) {
+	jobs, err := queue.ListDead(context.Background(), limit)
+	if err != nil {
+		log.Fatalf("Unable to list dead jobs: %v", err)
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No dead jobs")
+		return
+	}
+	for _, j := range jobs {
+		lastError := ""
+		if j.LastError != nil {
+			lastError = *j.LastError
+		}
+		fmt.Printf("%s  image %s  tenant %s  %d attempts  %s  %s\n",
+			j.ID, j.ImageID, j.TenantID, j.Attempts, j.FailedAt.Format(time.RFC3339), lastError)
+	}
+}
+
+func retryDead(queue *imagequeue.Queue, jobID string) {
+	n, err := queue.RetryDead(context.Background(), jobID)
+	if err != nil {
+		log.Fatalf("Unable to requeue dead jobs: %v", err)
+	}
+	fmt.Printf("✓ Requeued %d job(s)\n", n)
 }
 
-func (w *worker) subscribe(ctx context.Context) {
-	pubsub := w.rdb.Subscribe(ctx, "image:process")
-	defer pubsub.Close()
+func collectGarbage(cfg *config.Config, db *pgxpool.Pool, opts storagegc.Options) {
+	storageProvider, err := storage.NewProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create storage provider: %v", err)
+	}
+	report, err := storagegc.NewCollector(db, storageProvider).Run(context.Background(), opts)
+	if err != nil {
+		log.Fatalf("Garbage collection failed: %v", err)
+	}
 
-	log.Println("✓ Subscribed to channel: image:process")
+	verb := "Deleted"
+	if opts.DryRun {
+		verb = "Would delete"
+	}
+	for _, img := range report.Images {
+		fmt.Printf("image %s  tenant %s  %s %s  %d file(s)\n",
+			img.ID, img.TenantID, img.ImageableType, img.ImageableID, len(img.Paths))
+	}
+	for _, o := range report.Objects {
+		fmt.Printf("file %s  %d bytes  %s\n", o.Path, o.Size, o.Modified.Format(time.RFC3339))
+	}
+	fmt.Printf("✓ %s %d orphaned image(s) and %d orphaned file(s) (%d bytes)\n",
+		verb, len(report.Images), len(report.Objects), report.Bytes)
+	if report.Failed > 0 {
+		fmt.Printf("⚠ %d file(s) could not be deleted\n", report.Failed)
+	}
+}
 
-	ch := pubsub.Channel()
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Worker context cancelled, exiting")
-			return
-		case msg, ok := <-ch:
-			if !ok {
-				log.Println("Channel closed, exiting")
-				return
+// backfillPlaceholders computes the placeholder of every processed image
+// that has none. Images sharing an original are done once.
+func backfillPlaceholders(cfg *config.Config, db *pgxpool.Pool, tenantID string) {
+	storageProvider, err := storage.NewProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create storage provider: %v", err)
+	}
+	ctx := context.Background()
+	w := &worker{db: db, storage: storageProvider}
+
+	rows, err := db.Query(ctx,
+		`SELECT DISTINCT original_path FROM images
+		 WHERE processing_status = 'completed' AND blurhash IS NULL
+		   AND ($1 = '' OR tenant_id::text = $1)`,
+		tenantID,
+	)
+	if err != nil {
+		log.Fatalf("Unable to list images: %v", err)
+	}
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			log.Fatalf("Unable to list images: %v", err)
+		}
+		paths = append(paths, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Unable to list images: %v", err)
+	}
+
+	failed := 0
+	for _, p := range paths {
+		if err := w.backfillPlaceholder(ctx, p); err != nil {
+			log.Printf("Warning: %s: %v", p, err)
+			failed++
+		}
+	}
+	fmt.Printf("✓ Computed %d placeholder(s)\n", len(paths)-failed)
+	if failed > 0 {
+		fmt.Printf("⚠ %d original(s) could not be processed\n", failed)
+	}
+}
+
+func (w *worker) backfillPlaceholder(ctx context.Context, originalPath string) error {
+	reader, err := w.storage.GetReader(originalPath)
+	if err != nil {
+		return fmt.Errorf("failed to get reader: %w", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read original: %w", err)
+	}
+	img, _, err := decodeImage(data)
+	if err != nil {
+		return err
+	}
+	return w.updatePlaceholder(ctx, originalPath, img)
+}
+
+// run processes jobs until ctx is cancelled. Stale work is swept on start
+// and every sweepInterval.
+func (w *worker) run(ctx context.Context) {
+	w.sweep(ctx)
+	lastSweep := time.Now()
+
+	listener := w.listen(ctx)
+	defer func() {
+		if listener != nil {
+			listener.Release()
+		}
+	}()
+
+	for ctx.Err() == nil {
+		w.drain(ctx)
+
+		if time.Since(lastSweep) >= sweepInterval {
+			w.sweep(ctx)
+			lastSweep = time.Now()
+		}
+
+		if listener == nil {
+			listener = w.listen(ctx)
+		}
+		if listener == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollInterval):
 			}
-			w.handleMessage(ctx, msg.Payload)
+			continue
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, pollInterval)
+		_, err := listener.Conn().WaitForNotification(waitCtx)
+		cancel()
+		if err != nil && waitCtx.Err() == nil {
+			log.Printf("Lost queue listener: %v", err)
+			listener.Conn().Close(context.Background())
+			listener.Release()
+			listener = nil
 		}
 	}
+	log.Println("Worker context cancelled, exiting")
 }
 
-func (w *worker) handleMessage(ctx context.Context, payload string) {
-	var event struct {
-		ImageID string `json:"image_id"`
+// listen returns a connection LISTENing on the queue channel, or nil when
+// one cannot be set up; the worker then just polls
+func (w *worker) listen(ctx context.Context) *pgxpool.Conn {
+	conn, err := w.db.Acquire(ctx)
+	if err != nil {
+		log.Printf("Unable to acquire listener connection: %v", err)
+		return nil
 	}
-	if err := json.Unmarshal([]byte(payload), &event); err != nil {
-		log.Printf("Error parsing message: %v", err)
+	if _, err := conn.Exec(ctx, "LISTEN "+imagequeue.Channel); err != nil {
+		log.Printf("Unable to listen on %s: %v", imagequeue.Channel, err)
+		conn.Release()
+		return nil
+	}
+	log.Printf("✓ Listening for jobs on: %s", imagequeue.Channel)
+	return conn
+}
+
+func (w *worker) sweep(ctx context.Context) {
+	n, dead, err := w.queue.Sweep(ctx, staleAfter)
+	for _, job := range dead {
+		reason := "worker lost while processing"
+		if job.LastError != nil {
+			reason = *job.LastError
+		}
+		log.Printf("Error processing image %s, giving up after %d attempts: %s", job.ImageID, job.Attempts, reason)
+		w.imageFailed(ctx, job.ImageID, reason)
+	}
+	if err != nil {
+		log.Printf("Error sweeping image queue: %v", err)
 		return
 	}
+	if n > 0 {
+		log.Printf("Sweep requeued %d image job(s)", n)
+	}
 
-	log.Printf("Processing image: %s", event.ImageID)
+	if n, err := w.events.Prune(ctx); err != nil {
+		log.Printf("Error pruning image events: %v", err)
+	} else if n > 0 {
+		log.Printf("Sweep pruned %d image event(s)", n)
+	}
+}
 
-	if err := w.processImage(ctx, event.ImageID); err != nil {
-		log.Printf("Error processing image %s: %v", event.ImageID, err)
-	} else {
-		log.Printf("Image %s processed successfully", event.ImageID)
+// drain processes due jobs until there are none left
+func (w *worker) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.queue.Claim(ctx)
+		if err != nil {
+			log.Printf("Error claiming image job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		w.handleJob(ctx, job)
 	}
 }
 
+func (w *worker) handleJob(ctx context.Context, job *imagequeue.Job) {
+	log.Printf("Processing image: %s (attempt %d)", job.ImageID, job.Attempt)
+
+	procErr := w.processImage(ctx, job.ImageID)
+	if procErr == nil {
+		if err := w.queue.Complete(ctx, job); err != nil {
+			log.Printf("Error completing job %s: %v", job.ID, err)
+		}
+		log.Printf("Image %s processed successfully", job.ImageID)
+		return
+	}
+
+	dead, err := w.queue.Fail(ctx, job, procErr)
+	if err != nil {
+		log.Printf("Error recording failure of job %s: %v", job.ID, err)
+		return
+	}
+	if dead {
+		log.Printf("Error processing image %s, giving up after %d attempts: %v", job.ImageID, job.Attempt, procErr)
+		w.updateStatus(ctx, job.ImageID, "failed")
+		w.imageFailed(ctx, job.ImageID, procErr.Error())
+		return
+	}
+	log.Printf("Error processing image %s, retrying in %s: %v", job.ImageID, w.queue.RetryDelay(job.Attempt), procErr)
+	w.updateStatus(ctx, job.ImageID, "pending")
+}
+
 func (w *worker) processImage(ctx context.Context, imageID string) error {
 	// 1. Fetch image record
 	img, err := w.getImage(ctx, imageID)
@@ -153,88 +428,196 @@ func (w *worker) processImage(ctx context.Context, imageID string) error {
 		return fmt.Errorf("failed to get image: %w", err)
 	}
 
-	// 2. Validate
-	if img.ProcessingStatus != "pending" {
-		return fmt.Errorf("image not eligible: status=%s", img.ProcessingStatus)
+	// 2. Validate; a job can be delivered again after it already succeeded
+	if img.ProcessingStatus == "completed" {
+		return nil
 	}
 
 	// 3. Set status to processing
 	if err := w.updateStatus(ctx, imageID, "processing"); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
+	w.appendEvent(ctx, imageID, imageevents.Processing, "")
 
-	// 4. Check tenant convert_webp setting (default true)
-	convertWebp := w.getConvertWebp(ctx, img.TenantID)
+	// 4. Load tenant convert_webp setting (default true) and variant presets;
+	// avatars and logos always get the square profile presets
+	convertWebp, presets, err := w.getImageSettings(ctx, img.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load image settings: %w", err)
+	}
+	if profileimage.IsProfileType(img.ImageableType) {
+		presets = profileimage.Presets
+	}
 
 	// 5. Download original from storage
 	reader, err := w.storage.GetReader(img.OriginalPath)
 	if err != nil {
-		w.updateStatus(ctx, imageID, "failed")
 		return fmt.Errorf("failed to get reader: %w", err)
 	}
-	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read original: %w", err)
+	}
 
-	// 6. Decode image
-	srcImage, format, err := image.Decode(reader)
+	// 6. Decode image. Since every file written below is re-encoded from
+	// pixels, GPS and other metadata are dropped.
+	srcImage, format, err := decodeImage(data)
 	if err != nil {
-		w.updateStatus(ctx, imageID, "failed")
-		return fmt.Errorf("failed to decode image: %w", err)
+		return err
+	}
+
+	// Until step 10 commits, only files are written and the row keeps
+	// pointing at the original it was uploaded with, so a retry after a
+	// failure starts over from the same state. Images uploaded again with
+	// the same content use the files at this path.
+	sharedPath := img.OriginalPath
+	result := processedImage{
+		OriginalPath: img.OriginalPath,
+		OriginalURL:  img.OriginalURL,
+		Extension:    img.Extension,
+		MimeType:     img.MimeType,
+		FileSize:     img.FileSize,
 	}
 
-	// 7. Convert original to WebP if configured and not already webp
-	if convertWebp && format != "webp" {
-		newOrigPath, newOrigURL, newSize, err := w.convertOriginalToWebp(img, srcImage)
+	// 7. Convert original to WebP if configured and not already webp;
+	// otherwise rewrite it in place the first time it is processed, so the
+	// public original carries no metadata either
+	if !convertWebp || format == "webp" {
+		if img.ProcessedAt == nil {
+			size, err := w.stripOriginal(ctx, img, srcImage, format)
+			if err != nil {
+				log.Printf("Warning: failed to rewrite original of image %s: %v", imageID, err)
+			} else if size > 0 {
+				result.FileSize = &size
+			}
+		}
+	} else {
+		newOrigPath, newOrigURL, newSize, err := w.convertOriginalToWebp(ctx, img, srcImage)
 		if err != nil {
 			log.Printf("Warning: failed to convert original to webp for image %s: %v", imageID, err)
 		} else {
-			// Delete old original file
-			oldFullPath := filepath.Join(cfg_storagePath(), img.OriginalPath)
-			os.Remove(oldFullPath)
-			// Update DB record and in-memory struct
-			w.updateOriginalWebp(ctx, imageID, newOrigPath, newOrigURL, newSize)
-			img.OriginalPath = newOrigPath
-			img.Extension = "webp"
+			result.OriginalPath = newOrigPath
+			result.OriginalURL = &newOrigURL
+			result.Extension = "webp"
+			result.MimeType = "image/webp"
+			result.FileSize = &newSize
 		}
 	}
 
-	// 8. Update original dimensions
+	// 8. Original dimensions and the placeholder clients show while the
+	// image loads
 	bounds := srcImage.Bounds()
-	origWidth := bounds.Dx()
-	origHeight := bounds.Dy()
-	w.updateDimensions(ctx, imageID, origWidth, origHeight)
+	result.Width = bounds.Dx()
+	result.Height = bounds.Dy()
+	if hash, color, err := placeholderOf(srcImage); err != nil {
+		log.Printf("Warning: failed to compute placeholder of image %s: %v", imageID, err)
+	} else {
+		result.BlurHash = &hash
+		result.DominantColor = &color
+	}
 
-	// 9. Generate variants and update the same row
-	for _, v := range variants {
-		variantPath, variantURL, err := w.generateVariant(ctx, img, srcImage, format, v, convertWebp)
+	// 9. Generate variants
+	generated := make(map[string]imagevariant.Variant, len(presets))
+	for _, p := range presets {
+		v, err := w.generateVariant(ctx, img, srcImage, format, p, convertWebp)
 		if err != nil {
-			log.Printf("Error generating %s variant for image %s: %v", v.Name, imageID, err)
-			w.updateStatus(ctx, imageID, "failed")
-			return fmt.Errorf("failed to generate variant %s: %w", v.Name, err)
+			log.Printf("Error generating %s variant for image %s: %v", p.Name, imageID, err)
+			return fmt.Errorf("failed to generate variant %s: %w", p.Name, err)
+		}
+		generated[p.Name] = v
+	}
+	result.Variants = generated
+
+	// 10. Save the result and mark as completed, together with the images
+	// sharing the files
+	sharedUsed, err := w.saveProcessed(ctx, imageID, sharedPath, &result)
+	if err != nil {
+		return fmt.Errorf("failed to save processed image: %w", err)
+	}
+
+	// 11. Delete the files no image uses anymore: variants left over from
+	// presets that changed or were removed, and the original the WebP one
+	// replaced. While the image itself still uses sharedPath, nothing was
+	// replaced.
+	w.deleteStaleVariants(img.Variants, generated)
+	if !sharedUsed {
+		if err := w.storage.Delete(sharedPath); err != nil {
+			log.Printf("Warning: failed to delete original %s: %v", sharedPath, err)
 		}
-		// Update the image row with the variant path and URL
-		w.updateVariant(ctx, imageID, v.Name, variantPath, variantURL)
 	}
 
-	// 10. Mark as completed
-	if err := w.updateStatusCompleted(ctx, imageID); err != nil {
-		return fmt.Errorf("failed to mark completed: %w", err)
+	// 12. Point the profile of an avatar or logo at its square variant
+	if profileimage.IsProfileType(img.ImageableType) {
+		url := generated[profileimage.ProfileVariant].URL
+		if err := profileimage.SetProfileURL(ctx, w.db, img.ImageableType, img.ImageableID, imageID, url); err != nil {
+			log.Printf("Warning: failed to update profile of image %s: %v", imageID, err)
+		}
 	}
 
-	// 11. Notify SSE subscribers
+	// 13. Notify SSE subscribers of the image and of the tenant
 	w.publishCompletion(ctx, imageID)
+	w.appendEvent(ctx, imageID, imageevents.Completed, "")
+
+	// 14. Queue the tenant's image.completed webhooks; user avatars belong to
+	// no tenant
+	if img.TenantID != "" {
+		w.emitCompleted(ctx, img.TenantID, imageID)
+	}
 
 	return nil
 }
 
-// publishCompletion notifies the tenant-api SSE endpoint that an image is ready.
+// emitCompleted queues the image.completed webhook event with the image's
+// final dimensions and URLs
+func (w *worker) emitCompleted(ctx context.Context, tenantID, imageID string) {
+	var data struct {
+		ID            string                          `json:"id"`
+		ImageableType string                          `json:"imageable_type"`
+		ImageableID   string                          `json:"imageable_id"`
+		Width         *int                            `json:"width"`
+		Height        *int                            `json:"height"`
+		OriginalURL   *string                         `json:"original_url"`
+		Variants      map[string]imagevariant.Variant `json:"variants"`
+		BlurHash      *string                         `json:"blurhash"`
+		DominantColor *string                         `json:"dominant_color"`
+	}
+	err := w.db.QueryRow(ctx,
+		`SELECT id, imageable_type, imageable_id, width, height, original_url, variants, blurhash, dominant_color
+		 FROM images WHERE id = $1`, imageID,
+	).Scan(&data.ID, &data.ImageableType, &data.ImageableID, &data.Width, &data.Height, &data.OriginalURL, &data.Variants, &data.BlurHash, &data.DominantColor)
+	if err != nil {
+		log.Printf("Warning: failed to load image %s for webhooks: %v", imageID, err)
+		return
+	}
+	if err := w.hooks.Emit(ctx, tenantID, webhooks.EventImageCompleted, data); err != nil {
+		log.Printf("Warning: failed to queue webhooks for image %s: %v", imageID, err)
+	}
+}
+
+// publishCompletion notifies the tenant-api SSE endpoint that an image is done,
+// either completed or failed for good.
 func (w *worker) publishCompletion(ctx context.Context, imageID string) {
 	payload, _ := json.Marshal(map[string]string{"image_id": imageID})
 	w.rdb.Publish(ctx, "image:done:"+imageID, string(payload))
 }
 
-func (w *worker) generateVariant(ctx context.Context, img *imageRow, srcImage image.Image, format string, vc variantConfig, convertWebp bool) (string, string, error) {
-	// Resize using Lanczos
-	resized := imaging.Fit(srcImage, vc.MaxWidth, vc.MaxHeight, imaging.Lanczos)
+// imageFailed notifies the SSE subscribers of an image that failed for good
+func (w *worker) imageFailed(ctx context.Context, imageID, reason string) {
+	w.appendEvent(ctx, imageID, imageevents.Failed, reason)
+	w.publishCompletion(ctx, imageID)
+}
+
+// appendEvent records a processing state change in the tenant's event log
+func (w *worker) appendEvent(ctx context.Context, imageID, eventType, reason string) {
+	if err := w.events.Append(ctx, imageID, eventType, reason); err != nil {
+		log.Printf("Warning: failed to record %s event of image %s: %v", eventType, imageID, err)
+	}
+}
+
+// generateVariant renders one preset of the image and stores it next to the original
+func (w *worker) generateVariant(ctx context.Context, img *imageRow, srcImage image.Image, format string, p imagevariant.Preset, convertWebp bool) (imagevariant.Variant, error) {
+	resized := resize(srcImage, p)
 
 	// Determine output format and extension
 	outExt := img.Extension
@@ -242,127 +625,200 @@ func (w *worker) generateVariant(ctx context.Context, img *imageRow, srcImage im
 		outExt = "webp"
 	}
 
-	// Build variant filename from original path
-	origBase := filepath.Base(img.OriginalPath)
-	nameWithoutExt := strings.TrimSuffix(origBase, filepath.Ext(origBase))
-	variantFilename := fmt.Sprintf("%s_%s.%s", nameWithoutExt, vc.Name, outExt)
-
-	// Build storage path from original path
-	dir := filepath.Dir(img.OriginalPath)
-	variantStoragePath := filepath.Join(dir, variantFilename)
-
-	// Get full filesystem path for local storage
-	storagePath := cfg_storagePath()
-	fullPath := filepath.Join(storagePath, variantStoragePath)
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return "", "", fmt.Errorf("failed to create dir: %w", err)
-	}
-
-	// Create file
-	outFile, err := os.Create(fullPath)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to create file: %w", err)
-	}
-	defer outFile.Close()
+	// Build variant storage path next to the original
+	origBase := path.Base(img.OriginalPath)
+	nameWithoutExt := strings.TrimSuffix(origBase, path.Ext(origBase))
+	variantFilename := fmt.Sprintf("%s_%s.%s", nameWithoutExt, p.Name, outExt)
+	variantStoragePath := path.Join(path.Dir(img.OriginalPath), variantFilename)
 
 	// Encode based on output format
+	var buf bytes.Buffer
+	var contentType string
+	var err error
 	if convertWebp {
-		err = webp.Encode(outFile, resized, &webp.Options{Lossless: false, Quality: 85})
+		contentType = "image/webp"
+		err = webp.Encode(&buf, resized, &webp.Options{Lossless: false, Quality: float32(p.Quality)})
 	} else {
 		switch format {
-		case "jpeg", "jpg":
-			err = jpeg.Encode(outFile, resized, &jpeg.Options{Quality: 90})
 		case "png":
-			err = png.Encode(outFile, resized)
+			contentType = "image/png"
+			err = png.Encode(&buf, resized)
 		default:
-			err = jpeg.Encode(outFile, resized, &jpeg.Options{Quality: 90})
+			contentType = "image/jpeg"
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: p.Quality})
 		}
 	}
 	if err != nil {
-		os.Remove(fullPath)
-		return "", "", fmt.Errorf("failed to encode: %w", err)
+		return imagevariant.Variant{}, fmt.Errorf("failed to encode: %w", err)
 	}
+	size := int64(buf.Len())
 
-	// Build public URL
-	baseURL := cfg_storageBaseURL()
-	variantURL := fmt.Sprintf("%s/%s", baseURL, variantStoragePath)
+	variantURL, err := w.storage.Put(ctx, variantStoragePath, &buf, contentType)
+	if err != nil {
+		return imagevariant.Variant{}, fmt.Errorf("failed to store variant: %w", err)
+	}
+	bounds := resized.Bounds()
+	return imagevariant.Variant{
+		Path:   variantStoragePath,
+		URL:    variantURL,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Size:   size,
+	}, nil
+}
 
-	return variantStoragePath, variantURL, nil
+// resize applies the preset's mode (fit by default) using Lanczos
+func resize(src image.Image, p imagevariant.Preset) image.Image {
+	switch p.Mode {
+	case imagevariant.ModeFill:
+		return imaging.Fill(src, p.Width, p.Height, imaging.Center, imaging.Lanczos)
+	case imagevariant.ModeCrop:
+		return imaging.CropCenter(src, p.Width, p.Height)
+	default:
+		return imaging.Fit(src, p.Width, p.Height, imaging.Lanczos)
+	}
 }
 
-// convertOriginalToWebp re-encodes the original image as WebP and saves it alongside the original.
+// convertOriginalToWebp re-encodes the original image as WebP and stores it alongside the original.
 // Returns the new storage path, public URL, file size, and any error.
-func (w *worker) convertOriginalToWebp(img *imageRow, srcImage image.Image) (string, string, int64, error) {
-	origBase := filepath.Base(img.OriginalPath)
-	nameWithoutExt := strings.TrimSuffix(origBase, filepath.Ext(origBase))
-	webpFilename := nameWithoutExt + ".webp"
-	dir := filepath.Dir(img.OriginalPath)
-	webpStoragePath := filepath.Join(dir, webpFilename)
+func (w *worker) convertOriginalToWebp(ctx context.Context, img *imageRow, srcImage image.Image) (string, string, int64, error) {
+	origBase := path.Base(img.OriginalPath)
+	nameWithoutExt := strings.TrimSuffix(origBase, path.Ext(origBase))
+	webpStoragePath := path.Join(path.Dir(img.OriginalPath), nameWithoutExt+".webp")
 
-	storagePath := cfg_storagePath()
-	fullPath := filepath.Join(storagePath, webpStoragePath)
-
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return "", "", 0, fmt.Errorf("failed to create dir: %w", err)
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, srcImage, &webp.Options{Lossless: false, Quality: originalQuality}); err != nil {
+		return "", "", 0, fmt.Errorf("failed to encode webp: %w", err)
 	}
+	size := int64(buf.Len())
 
-	outFile, err := os.Create(fullPath)
+	webpURL, err := w.storage.Put(ctx, webpStoragePath, &buf, "image/webp")
 	if err != nil {
-		return "", "", 0, fmt.Errorf("failed to create file: %w", err)
+		return "", "", 0, fmt.Errorf("failed to store webp: %w", err)
 	}
+	return webpStoragePath, webpURL, size, nil
+}
 
-	if err := webp.Encode(outFile, srcImage, &webp.Options{Lossless: false, Quality: 85}); err != nil {
-		outFile.Close()
-		os.Remove(fullPath)
-		return "", "", 0, fmt.Errorf("failed to encode webp: %w", err)
+// stripOriginal re-encodes the original in its own format from the decoded,
+// upright pixels, writes it over the stored file and returns its new size.
+// GIFs are left alone, with a size of 0: they carry no EXIF and re-encoding
+// would drop their animation.
+func (w *worker) stripOriginal(ctx context.Context, img *imageRow, srcImage image.Image, format string) (int64, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, srcImage, &jpeg.Options{Quality: originalQuality})
+	case "png":
+		err = png.Encode(&buf, srcImage)
+	case "webp":
+		err = webp.Encode(&buf, srcImage, &webp.Options{Lossless: false, Quality: originalQuality})
+	default:
+		return 0, nil
 	}
-	outFile.Close()
-
-	fi, _ := os.Stat(fullPath)
-	var size int64
-	if fi != nil {
-		size = fi.Size()
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode: %w", err)
 	}
+	size := int64(buf.Len())
+	if _, err := w.storage.Put(ctx, img.OriginalPath, &buf, "image/"+format); err != nil {
+		return 0, fmt.Errorf("failed to store: %w", err)
+	}
+	return size, nil
+}
 
-	baseURL := cfg_storageBaseURL()
-	webpURL := fmt.Sprintf("%s/%s", baseURL, webpStoragePath)
-
-	return webpStoragePath, webpURL, size, nil
+// processedImage is what processing an image produced: its original, which
+// may have been converted, and the variants generated from it
+type processedImage struct {
+	OriginalPath  string
+	OriginalURL   *string
+	Extension     string
+	MimeType      string
+	FileSize      *int64
+	Width         int
+	Height        int
+	BlurHash      *string
+	DominantColor *string
+	Variants      map[string]imagevariant.Variant
 }
 
-// updateOriginalWebp updates the images row to reflect the newly converted WebP original.
-func (w *worker) updateOriginalWebp(ctx context.Context, imageID, newPath, newURL string, fileSize int64) {
-	w.db.Exec(ctx,
-		`UPDATE images SET original_path = $1, original_url = $2, extension = 'webp', mime_type = 'image/webp', file_size = $3, updated_at = NOW() WHERE id = $4`,
-		newPath, newURL, fileSize, imageID,
+// saveProcessed stores the result of processing an image and marks it
+// completed. The other images that shared its files at sharedPath, which
+// were uploaded with the same content and never processed on their own,
+// get the same original and variants. It reports whether any image still
+// uses the files at sharedPath.
+//
+// Like the tenant API's DeleteImage, it holds the advisory lock on
+// sharedPath while moving the images and checking for remaining users, so
+// no duplicate is created from the files in between. Once no image uses
+// the path none can start to, so the caller may delete the file after the
+// commit.
+func (w *worker) saveProcessed(ctx context.Context, imageID, sharedPath string, p *processedImage) (bool, error) {
+	variants, err := json.Marshal(p.Variants)
+	if err != nil {
+		return false, err
+	}
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, sharedPath); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE images SET original_path = $2, original_url = $3, extension = $4, mime_type = $5, file_size = $6,
+		        width = $7, height = $8, blurhash = COALESCE($9, blurhash), dominant_color = COALESCE($10, dominant_color),
+		        variants = $11::jsonb, processing_status = 'completed', processed_at = NOW(), updated_at = NOW()
+		 WHERE id = $1`,
+		imageID, p.OriginalPath, p.OriginalURL, p.Extension, p.MimeType, p.FileSize,
+		p.Width, p.Height, p.BlurHash, p.DominantColor, string(variants),
+	)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE images d SET original_path = s.original_path, original_url = s.original_url, extension = s.extension, mime_type = s.mime_type,
+		        file_size = s.file_size, width = s.width, height = s.height, variants = s.variants,
+		        blurhash = s.blurhash, dominant_color = s.dominant_color, updated_at = NOW()
+		 FROM images s
+		 WHERE s.id = $1 AND d.id <> s.id AND d.original_path = $2`,
+		imageID, sharedPath,
 	)
+	if err != nil {
+		return false, err
+	}
+	var used bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM images WHERE original_path = $1)`, sharedPath).Scan(&used)
+	if err != nil {
+		return false, err
+	}
+	return used, tx.Commit(ctx)
 }
 
-// updateVariant updates the image row with the path and URL for a specific variant (medium, small, thumb).
-func (w *worker) updateVariant(ctx context.Context, imageID, variantName, variantPath, variantURL string) {
-	var col string
-	switch variantName {
-	case "medium":
-		col = "medium"
-	case "small":
-		col = "small"
-	case "thumb":
-		col = "thumb"
-	default:
-		return
+// deleteStaleVariants removes the files of previous variants that were not
+// overwritten by the current ones
+func (w *worker) deleteStaleVariants(previous, current map[string]imagevariant.Variant) {
+	kept := make(map[string]bool, len(current))
+	for _, v := range current {
+		kept[v.Path] = true
+	}
+	for _, v := range previous {
+		if v.Path == "" || kept[v.Path] {
+			continue
+		}
+		if err := w.storage.Delete(v.Path); err != nil {
+			log.Printf("Warning: failed to delete stale variant %s: %v", v.Path, err)
+		}
 	}
-	query := fmt.Sprintf(`UPDATE images SET %s_path = $1, %s_url = $2, updated_at = NOW() WHERE id = $3`, col, col)
-	w.db.Exec(ctx, query, variantPath, variantURL, imageID)
 }
 
 func (w *worker) getImage(ctx context.Context, imageID string) (*imageRow, error) {
 	var img imageRow
 	err := w.db.QueryRow(ctx,
-		`SELECT id, tenant_id, imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url, processing_status, file_size
+		`SELECT id, COALESCE(tenant_id::text, ''), imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url, processing_status, file_size, variants, processed_at
 		 FROM images WHERE id = $1`, imageID,
-	).Scan(&img.ID, &img.TenantID, &img.ImageableType, &img.ImageableID, &img.OriginalFilename, &img.MimeType, &img.Extension, &img.StorageDriver, &img.OriginalPath, &img.OriginalURL, &img.ProcessingStatus, &img.FileSize)
+	).Scan(&img.ID, &img.TenantID, &img.ImageableType, &img.ImageableID, &img.OriginalFilename, &img.MimeType, &img.Extension, &img.StorageDriver, &img.OriginalPath, &img.OriginalURL, &img.ProcessingStatus, &img.FileSize, &img.Variants, &img.ProcessedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -376,42 +832,83 @@ func (w *worker) updateStatus(ctx context.Context, imageID, status string) error
 	return err
 }
 
-func (w *worker) updateStatusCompleted(ctx context.Context, imageID string) error {
-	_, err := w.db.Exec(ctx,
-		`UPDATE images SET processing_status = 'completed', processed_at = NOW(), updated_at = NOW() WHERE id = $1`, imageID,
+// updatePlaceholder stores the BlurHash and average color of img on the
+// images using the original at originalPath
+func (w *worker) updatePlaceholder(ctx context.Context, originalPath string, img image.Image) error {
+	hash, color, err := placeholderOf(img)
+	if err != nil {
+		return err
+	}
+	_, err = w.db.Exec(ctx,
+		`UPDATE images SET blurhash = $1, dominant_color = $2, updated_at = NOW() WHERE original_path = $3`,
+		hash, color, originalPath,
 	)
 	return err
 }
 
-func (w *worker) updateDimensions(ctx context.Context, imageID string, width, height int) {
-	w.db.Exec(ctx,
-		`UPDATE images SET width = $1, height = $2, updated_at = NOW() WHERE id = $3`, width, height, imageID,
-	)
+// placeholderOf computes the BlurHash and average color of img from a
+// small sample of it
+func placeholderOf(img image.Image) (string, string, error) {
+	sample := imaging.Fit(img, placeholder.SampleSize, placeholder.SampleSize, imaging.Box)
+	hash, err := placeholder.BlurHash(sample, placeholder.XComponents, placeholder.YComponents)
+	if err != nil {
+		return "", "", err
+	}
+	return hash, placeholder.AverageColor(sample), nil
 }
 
-func (w *worker) getConvertWebp(ctx context.Context, tenantID string) bool {
-	var convertWebp bool
-	err := w.db.QueryRow(ctx,
-		`SELECT convert_webp FROM tenant_settings WHERE tenant_id = $1`, tenantID,
-	).Scan(&convertWebp)
+// decodeImage decodes an original, applying its EXIF orientation. The
+// header is checked first so a decompression bomb that slipped past upload
+// validation never gets its pixels allocated.
+func decodeImage(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image header: %w", err)
+	}
+	if cfg.Width*cfg.Height > upload.MaxPixels {
+		return nil, "", fmt.Errorf("image too large: %dx%d", cfg.Width, cfg.Height)
+	}
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
 	if err != nil {
-		// Default to true if no settings row exists
-		return true
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
 	}
-	return convertWebp
+	return img, format, nil
 }
 
-// Config helpers — read from environment with defaults
-func cfg_storagePath() string {
-	if v := os.Getenv("STORAGE_LOCAL_PATH"); v != "" {
-		return v
+// getImageSettings returns the tenant's convert_webp setting and variant
+// presets, clamped to what its current plan allows
+func (w *worker) getImageSettings(ctx context.Context, tenantID string) (bool, []imagevariant.Preset, error) {
+	if tenantID == "" {
+		return true, imagevariant.Defaults, nil
+	}
+	var convertWebp *bool
+	var rawPresets []byte
+	var maxVariants, maxSize *int
+	err := w.db.QueryRow(ctx,
+		`SELECT s.convert_webp, s.image_variants, p.max_image_variants, p.max_image_size
+		 FROM tenants t
+		 LEFT JOIN tenant_settings s ON s.tenant_id = t.id
+		 LEFT JOIN tenant_plans tp ON tp.tenant_id = t.id AND tp.is_active = true
+		 LEFT JOIN saas_plans p ON p.id = tp.plan_id
+		 WHERE t.id = $1
+		 LIMIT 1`, tenantID,
+	).Scan(&convertWebp, &rawPresets, &maxVariants, &maxSize)
+	if err != nil {
+		return false, nil, err
 	}
-	return "./uploads"
-}
 
-func cfg_storageBaseURL() string {
-	if v := os.Getenv("STORAGE_BASE_URL"); v != "" {
-		return v
+	var presets []imagevariant.Preset
+	if rawPresets != nil {
+		if err := json.Unmarshal(rawPresets, &presets); err != nil {
+			log.Printf("Warning: invalid image variants of tenant %s, using defaults: %v", tenantID, err)
+			presets = nil
+		}
+	}
+	limits := imagevariant.DefaultLimits
+	if maxVariants != nil && maxSize != nil {
+		limits = imagevariant.Limits{MaxVariants: *maxVariants, MaxSize: *maxSize}
 	}
-	return "http://localhost:8080/uploads"
+
+	// Default to true if no settings row exists
+	return convertWebp == nil || *convertWebp, imagevariant.Clamp(presets, limits), nil
 }
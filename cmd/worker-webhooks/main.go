@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/saas-single-db-api/internal/config"
+	"github.com/saas-single-db-api/internal/database"
+	"github.com/saas-single-db-api/internal/webhooks"
+)
+
+// pollInterval is how often the delivery queue is checked for due deliveries
+const pollInterval = 2 * time.Second
+
+func main() {
+	log.Println("🔔 Webhook Worker starting...")
+
+	cfg := config.Load()
+
+	db := database.NewPostgresPool(cfg.DatabaseURL)
+	defer db.Close()
+
+	dispatcher := webhooks.NewDispatcher(db, nil)
+
+	// Handle graceful shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+
+	go func() {
+		<-sigCh
+		log.Println("Shutting down worker...")
+		bgCancel()
+	}()
+
+	log.Printf("✓ Delivering webhooks every %s", pollInterval)
+	dispatcher.Run(bgCtx, pollInterval)
+}
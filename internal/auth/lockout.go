@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/saas-single-db-api/internal/cache"
+)
+
+const (
+	// loginIPMaxFailures limits failed logins from one IP within loginIPWindow,
+	// whatever the accounts tried
+	loginIPMaxFailures = 30
+	loginIPWindow      = 15 * time.Minute
+	// loginMaxFailures is the number of consecutive failures an account
+	// tolerates before it is locked. Every further failure doubles the lock,
+	// from loginLockBase up to loginLockMax.
+	loginMaxFailures = 5
+	loginLockBase    = time.Minute
+	loginLockMax     = time.Hour
+	// loginFailureWindow is how long failures are remembered after the last one
+	loginFailureWindow = 24 * time.Hour
+)
+
+// LoginThrottledError is returned when a login is refused before the password
+// is checked. Its message is the i18n key to respond with.
+type LoginThrottledError struct {
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return e.Key
+}
+
+// RetryAfterSeconds is the value for the Retry-After header
+func (e *LoginThrottledError) RetryAfterSeconds() string {
+	return strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// LoginGuard protects password logins against brute force. Failures are
+// counted per client IP and per account; an account is identified by scope
+// (the subject type, and the tenant for app users) and email, so it works
+// the same whether or not the email exists.
+type LoginGuard struct {
+	redis *redis.Client
+}
+
+// NewLoginGuard creates a new login guard
+func NewLoginGuard(redisClient *redis.Client) *LoginGuard {
+	return &LoginGuard{redis: redisClient}
+}
+
+// AppScope is the login scope of app users of a tenant
+func AppScope(tenantID string) string {
+	return SubjectApp + ":" + tenantID
+}
+
+// Check refuses the attempt when the IP or the account is locked out
+func (g *LoginGuard) Check(ctx context.Context, scope, email, ip string) error {
+	if ttl, ok := g.blocked(ctx, ipFailuresKey(ip), loginIPMaxFailures); ok {
+		return &LoginThrottledError{Key: "too_many_login_attempts", RetryAfter: ttl}
+	}
+	if ttl, err := g.redis.TTL(ctx, lockKey(scope, email)).Result(); err == nil && ttl > 0 {
+		return &LoginThrottledError{Key: "account_temporarily_locked", RetryAfter: ttl}
+	}
+	return nil
+}
+
+// Fail records a failed attempt and returns cause, or the lockout error when
+// this failure locked the account
+func (g *LoginGuard) Fail(ctx context.Context, scope, email, ip string, cause error) error {
+	cache.IncrWithTTL(g.redis, ctx, ipFailuresKey(ip), loginIPWindow)
+
+	key := failuresKey(scope, email)
+	failures, err := g.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return cause
+	}
+	g.redis.Expire(ctx, key, loginFailureWindow)
+	if failures < loginMaxFailures {
+		return cause
+	}
+
+	lock := loginLockBase << min(failures-loginMaxFailures, 30)
+	if lock > loginLockMax || lock <= 0 {
+		lock = loginLockMax
+	}
+	g.redis.Set(ctx, lockKey(scope, email), "1", lock)
+	return &LoginThrottledError{Key: "account_temporarily_locked", RetryAfter: lock}
+}
+
+// Succeed clears the account's failures once a login is complete, after
+// the password and any second factor
+func (g *LoginGuard) Succeed(ctx context.Context, scope, email string) {
+	g.redis.Del(ctx, failuresKey(scope, email))
+}
+
+// Unlock lifts an account lock and forgets its failures
+func (g *LoginGuard) Unlock(ctx context.Context, scope, email string) error {
+	return g.redis.Del(ctx, lockKey(scope, email), failuresKey(scope, email)).Err()
+}
+
+// blocked reports whether the counter at key reached max, with its remaining TTL
+func (g *LoginGuard) blocked(ctx context.Context, key string, max int64) (time.Duration, bool) {
+	n, err := g.redis.Get(ctx, key).Int64()
+	if err != nil || n < max {
+		return 0, false
+	}
+	ttl, err := g.redis.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+func ipFailuresKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+func failuresKey(scope, email string) string {
+	return "login:failures:" + scope + ":" + normalizeEmail(email)
+}
+
+func lockKey(scope, email string) string {
+	return "login:lock:" + scope + ":" + normalizeEmail(email)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/saas-single-db-api/internal/cache"
+	"github.com/saas-single-db-api/internal/utils"
+)
+
+var (
+	ErrInvalidMFAToken   = errors.New("invalid_mfa_token")
+	ErrInvalidMFACode    = errors.New("invalid_mfa_code")
+	ErrMFAAlreadyEnabled = errors.New("two_factor_already_enabled")
+	ErrMFANotEnabled     = errors.New("two_factor_not_enabled")
+	ErrMFASetupRequired  = errors.New("two_factor_setup_required")
+)
+
+const (
+	mfaChallengeTTL       = 5 * time.Minute
+	mfaChallengeAttempts  = 5
+	mfaRecoveryCodeCount  = 10
+	mfaRecoveryCodeLength = 10
+)
+
+// MFAService manages TOTP second factors and the short-lived challenges that
+// sit between a successful password check and token issuance.
+type MFAService struct {
+	db     *pgxpool.Pool
+	redis  *redis.Client
+	issuer string
+}
+
+// NewMFAService creates a new MFA service. issuer is shown in authenticator apps.
+func NewMFAService(db *pgxpool.Pool, redisClient *redis.Client, issuer string) *MFAService {
+	return &MFAService{db: db, redis: redisClient, issuer: issuer}
+}
+
+// Enrollment is what a client needs to add the account to an authenticator app
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// Challenge is the state carried by an mfa_token between the two login steps.
+// Enroll is set when the subject must set up 2FA before logging in.
+type Challenge struct {
+	SubjectType string `json:"subject_type"`
+	SubjectID   string `json:"subject_id"`
+	Enroll      bool   `json:"enroll,omitempty"`
+}
+
+// IsEnabled reports whether the subject has an active second factor
+func (s *MFAService) IsEnabled(ctx context.Context, subjectType, subjectID string) bool {
+	var enabled bool
+	err := s.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM two_factor_secrets WHERE subject_type = $1 AND subject_id = $2 AND enabled_at IS NOT NULL)`,
+		subjectType, subjectID,
+	).Scan(&enabled)
+	return err == nil && enabled
+}
+
+// Setup generates a new pending secret for the subject. It is not enforced
+// until confirmed with Enable; calling Setup again replaces the pending secret.
+func (s *MFAService) Setup(ctx context.Context, subjectType, subjectID, account string) (*Enrollment, error) {
+	if s.IsEnabled(ctx, subjectType, subjectID) {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.Exec(ctx,
+		`INSERT INTO two_factor_secrets (subject_type, subject_id, secret) VALUES ($1, $2, $3)
+		 ON CONFLICT (subject_type, subject_id) DO UPDATE SET secret = EXCLUDED.secret, enabled_at = NULL, updated_at = NOW()`,
+		subjectType, subjectID, secret,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Enrollment{Secret: secret, URI: TOTPURI(s.issuer, account, secret)}, nil
+}
+
+// Enable confirms a pending secret with a code from the authenticator app and
+// returns a fresh set of recovery codes.
+func (s *MFAService) Enable(ctx context.Context, subjectType, subjectID, code string) ([]string, error) {
+	var (
+		secret    string
+		enabledAt *time.Time
+	)
+	err := s.db.QueryRow(ctx,
+		`SELECT secret, enabled_at FROM two_factor_secrets WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	).Scan(&secret, &enabledAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMFASetupRequired
+		}
+		return nil, err
+	}
+	if enabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if !s.checkTOTP(ctx, subjectType, subjectID, secret, code) {
+		return nil, ErrInvalidMFACode
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE two_factor_secrets SET enabled_at = NOW(), updated_at = NOW() WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(ctx, tx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code or, failing that, consumes a recovery code
+func (s *MFAService) Verify(ctx context.Context, subjectType, subjectID, code string) error {
+	var secret string
+	err := s.db.QueryRow(ctx,
+		`SELECT secret FROM two_factor_secrets WHERE subject_type = $1 AND subject_id = $2 AND enabled_at IS NOT NULL`,
+		subjectType, subjectID,
+	).Scan(&secret)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMFANotEnabled
+		}
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if s.checkTOTP(ctx, subjectType, subjectID, secret, code) {
+		return nil
+	}
+
+	tag, err := s.db.Exec(ctx,
+		`UPDATE two_factor_recovery_codes SET used_at = NOW()
+		 WHERE subject_type = $1 AND subject_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		subjectType, subjectID, utils.HashToken(normalizeRecoveryCode(code)),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// Disable removes the subject's second factor and recovery codes
+func (s *MFAService) Disable(ctx context.Context, subjectType, subjectID string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM two_factor_secrets WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM two_factor_recovery_codes WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RegenerateRecoveryCodes invalidates the current recovery codes and returns new ones
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, subjectType, subjectID string) ([]string, error) {
+	if !s.IsEnabled(ctx, subjectType, subjectID) {
+		return nil, ErrMFANotEnabled
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	codes, err := replaceRecoveryCodes(ctx, tx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// NewChallenge stores a challenge and returns the opaque mfa_token for it
+func (s *MFAService) NewChallenge(ctx context.Context, ch Challenge) (string, error) {
+	data, err := json.Marshal(ch)
+	if err != nil {
+		return "", err
+	}
+	token := utils.GenerateVerificationToken()
+	if err := s.redis.Set(ctx, challengeKey(token), data, mfaChallengeTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Challenge loads the challenge for an mfa_token. Each call counts as an
+// attempt; the challenge is dropped once the attempts are exhausted.
+func (s *MFAService) Challenge(ctx context.Context, token string) (*Challenge, error) {
+	key := challengeKey(token)
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	attempts, err := cache.IncrWithTTL(s.redis, ctx, key+":attempts", mfaChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > mfaChallengeAttempts {
+		s.redis.Del(ctx, key, key+":attempts")
+		return nil, ErrInvalidMFAToken
+	}
+
+	var ch Challenge
+	if err := json.Unmarshal(data, &ch); err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	return &ch, nil
+}
+
+// ClearChallenge invalidates an mfa_token once the login completed
+func (s *MFAService) ClearChallenge(ctx context.Context, token string) {
+	key := challengeKey(token)
+	s.redis.Del(ctx, key, key+":attempts")
+}
+
+// checkTOTP validates a code and rejects reuse of a time step that was
+// already accepted for the subject
+func (s *MFAService) checkTOTP(ctx context.Context, subjectType, subjectID, secret, code string) bool {
+	step, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	key := fmt.Sprintf("mfa:totp_used:%s:%s:%d", subjectType, subjectID, step)
+	fresh, err := s.redis.SetNX(ctx, key, "1", time.Duration(2*totpSkew+1)*totpPeriod*time.Second).Result()
+	return err == nil && fresh
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, subjectType, subjectID string) ([]string, error) {
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM two_factor_recovery_codes WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, mfaRecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, mfaRecoveryCodeLength/2)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:mfaRecoveryCodeLength/2] + "-" + raw[mfaRecoveryCodeLength/2:]
+
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO two_factor_recovery_codes (subject_type, subject_id, code_hash) VALUES ($1, $2, $3)`,
+			subjectType, subjectID, utils.HashToken(raw),
+		); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(code, "-", ""), " ", ""))
+}
+
+func challengeKey(token string) string {
+	return "mfa:challenge:" + utils.HashToken(token)
+}
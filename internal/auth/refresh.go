@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/saas-single-db-api/internal/utils"
+)
+
+// Refresh tokens are opaque and grouped in families, one per session: each
+// successful refresh marks the presented token as used and issues a new one in
+// the same family. Presenting a token that was already used revokes the session.
+
+// Grant identifies who a refresh token was issued to
+type Grant struct {
+	SessionID string
+	SubjectID string
+	TenantID  string
+}
+
+func (s *SessionService) issueRefresh(ctx context.Context, tx pgx.Tx, sessionID, subjectType, subjectID, tenantID string) (string, error) {
+	token := utils.GenerateVerificationToken()
+	_, err := tx.Exec(ctx,
+		`INSERT INTO refresh_tokens (family_id, subject_type, subject_id, tenant_id, token_hash, expires_at)
+		 VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6)`,
+		sessionID, subjectType, subjectID, tenantID, utils.HashToken(token), time.Now().Add(s.refreshTTL),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Rotate consumes a refresh token and returns its grant with a new token for
+// the same session. When tenantID is set, tokens issued for other tenants are
+// rejected. Reuse of an already rotated token revokes the session and returns
+// ErrRefreshTokenReused.
+func (s *SessionService) Rotate(ctx context.Context, subjectType, tenantID, token string) (*Grant, string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id        string
+		g         Grant
+		grantTID  *string
+		expiresAt time.Time
+		usedAt    *time.Time
+		revokedAt *time.Time
+	)
+	err = tx.QueryRow(ctx,
+		`SELECT id, family_id, subject_id, tenant_id, expires_at, used_at, revoked_at
+		 FROM refresh_tokens
+		 WHERE token_hash = $1 AND subject_type = $2 AND ($3 = '' OR tenant_id = NULLIF($3, '')::uuid)
+		 FOR UPDATE`,
+		utils.HashToken(token), subjectType, tenantID,
+	).Scan(&id, &g.SessionID, &g.SubjectID, &grantTID, &expiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", ErrInvalidRefreshToken
+		}
+		return nil, "", err
+	}
+	if grantTID != nil {
+		g.TenantID = *grantTID
+	}
+
+	if revokedAt != nil || time.Now().After(expiresAt) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if usedAt != nil {
+		// Replay: someone holds a stale copy of this token, kill the session
+		if _, err := tx.Exec(ctx,
+			`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`,
+			g.SessionID,
+		); err != nil {
+			return nil, "", err
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`,
+			g.SessionID,
+		); err != nil {
+			return nil, "", err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, "", err
+		}
+		s.markRevoked(ctx, g.SessionID)
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, id); err != nil {
+		return nil, "", err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE sessions SET last_seen_at = NOW() WHERE id = $1`, g.SessionID); err != nil {
+		return nil, "", err
+	}
+
+	newToken, err := s.issueRefresh(ctx, tx, g.SessionID, subjectType, g.SubjectID, g.TenantID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, "", err
+	}
+	return &g, newToken, nil
+}
+
+// Rescope moves a session to another tenant (or none, when tenantID is empty).
+// Outstanding refresh tokens of the session are revoked and a new one is issued
+// for the new scope.
+func (s *SessionService) Rescope(ctx context.Context, subjectType, subjectID, sessionID, tenantID string) (string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE sessions SET tenant_id = NULLIF($4, '')::uuid, last_seen_at = NOW()
+		 WHERE id = $1 AND subject_type = $2 AND subject_id = $3 AND revoked_at IS NULL`,
+		sessionID, subjectType, subjectID, tenantID,
+	)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", ErrSessionNotFound
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND used_at IS NULL AND revoked_at IS NULL`,
+		sessionID,
+	); err != nil {
+		return "", err
+	}
+
+	token, err := s.issueRefresh(ctx, tx, sessionID, subjectType, subjectID, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
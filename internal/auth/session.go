@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Subject types stored in sessions.subject_type and refresh_tokens.subject_type
+const (
+	SubjectAdmin = "admin"
+	SubjectUser  = "user"
+	SubjectApp   = "app"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid_refresh_token")
+	ErrRefreshTokenReused  = errors.New("refresh_token_reused")
+	ErrSessionNotFound     = errors.New("session_not_found")
+)
+
+// touchInterval limits how often last_seen_at is written for a session
+const touchInterval = time.Minute
+
+// SessionService keeps the registry of logged-in sessions and their refresh
+// tokens. The session id is the `jti` of every access token issued for it and
+// the family id of its refresh tokens, so a session survives token refreshes.
+//
+// Revocations are mirrored to Redis for as long as an access token can live,
+// which lets the auth middlewares reject revoked sessions without a DB hit.
+type SessionService struct {
+	db         *pgxpool.Pool
+	redis      *redis.Client
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(db *pgxpool.Pool, redisClient *redis.Client, accessTTL, refreshTTL time.Duration) *SessionService {
+	return &SessionService{db: db, redis: redisClient, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// Client describes the device a session was started from
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is a row of the session registry
+type Session struct {
+	ID         string    `json:"id"`
+	TenantID   *string   `json:"tenant_id,omitempty"`
+	UserAgent  *string   `json:"user_agent"`
+	IPAddress  *string   `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// Start registers a new session and issues its first refresh token.
+// tenantID may be empty for subjects that are not tenant scoped.
+func (s *SessionService) Start(ctx context.Context, subjectType, subjectID, tenantID string, client Client) (string, string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var sessionID string
+	err = tx.QueryRow(ctx,
+		`INSERT INTO sessions (subject_type, subject_id, tenant_id, user_agent, ip_address)
+		 VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, ''), NULLIF($5, ''))
+		 RETURNING id`,
+		subjectType, subjectID, tenantID, client.UserAgent, client.IP,
+	).Scan(&sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := s.issueRefresh(ctx, tx, sessionID, subjectType, subjectID, tenantID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", "", err
+	}
+	return sessionID, refreshToken, nil
+}
+
+// List returns the active sessions of a subject, most recently used first.
+// currentID marks the session making the request.
+func (s *SessionService) List(ctx context.Context, subjectType, subjectID, currentID string) ([]Session, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT id, tenant_id, user_agent, ip_address, created_at, last_seen_at
+		 FROM sessions
+		 WHERE subject_type = $1 AND subject_id = $2 AND revoked_at IS NULL
+		   AND EXISTS (
+		     SELECT 1 FROM refresh_tokens rt
+		     WHERE rt.family_id = sessions.id AND rt.revoked_at IS NULL AND rt.used_at IS NULL AND rt.expires_at > NOW()
+		   )
+		 ORDER BY last_seen_at DESC`,
+		subjectType, subjectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var ss Session
+		if err := rows.Scan(&ss.ID, &ss.TenantID, &ss.UserAgent, &ss.IPAddress, &ss.CreatedAt, &ss.LastSeenAt); err != nil {
+			return nil, err
+		}
+		ss.Current = ss.ID == currentID
+		sessions = append(sessions, ss)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke ends one session of the subject
+func (s *SessionService) Revoke(ctx context.Context, subjectType, subjectID, sessionID string) error {
+	ids, err := s.revoke(ctx,
+		`id::text = $1 AND subject_type = $2 AND subject_id = $3`,
+		sessionID, subjectType, subjectID,
+	)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAll ends every session of the subject except exceptID, which may be
+// empty (e.g. after a password reset).
+func (s *SessionService) RevokeAll(ctx context.Context, subjectType, subjectID, exceptID string) error {
+	_, err := s.revoke(ctx,
+		`subject_type = $1 AND subject_id = $2 AND id::text <> $3`,
+		subjectType, subjectID, exceptID,
+	)
+	return err
+}
+
+// IsRevoked reports whether the session was revoked while access tokens
+// issued for it may still be valid.
+func (s *SessionService) IsRevoked(ctx context.Context, sessionID string) bool {
+	n, err := s.redis.Exists(ctx, revokedKey(sessionID)).Result()
+	return err == nil && n > 0
+}
+
+// Touch records activity on a session, at most once per touchInterval
+func (s *SessionService) Touch(ctx context.Context, sessionID string) {
+	ok, err := s.redis.SetNX(ctx, "session:seen:"+sessionID, "1", touchInterval).Result()
+	if err != nil || !ok {
+		return
+	}
+	_, _ = s.db.Exec(ctx, `UPDATE sessions SET last_seen_at = NOW() WHERE id = $1`, sessionID)
+}
+
+// revoke marks the sessions matched by where (and their refresh tokens) as
+// revoked and flags them in Redis. It returns the ids that were revoked.
+func (s *SessionService) revoke(ctx context.Context, where string, args ...interface{}) ([]string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE sessions SET revoked_at = NOW()
+		 WHERE revoked_at IS NULL AND `+where+`
+		 RETURNING id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = ANY($1::uuid[]) AND revoked_at IS NULL`,
+		ids,
+	); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	s.markRevoked(ctx, ids...)
+	return ids, nil
+}
+
+func (s *SessionService) markRevoked(ctx context.Context, ids ...string) {
+	pipe := s.redis.Pipeline()
+	for _, id := range ids {
+		pipe.Set(ctx, revokedKey(id), "1", s.accessTTL)
+	}
+	_, _ = pipe.Exec(ctx)
+}
+
+func revokedKey(sessionID string) string {
+	return "session:revoked:" + sessionID
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 Appendix B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; a 6-digit code is the same value
+	// truncated to its last six digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		want := v.code[len(v.code)-totpDigits:]
+		if got := totpCode(key, uint64(v.unix/totpPeriod)); got != want {
+			t.Errorf("T=%d: code = %s, want %s", v.unix, got, want)
+		}
+		step, ok := matchTOTP(rfcSecret, want, time.Unix(v.unix, 0))
+		if !ok || step != v.unix/totpPeriod {
+			t.Errorf("T=%d: matchTOTP = %d, %v", v.unix, step, ok)
+		}
+	}
+}
+
+func TestMatchTOTPWindow(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+
+	for offset := int64(-3); offset <= 3; offset++ {
+		code := totpCode(key, uint64(step+offset))
+		got, ok := matchTOTP(rfcSecret, code, now)
+		inWindow := offset >= -totpSkew && offset <= totpSkew
+		if ok != inWindow {
+			t.Errorf("code of step %+d: accepted = %v, want %v", offset, ok, inWindow)
+		}
+		if ok && got != step+offset {
+			t.Errorf("code of step %+d: matched step %d, want %d", offset, got, step+offset)
+		}
+	}
+
+	// A code keeps matching its own step while it is in the window, which
+	// is what the replay check keys on
+	code := totpCode(key, uint64(step))
+	for _, d := range []time.Duration{-totpPeriod * time.Second, 0, totpPeriod * time.Second} {
+		if got, ok := matchTOTP(rfcSecret, code, now.Add(d)); !ok || got != step {
+			t.Errorf("at %s: matchTOTP = %d, %v, want %d", d, got, ok, step)
+		}
+	}
+
+	code = totpCode(key, uint64(step))
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"wrong code", rfcSecret, fmt.Sprintf("%0*d", totpDigits, (mustAtoi(code)+1)%int(totpModulus))},
+		{"short code", rfcSecret, code[1:]},
+		{"long code", rfcSecret, code + "0"},
+		{"invalid secret", "not base32!", code},
+	}
+	for _, tt := range tests {
+		if _, ok := matchTOTP(tt.secret, tt.code, now); ok {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+	if _, ok := matchTOTP(strings.ToLower(rfcSecret), code, now); !ok {
+		t.Error("lowercase secret: rejected")
+	}
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// fakeRedis answers SET ... NX, all checkTOTP needs, over RESP. Other
+// commands, like the client's HELLO handshake, get an error reply, which
+// the client tolerates.
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{keys: map[string]bool{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2})
+	t.Cleanup(func() {
+		rdb.Close()
+		ln.Close()
+	})
+	return rdb
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		reply := "-ERR unknown command\r\n"
+		if len(args) >= 3 && strings.EqualFold(args[0], "SET") {
+			nx := false
+			for _, a := range args[3:] {
+				nx = nx || strings.EqualFold(a, "NX")
+			}
+			f.mu.Lock()
+			if nx && f.keys[args[1]] {
+				reply = "$-1\r\n"
+			} else {
+				f.keys[args[1]] = true
+				reply = "+OK\r\n"
+			}
+			f.mu.Unlock()
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestCheckTOTPReplay(t *testing.T) {
+	s := NewMFAService(nil, newFakeRedis(t), "test")
+	ctx := context.Background()
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+	// Stay clear of a step boundary, so the window does not move during the
+	// test
+	if left := totpPeriod - time.Now().Unix()%totpPeriod; left < 2 {
+		time.Sleep(time.Duration(left) * time.Second)
+	}
+	step := time.Now().Unix() / totpPeriod
+	current := totpCode(key, uint64(step))
+	previous := totpCode(key, uint64(step-1))
+
+	if !s.checkTOTP(ctx, "admin", "u1", rfcSecret, current) {
+		t.Fatal("current code rejected")
+	}
+	if s.checkTOTP(ctx, "admin", "u1", rfcSecret, current) {
+		t.Error("current code accepted twice")
+	}
+	// Another step inside the window is its own code
+	if !s.checkTOTP(ctx, "admin", "u1", rfcSecret, previous) {
+		t.Error("previous step's code rejected")
+	}
+	if s.checkTOTP(ctx, "admin", "u1", rfcSecret, previous) {
+		t.Error("previous step's code accepted twice")
+	}
+	// Steps are tracked per subject
+	if !s.checkTOTP(ctx, "tenant", "u1", rfcSecret, current) {
+		t.Error("code rejected for another subject type")
+	}
+	if !s.checkTOTP(ctx, "admin", "u2", rfcSecret, current) {
+		t.Error("code rejected for another subject")
+	}
+	if s.checkTOTP(ctx, "admin", "u3", rfcSecret, totpCode(key, uint64(step+2))) {
+		t.Error("code outside the window accepted")
+	}
+}
@@ -83,3 +83,16 @@ func IsBlacklisted(client *redis.Client, ctx context.Context, token string) bool
 	val, err := client.Get(ctx, "blacklist:"+token).Result()
 	return err == nil && val == "1"
 }
+
+// IncrWithTTL increments a counter and starts its expiry window on the first hit.
+// Useful for fixed-window rate limits.
+func IncrWithTTL(client *redis.Client, ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		client.Expire(ctx, key, ttl)
+	}
+	return n, nil
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TenantContext manages the tenant context cached by the tenant middlewares:
+// id, url_code, status, active features and language, stored under one key
+// per way the tenant is addressed (url_code, subdomain, custom domain).
+//
+// Alongside each entry it keeps indexes from the tenant, its plan and the
+// plan's features to the cached entries. Code that changes any of that data
+// invalidates through these indexes and needs to know neither how the
+// tenant was addressed nor which tenants use a plan or feature.
+type TenantContext struct {
+	redis *redis.Client
+}
+
+// TenantContextRefs is what a cached tenant context depends on besides the
+// tenant row itself
+type TenantContextRefs struct {
+	TenantID   string
+	PlanID     string
+	FeatureIDs []string
+}
+
+// NewTenantContext creates the tenant context cache
+func NewTenantContext(redisClient *redis.Client) *TenantContext {
+	return &TenantContext{redis: redisClient}
+}
+
+// Get returns the cached entry at key
+func (t *TenantContext) Get(ctx context.Context, key string) ([]byte, error) {
+	return t.redis.Get(ctx, key).Bytes()
+}
+
+// Store caches value at key for ttl and indexes it under refs. The indexes
+// live as long as the newest entry they point to.
+func (t *TenantContext) Store(ctx context.Context, key string, value []byte, ttl time.Duration, refs TenantContextRefs) error {
+	_, err := t.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, ttl)
+
+		tenantKey := tenantContextKeysKey(refs.TenantID)
+		pipe.SAdd(ctx, tenantKey, key)
+		pipe.Expire(ctx, tenantKey, ttl)
+
+		if refs.PlanID != "" {
+			planKey := tenantContextPlanKey(refs.PlanID)
+			pipe.SAdd(ctx, planKey, refs.TenantID)
+			pipe.Expire(ctx, planKey, ttl)
+		}
+		for _, featureID := range refs.FeatureIDs {
+			featureKey := tenantContextFeatureKey(featureID)
+			pipe.SAdd(ctx, featureKey, refs.TenantID)
+			pipe.Expire(ctx, featureKey, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// Invalidate drops every cached context of the given tenants
+func (t *TenantContext) Invalidate(ctx context.Context, tenantIDs ...string) error {
+	for _, tenantID := range tenantIDs {
+		tenantKey := tenantContextKeysKey(tenantID)
+		keys, err := t.redis.SMembers(ctx, tenantKey).Result()
+		if err != nil {
+			return err
+		}
+		if err := t.redis.Del(ctx, append(keys, tenantKey)...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidatePlan drops the cached context of every tenant on the given plans
+func (t *TenantContext) InvalidatePlan(ctx context.Context, planIDs ...string) error {
+	for _, planID := range planIDs {
+		if err := t.invalidateIndex(ctx, tenantContextPlanKey(planID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidateFeature drops the cached context of every tenant whose plan
+// includes one of the given features
+func (t *TenantContext) InvalidateFeature(ctx context.Context, featureIDs ...string) error {
+	for _, featureID := range featureIDs {
+		if err := t.invalidateIndex(ctx, tenantContextFeatureKey(featureID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TenantContext) invalidateIndex(ctx context.Context, indexKey string) error {
+	tenantIDs, err := t.redis.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return err
+	}
+	if err := t.Invalidate(ctx, tenantIDs...); err != nil {
+		return err
+	}
+	return t.redis.Del(ctx, indexKey).Err()
+}
+
+func tenantContextKeysKey(tenantID string) string {
+	return "tenantctx:tenant:" + tenantID
+}
+
+func tenantContextPlanKey(planID string) string {
+	return "tenantctx:plan:" + planID
+}
+
+func tenantContextFeatureKey(featureID string) string {
+	return "tenantctx:feature:" + featureID
+}
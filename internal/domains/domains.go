@@ -0,0 +1,130 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// challengePrefix is the label under which a tenant publishes the TXT record
+// proving it controls a custom domain
+const challengePrefix = "_saas-verification"
+
+// challengeValuePrefix precedes the token in the TXT record value
+const challengeValuePrefix = "saas-verification="
+
+// CacheKey is the Redis key under which the tenant served on a custom domain
+// is cached
+func CacheKey(domain string) string {
+	return "tenant:domain:" + domain
+}
+
+// TXTResolver looks up DNS TXT records. *net.Resolver satisfies it.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Verifier validates custom domains and checks their ownership through DNS
+type Verifier struct {
+	resolver   TXTResolver
+	baseDomain string
+}
+
+// NewVerifier creates a verifier. baseDomain is the domain under which tenants
+// get a subdomain; custom domains below it are refused. A nil resolver uses
+// the system resolver.
+func NewVerifier(resolver TXTResolver, baseDomain string) *Verifier {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &Verifier{resolver: resolver, baseDomain: NormalizeHost(baseDomain)}
+}
+
+// BaseDomain is the domain tenants' subdomains live under, or empty when
+// subdomain routing is disabled
+func (v *Verifier) BaseDomain() string {
+	return v.baseDomain
+}
+
+// Normalize validates a custom domain and returns it lowercased, without a
+// trailing dot. It errors with an i18n key.
+func (v *Verifier) Normalize(domain string) (string, error) {
+	d := NormalizeHost(domain)
+	if !validHostname(d) || !strings.Contains(d, ".") || net.ParseIP(d) != nil {
+		return "", errors.New("invalid_domain")
+	}
+	if v.baseDomain != "" && (d == v.baseDomain || strings.HasSuffix(d, "."+v.baseDomain)) {
+		return "", errors.New("domain_reserved")
+	}
+	return d, nil
+}
+
+// ChallengeName is the name of the TXT record to create for domain
+func ChallengeName(domain string) string {
+	return challengePrefix + "." + domain
+}
+
+// ChallengeValue is the content of the TXT record for token
+func ChallengeValue(token string) string {
+	return challengeValuePrefix + token
+}
+
+// Verify reports whether domain publishes the TXT record for token. A domain
+// without the record is not an error.
+func (v *Verifier) Verify(ctx context.Context, domain, token string) (bool, error) {
+	records, err := v.resolver.LookupTXT(ctx, ChallengeName(domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	want := ChallengeValue(token)
+	for _, r := range records {
+		if strings.TrimSpace(r) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NormalizeHost lowercases a host name, dropping the port and trailing dot
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// Subdomain returns the tenant label of host when host is a direct subdomain
+// of baseDomain
+func Subdomain(host, baseDomain string) (string, bool) {
+	if baseDomain == "" {
+		return "", false
+	}
+	label, ok := strings.CutSuffix(host, "."+baseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+func validHostname(host string) bool {
+	if len(host) == 0 || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
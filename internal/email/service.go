@@ -37,21 +37,36 @@ func (s *Service) BaseURL() string {
 	return s.cfg.BaseURL
 }
 
+// DefaultLanguage is the template language used when no localized version exists
+const DefaultLanguage = "pt-BR"
+
 // Template represents an email template from the database
 type Template struct {
 	ID       string
 	Slug     string
+	Language string
 	Subject  string
 	BodyHTML string
 }
 
-// GetTemplate loads a template by slug from the database
+// GetTemplate loads a template by slug from the database in the default language
 func (s *Service) GetTemplate(ctx context.Context, slug string) (*Template, error) {
+	return s.GetLocalizedTemplate(ctx, slug, DefaultLanguage)
+}
+
+// GetLocalizedTemplate loads a template by slug and language, falling back to
+// the default language when the requested translation does not exist
+func (s *Service) GetLocalizedTemplate(ctx context.Context, slug, language string) (*Template, error) {
+	if language == "" {
+		language = DefaultLanguage
+	}
 	var t Template
 	err := s.db.QueryRow(ctx,
-		`SELECT id, slug, subject, body_html FROM email_templates WHERE slug = $1 AND is_active = true`,
-		slug,
-	).Scan(&t.ID, &t.Slug, &t.Subject, &t.BodyHTML)
+		`SELECT id, slug, language, subject, body_html FROM email_templates
+		 WHERE slug = $1 AND language IN ($2, $3) AND is_active = true
+		 ORDER BY (language = $2) DESC LIMIT 1`,
+		slug, language, DefaultLanguage,
+	).Scan(&t.ID, &t.Slug, &t.Language, &t.Subject, &t.BodyHTML)
 	if err != nil {
 		return nil, fmt.Errorf("template '%s' not found: %w", slug, err)
 	}
@@ -69,7 +84,12 @@ func RenderTemplate(template string, vars map[string]string) string {
 
 // SendWithTemplate loads a template, renders it, and sends the email
 func (s *Service) SendWithTemplate(ctx context.Context, to, templateSlug string, vars map[string]string) error {
-	tmpl, err := s.GetTemplate(ctx, templateSlug)
+	return s.SendLocalizedTemplate(ctx, to, templateSlug, DefaultLanguage, vars)
+}
+
+// SendLocalizedTemplate is like SendWithTemplate but picks the template translation for language
+func (s *Service) SendLocalizedTemplate(ctx context.Context, to, templateSlug, language string, vars map[string]string) error {
+	tmpl, err := s.GetLocalizedTemplate(ctx, templateSlug, language)
 	if err != nil {
 		return err
 	}
@@ -177,3 +197,28 @@ func (s *Service) SendWelcomeVerification(ctx context.Context, to string, vars m
 func (s *Service) SendEmailVerified(ctx context.Context, to string, vars map[string]string) error {
 	return s.SendWithTemplate(ctx, to, "email_verified", vars)
 }
+
+// SendAppPasswordReset sends the password reset link to an app user
+func (s *Service) SendAppPasswordReset(ctx context.Context, to, language string, vars map[string]string) error {
+	return s.SendLocalizedTemplate(ctx, to, "app_password_reset", language, vars)
+}
+
+// SendPasswordReset sends the password reset link to a backoffice user
+func (s *Service) SendPasswordReset(ctx context.Context, to, language string, vars map[string]string) error {
+	return s.SendLocalizedTemplate(ctx, to, "password_reset", language, vars)
+}
+
+// SendPasswordChanged notifies a backoffice user that their password was changed
+func (s *Service) SendPasswordChanged(ctx context.Context, to, language string, vars map[string]string) error {
+	return s.SendLocalizedTemplate(ctx, to, "password_changed", language, vars)
+}
+
+// SendMemberInvite sends a tenant membership invitation
+func (s *Service) SendMemberInvite(ctx context.Context, to, language string, vars map[string]string) error {
+	return s.SendLocalizedTemplate(ctx, to, "member_invite", language, vars)
+}
+
+// SendOwnershipTransfer asks a member to confirm becoming the tenant owner
+func (s *Service) SendOwnershipTransfer(ctx context.Context, to, language string, vars map[string]string) error {
+	return s.SendLocalizedTemplate(ctx, to, "ownership_transfer", language, vars)
+}
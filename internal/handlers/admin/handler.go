@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,13 +10,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
-	"github.com/saas-single-db-api/internal/cache"
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/i18n"
+	"github.com/saas-single-db-api/internal/imagevariant"
 	models "github.com/saas-single-db-api/internal/models/admin"
 	"github.com/saas-single-db-api/internal/models/shared"
 	_ "github.com/saas-single-db-api/internal/models/swagger"
 	tenantModels "github.com/saas-single-db-api/internal/models/tenant"
 	svc "github.com/saas-single-db-api/internal/services/admin"
+	"github.com/saas-single-db-api/internal/upload"
 	"github.com/saas-single-db-api/internal/utils"
 )
 
@@ -32,7 +35,7 @@ func NewHandler(service *svc.Service, redisClient *redis.Client) *Handler {
 
 // Login godoc
 // @Summary Login de administrador
-// @Description Autentica um administrador do sistema com email e senha
+// @Description Autentica um administrador do sistema com email e senha. Se o administrador tiver 2FA ativo (ou uma role que exige 2FA), retorna mfa_required=true e um mfa_token para concluir o login em /auth/2fa/verify.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -40,6 +43,7 @@ func NewHandler(service *svc.Service, redisClient *redis.Client) *Handler {
 // @Success 200 {object} swagger.AdminLoginResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
 // @Router /auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var req models.LoginRequest
@@ -48,18 +52,232 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	token, admin, err := h.service.Login(c.Request.Context(), req.Email, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": result.MFAToken, "enrollment_required": result.MFAEnrollment})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": result.Token, "refresh_token": result.RefreshToken, "admin": result.Admin})
+}
+
+// loginThrottled answers 429 with Retry-After when err is a login lockout
+func loginThrottled(c *gin.Context, err error) bool {
+	var throttled *auth.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	c.Header("Retry-After", throttled.RetryAfterSeconds())
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(c, err.Error())})
+	return true
+}
+
+// VerifyMFA godoc
+// @Summary Concluir login com 2FA
+// @Description Conclui o login usando o mfa_token e um código TOTP ou um código de recuperação. Se o login exigia cadastro de 2FA, o código confirma o segredo e a resposta inclui os códigos de recuperação.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFAVerifyRequest true "Token de desafio e código"
+// @Success 200 {object} swagger.AdminLoginResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/verify [post]
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	result, err := h.service.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+
+	resp := gin.H{"token": result.Token, "refresh_token": result.RefreshToken, "admin": result.Admin}
+	if len(result.RecoveryCodes) > 0 {
+		resp["recovery_codes"] = result.RecoveryCodes
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// EnrollMFA godoc
+// @Summary Cadastrar 2FA durante o login
+// @Description Gera o segredo TOTP para um administrador cuja role exige 2FA e que ainda não o configurou. Requer o mfa_token retornado pelo login com enrollment_required=true.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFATokenRequest true "Token de desafio"
+// @Success 200 {object} swagger.TwoFactorSetupResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /auth/2fa/enroll [post]
+func (h *Handler) EnrollMFA(c *gin.Context) {
+	var req models.MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	enrollment, err := h.service.EnrollMFA(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// SetupMFA godoc
+// @Summary Iniciar configuração de 2FA
+// @Description Gera um novo segredo TOTP e a URI otpauth:// para o QR code. O 2FA só passa a valer após a confirmação em /auth/2fa/enable.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} swagger.TwoFactorSetupResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Router /auth/2fa/setup [post]
+func (h *Handler) SetupMFA(c *gin.Context) {
+	enrollment, err := h.service.SetupMFA(c.Request.Context(), c.GetString("admin_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// EnableMFA godoc
+// @Summary Ativar 2FA
+// @Description Confirma o segredo TOTP com um código do aplicativo autenticador e retorna os códigos de recuperação (exibidos uma única vez)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "Código TOTP"
+// @Success 200 {object} swagger.RecoveryCodesResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/enable [post]
+func (h *Handler) EnableMFA(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	codes, err := h.service.EnableMFA(c.Request.Context(), c.GetString("admin_id"), req.Code, c.ClientIP())
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA godoc
+// @Summary Desativar 2FA
+// @Description Remove o 2FA do administrador. Exige a senha atual e um código válido. Não é permitido enquanto alguma role do administrador exigir 2FA.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFADisableRequest true "Senha e código"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/disable [post]
+func (h *Handler) DisableMFA(c *gin.Context) {
+	var req models.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	if err := h.service.DisableMFA(c.Request.Context(), c.GetString("admin_id"), req.Password, req.Code, c.ClientIP()); err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "two_factor_disabled")})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Gerar novos códigos de recuperação
+// @Description Invalida os códigos de recuperação atuais e gera novos. Exige um código válido.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "Código TOTP ou de recuperação"
+// @Success 200 {object} swagger.RecoveryCodesResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("admin_id"), req.Code, c.ClientIP())
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Refresh godoc
+// @Summary Renovar token de administrador
+// @Description Troca um refresh token válido por um novo access token e um novo refresh token (rotação). Reutilizar um refresh token já usado revoga toda a família de tokens.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} swagger.RefreshTokenResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /auth/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	token, refreshToken, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token, "admin": admin})
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
 }
 
 // Logout godoc
 // @Summary Logout de administrador
-// @Description Invalida o token JWT do administrador
+// @Description Encerra a sessão atual do administrador, invalidando o access token e os refresh tokens da sessão
 // @Tags Auth
 // @Produce json
 // @Security BearerAuth
@@ -67,11 +285,67 @@ func (h *Handler) Login(c *gin.Context) {
 // @Failure 401 {object} swagger.ErrorResponse
 // @Router /auth/logout [post]
 func (h *Handler) Logout(c *gin.Context) {
-	token := c.GetString("token")
-	cache.SetBlacklist(h.redisClient, context.Background(), token, 24*time.Hour)
+	_ = h.service.Logout(c.Request.Context(), c.GetString("admin_id"), c.GetString("session_id"))
 	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "logged_out")})
 }
 
+// ListSessions godoc
+// @Summary Listar sessões do administrador
+// @Description Lista as sessões ativas do administrador (dispositivo, IP, criação e último acesso). A sessão atual vem marcada com current=true.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} swagger.SessionDTO
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /auth/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), c.GetString("admin_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_sessions")})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revogar sessão do administrador
+// @Description Encerra uma sessão do administrador. Os tokens da sessão deixam de ser aceitos imediatamente.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da sessão"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /auth/sessions/{id} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if err := h.service.RevokeSession(c.Request.Context(), c.GetString("admin_id"), c.Param("id")); err != nil {
+		if err == auth.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "session_not_found")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_revoke_session")})
+		return
+	}
+	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "session_revoked")})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revogar outras sessões do administrador
+// @Description Encerra todas as sessões do administrador, exceto a atual
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /auth/sessions [delete]
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	if err := h.service.RevokeOtherSessions(c.Request.Context(), c.GetString("admin_id"), c.GetString("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_revoke_session")})
+		return
+	}
+	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "sessions_revoked")})
+}
+
 // Me godoc
 // @Summary Dados do administrador autenticado
 // @Description Retorna os dados do administrador logado
@@ -146,12 +420,6 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /sys-users [get]
 func (h *Handler) ListSysUsers(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	p := utils.GetPagination(c)
 	admins, total, err := h.service.Repo().ListAdmins(c.Request.Context(), p.PageSize, p.Offset)
 	if err != nil {
@@ -178,12 +446,6 @@ func (h *Handler) ListSysUsers(c *gin.Context) {
 // @Failure 409 {object} swagger.ErrorResponse
 // @Router /sys-users [post]
 func (h *Handler) CreateSysUser(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	var req models.CreateAdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
@@ -204,7 +466,7 @@ func (h *Handler) CreateSysUser(c *gin.Context) {
 	if req.RoleSlug != "" {
 		role, err := h.service.Repo().GetRoleBySlug(c.Request.Context(), req.RoleSlug)
 		if err == nil {
-			h.service.Repo().AssignRoleToAdmin(c.Request.Context(), admin.ID, role.ID)
+			h.service.AssignRole(c.Request.Context(), admin.ID, role.ID)
 		}
 	}
 
@@ -223,12 +485,6 @@ func (h *Handler) CreateSysUser(c *gin.Context) {
 // @Failure 404 {object} swagger.ErrorResponse
 // @Router /sys-users/{id} [get]
 func (h *Handler) GetSysUser(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	admin, err := h.service.Repo().GetAdminByID(c.Request.Context(), id)
 	if err != nil {
@@ -259,12 +515,6 @@ func (h *Handler) GetSysUser(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /sys-users/{id} [put]
 func (h *Handler) UpdateSysUser(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	var req models.UpdateAdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -292,12 +542,6 @@ func (h *Handler) UpdateSysUser(c *gin.Context) {
 // @Failure 500 {object} swagger.ErrorResponse
 // @Router /sys-users/{id} [delete]
 func (h *Handler) DeleteSysUser(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	if err := h.service.Repo().SoftDeleteAdmin(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete")})
@@ -307,6 +551,25 @@ func (h *Handler) DeleteSysUser(c *gin.Context) {
 	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "deleted")})
 }
 
+// UnlockSysUser godoc
+// @Summary Desbloquear login de administrador
+// @Description Remove o bloqueio temporário de login causado por tentativas de senha malsucedidas. Requer permissão manage_sys_users.
+// @Tags Sys Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do administrador"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /sys-users/{id}/unlock [post]
+func (h *Handler) UnlockSysUser(c *gin.Context) {
+	if err := h.service.UnlockAdmin(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "account_unlocked")})
+}
+
 // GetSysUserProfile godoc
 // @Summary Obter perfil de administrador
 // @Description Retorna o perfil de um administrador por ID. Requer permissão manage_sys_users.
@@ -319,12 +582,6 @@ func (h *Handler) DeleteSysUser(c *gin.Context) {
 // @Failure 404 {object} swagger.ErrorResponse
 // @Router /sys-users/{id}/profile [get]
 func (h *Handler) GetSysUserProfile(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	profile, err := h.service.Repo().GetProfile(c.Request.Context(), id)
 	if err != nil {
@@ -348,12 +605,6 @@ func (h *Handler) GetSysUserProfile(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /sys-users/{id}/profile [put]
 func (h *Handler) UpdateSysUserProfile(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_sys_users") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	var req models.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -420,12 +671,13 @@ func (h *Handler) UpdateMyProfile(c *gin.Context) {
 
 // ListRoles godoc
 // @Summary Listar roles do sistema
-// @Description Retorna todas as roles de administradores
+// @Description Retorna todas as roles de administradores. Requer permissão manage_sys_users.
 // @Tags Roles
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} swagger.AdminRoleListResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /roles [get]
 func (h *Handler) ListRoles(c *gin.Context) {
 	roles, err := h.service.Repo().ListRoles(c.Request.Context())
@@ -438,7 +690,7 @@ func (h *Handler) ListRoles(c *gin.Context) {
 
 // CreateRole godoc
 // @Summary Criar role
-// @Description Cria uma nova role de administrador
+// @Description Cria uma nova role de administrador. Requer permissão manage_sys_users.
 // @Tags Roles
 // @Accept json
 // @Produce json
@@ -447,6 +699,7 @@ func (h *Handler) ListRoles(c *gin.Context) {
 // @Success 201 {object} swagger.AdminRoleDTO
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 409 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /roles [post]
 func (h *Handler) CreateRole(c *gin.Context) {
 	var req models.CreateRoleRequest
@@ -455,7 +708,7 @@ func (h *Handler) CreateRole(c *gin.Context) {
 		return
 	}
 
-	role, err := h.service.Repo().CreateRole(c.Request.Context(), req.Title, req.Slug, req.Description)
+	role, err := h.service.Repo().CreateRole(c.Request.Context(), req.Title, req.Slug, req.Description, req.Require2FA)
 	if err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "role_already_exists")})
 		return
@@ -465,13 +718,14 @@ func (h *Handler) CreateRole(c *gin.Context) {
 
 // GetRole godoc
 // @Summary Obter role por ID
-// @Description Retorna uma role específica
+// @Description Retorna uma role específica. Requer permissão manage_sys_users.
 // @Tags Roles
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "ID da role"
 // @Success 200 {object} swagger.AdminRoleDTO
 // @Failure 404 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /roles/{id} [get]
 func (h *Handler) GetRole(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
@@ -485,7 +739,7 @@ func (h *Handler) GetRole(c *gin.Context) {
 
 // UpdateRole godoc
 // @Summary Atualizar role
-// @Description Atualiza uma role existente
+// @Description Atualiza uma role existente. Requer permissão manage_sys_users.
 // @Tags Roles
 // @Accept json
 // @Produce json
@@ -495,6 +749,7 @@ func (h *Handler) GetRole(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /roles/{id} [put]
 func (h *Handler) UpdateRole(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
@@ -513,17 +768,18 @@ func (h *Handler) UpdateRole(c *gin.Context) {
 
 // DeleteRole godoc
 // @Summary Remover role
-// @Description Remove uma role do sistema
+// @Description Remove uma role do sistema. Requer permissão manage_sys_users.
 // @Tags Roles
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "ID da role"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /roles/{id} [delete]
 func (h *Handler) DeleteRole(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := h.service.Repo().DeleteRole(c.Request.Context(), id); err != nil {
+	if err := h.service.DeleteRole(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_role")})
 		return
 	}
@@ -532,7 +788,7 @@ func (h *Handler) DeleteRole(c *gin.Context) {
 
 // AssignRole godoc
 // @Summary Atribuir role a administrador
-// @Description Atribui uma role a um administrador
+// @Description Atribui uma role a um administrador. Requer permissão manage_sys_users.
 // @Tags Sys Users
 // @Accept json
 // @Produce json
@@ -542,6 +798,7 @@ func (h *Handler) DeleteRole(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /sys-users/{id}/roles [post]
 func (h *Handler) AssignRole(c *gin.Context) {
 	id := c.Param("id")
@@ -551,7 +808,7 @@ func (h *Handler) AssignRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Repo().AssignRoleToAdmin(c.Request.Context(), id, req.RoleID); err != nil {
+	if err := h.service.AssignRole(c.Request.Context(), id, req.RoleID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_assign_role")})
 		return
 	}
@@ -560,7 +817,7 @@ func (h *Handler) AssignRole(c *gin.Context) {
 
 // RemoveRole godoc
 // @Summary Remover role de administrador
-// @Description Remove a atribuição de uma role de um administrador
+// @Description Remove a atribuição de uma role de um administrador. Requer permissão manage_sys_users.
 // @Tags Sys Users
 // @Produce json
 // @Security BearerAuth
@@ -568,11 +825,12 @@ func (h *Handler) AssignRole(c *gin.Context) {
 // @Param role_id path int true "ID da role"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /sys-users/{id}/roles/{role_id} [delete]
 func (h *Handler) RemoveRole(c *gin.Context) {
 	id := c.Param("id")
 	roleID, _ := strconv.Atoi(c.Param("role_id"))
-	if err := h.service.Repo().RemoveRoleFromAdmin(c.Request.Context(), id, roleID); err != nil {
+	if err := h.service.RemoveRole(c.Request.Context(), id, roleID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_remove_role")})
 		return
 	}
@@ -581,12 +839,13 @@ func (h *Handler) RemoveRole(c *gin.Context) {
 
 // ListPermissions godoc
 // @Summary Listar permissões
-// @Description Retorna todas as permissões do sistema
+// @Description Retorna todas as permissões do sistema. Requer permissão manage_sys_users.
 // @Tags Permissions
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} swagger.AdminPermissionListResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /permissions [get]
 func (h *Handler) ListPermissions(c *gin.Context) {
 	perms, err := h.service.Repo().ListPermissions(c.Request.Context())
@@ -601,7 +860,7 @@ func (h *Handler) ListPermissions(c *gin.Context) {
 
 // ListTenants godoc
 // @Summary Listar tenants
-// @Description Retorna lista paginada de tenants. Requer permissão view_tenants.
+// @Description Retorna lista paginada de tenants. Requer permissão view_tenants ou manage_tenants.
 // @Tags Tenants
 // @Produce json
 // @Security BearerAuth
@@ -612,12 +871,6 @@ func (h *Handler) ListPermissions(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /tenants [get]
 func (h *Handler) ListTenants(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "view_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	p := utils.GetPagination(c)
 	tenants, total, err := h.service.Repo().ListTenants(c.Request.Context(), p.PageSize, p.Offset)
 	if err != nil {
@@ -644,12 +897,6 @@ func (h *Handler) ListTenants(c *gin.Context) {
 // @Failure 409 {object} swagger.ErrorResponse
 // @Router /tenants [post]
 func (h *Handler) CreateTenant(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	var req tenantModels.CreateTenantRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
@@ -735,7 +982,7 @@ func (h *Handler) CreateTenant(c *gin.Context) {
 
 // GetTenant godoc
 // @Summary Obter tenant por ID
-// @Description Retorna detalhes de um tenant. Requer permissão view_tenants.
+// @Description Retorna detalhes de um tenant. Requer permissão view_tenants ou manage_tenants.
 // @Tags Tenants
 // @Produce json
 // @Security BearerAuth
@@ -745,12 +992,6 @@ func (h *Handler) CreateTenant(c *gin.Context) {
 // @Failure 404 {object} swagger.ErrorResponse
 // @Router /tenants/{id} [get]
 func (h *Handler) GetTenant(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "view_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	tenant, err := h.service.Repo().GetTenantByID(c.Request.Context(), id)
 	if err != nil {
@@ -774,12 +1015,6 @@ func (h *Handler) GetTenant(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /tenants/{id} [put]
 func (h *Handler) UpdateTenant(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	var req tenantModels.UpdateTenantRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -806,14 +1041,8 @@ func (h *Handler) UpdateTenant(c *gin.Context) {
 // @Failure 500 {object} swagger.ErrorResponse
 // @Router /tenants/{id} [delete]
 func (h *Handler) DeleteTenant(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
-	if err := h.service.Repo().SoftDeleteTenant(c.Request.Context(), id); err != nil {
+	if err := h.service.DeleteTenant(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete")})
 		return
 	}
@@ -834,12 +1063,6 @@ func (h *Handler) DeleteTenant(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /tenants/{id}/status [put]
 func (h *Handler) UpdateTenantStatus(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	id := c.Param("id")
 	var req tenantModels.UpdateTenantStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -847,13 +1070,47 @@ func (h *Handler) UpdateTenantStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Repo().UpdateTenantStatus(c.Request.Context(), id, req.Status); err != nil {
+	if err := h.service.UpdateTenantStatus(c.Request.Context(), id, req.Status); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_status_admin")})
 		return
 	}
 	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "status_updated")})
 }
 
+// TransferTenantOwnership godoc
+// @Summary Transferir propriedade do tenant
+// @Description Torna um membro ativo owner do tenant sem confirmação, para casos de suporte em que o owner atual não pode iniciar a transferência (por exemplo, saiu da empresa). O owner anterior passa à role member, só com leitura. Cancela transferências pendentes e registra a alteração com o admin e o motivo. Requer permissão manage_tenants.
+// @Tags Tenants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do tenant"
+// @Param request body tenantModels.TransferOwnershipRequest true "Novo owner e motivo"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /tenants/{id}/owner [put]
+func (h *Handler) TransferTenantOwnership(c *gin.Context) {
+	tenantID := c.Param("id")
+	var req tenantModels.TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	err := h.service.TransferTenantOwnership(c.Request.Context(), tenantID, req.UserID, c.GetString("admin_id"), req.Reason)
+	if err != nil {
+		switch err.Error() {
+		case "member_not_found", "already_tenant_owner":
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_transfer_ownership")})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "ownership_transferred")})
+}
+
 // ChangeTenantPlan godoc
 // @Summary Alterar plano do tenant
 // @Description Altera o plano de um tenant, com opção de promoção. Requer permissão manage_plans.
@@ -868,12 +1125,6 @@ func (h *Handler) UpdateTenantStatus(c *gin.Context) {
 // @Failure 403 {object} swagger.ErrorResponse
 // @Router /tenants/{id}/plan [put]
 func (h *Handler) ChangeTenantPlan(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "manage_plans") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	tenantID := c.Param("id")
 	var req tenantModels.ChangePlanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -922,13 +1173,14 @@ func (h *Handler) ChangeTenantPlan(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "commit_failed")})
 		return
 	}
+	h.service.InvalidateTenantContext(ctx, tenantID)
 
 	c.JSON(http.StatusOK, shared.MessageResponse{Message: i18n.T(c, "plan_changed")})
 }
 
 // GetTenantPlanHistory godoc
 // @Summary Histórico de planos do tenant
-// @Description Retorna o histórico de planos de um tenant. Requer permissão view_tenants.
+// @Description Retorna o histórico de planos de um tenant. Requer permissão view_tenants ou manage_tenants.
 // @Tags Tenants
 // @Produce json
 // @Security BearerAuth
@@ -938,12 +1190,6 @@ func (h *Handler) ChangeTenantPlan(c *gin.Context) {
 // @Failure 500 {object} swagger.ErrorResponse
 // @Router /tenants/{id}/plan-history [get]
 func (h *Handler) GetTenantPlanHistory(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "view_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	tenantID := c.Param("id")
 	history, err := h.service.Repo().GetTenantPlanHistory(c.Request.Context(), tenantID)
 	if err != nil {
@@ -955,7 +1201,7 @@ func (h *Handler) GetTenantPlanHistory(c *gin.Context) {
 
 // GetTenantMembers godoc
 // @Summary Listar membros do tenant
-// @Description Retorna os membros de um tenant. Requer permissão view_tenants.
+// @Description Retorna os membros de um tenant. Requer permissão view_tenants ou manage_tenants.
 // @Tags Tenants
 // @Produce json
 // @Security BearerAuth
@@ -965,12 +1211,6 @@ func (h *Handler) GetTenantPlanHistory(c *gin.Context) {
 // @Failure 500 {object} swagger.ErrorResponse
 // @Router /tenants/{id}/members [get]
 func (h *Handler) GetTenantMembers(c *gin.Context) {
-	adminID := c.GetString("admin_id")
-	if !h.service.HasPermission(c.Request.Context(), adminID, "view_tenants") {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
-		return
-	}
-
 	tenantID := c.Param("id")
 	members, err := h.service.Repo().GetTenantMembers(c.Request.Context(), tenantID)
 	if err != nil {
@@ -984,12 +1224,13 @@ func (h *Handler) GetTenantMembers(c *gin.Context) {
 
 // ListPlans godoc
 // @Summary Listar planos
-// @Description Retorna todos os planos com suas features
+// @Description Retorna todos os planos com suas features. Requer permissão manage_plans ou view_analytics.
 // @Tags Plans
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} swagger.PlanListResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans [get]
 func (h *Handler) ListPlans(c *gin.Context) {
 	plans, err := h.service.Repo().ListPlans(c.Request.Context())
@@ -1002,7 +1243,7 @@ func (h *Handler) ListPlans(c *gin.Context) {
 
 // CreatePlan godoc
 // @Summary Criar plano
-// @Description Cria um novo plano com features opcionais
+// @Description Cria um novo plano com features opcionais. Requer permissão manage_plans.
 // @Tags Plans
 // @Accept json
 // @Produce json
@@ -1011,6 +1252,7 @@ func (h *Handler) ListPlans(c *gin.Context) {
 // @Success 201 {object} swagger.PlanResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans [post]
 func (h *Handler) CreatePlan(c *gin.Context) {
 	var req tenantModels.CreatePlanRequest
@@ -1023,8 +1265,24 @@ func (h *Handler) CreatePlan(c *gin.Context) {
 	if maxUsers == 0 {
 		maxUsers = 1
 	}
+	maxImageVariants := req.MaxImageVariants
+	if maxImageVariants == 0 {
+		maxImageVariants = imagevariant.DefaultLimits.MaxVariants
+	}
+	maxImageSize := req.MaxImageSize
+	if maxImageSize == 0 {
+		maxImageSize = imagevariant.DefaultLimits.MaxSize
+	}
+	maxUploadSize := req.MaxUploadSize
+	if maxUploadSize == 0 {
+		maxUploadSize = upload.DefaultLimits.MaxBytes
+	}
+	maxUploadDimension := req.MaxUploadDimension
+	if maxUploadDimension == 0 {
+		maxUploadDimension = upload.DefaultLimits.MaxDimension
+	}
 
-	id, err := h.service.Repo().CreatePlan(c.Request.Context(), req.Name, req.Description, req.PlanType, req.Price, maxUsers, req.IsMultilang, req.Translations)
+	id, err := h.service.Repo().CreatePlan(c.Request.Context(), req.Name, req.Description, req.PlanType, req.Price, maxUsers, maxImageVariants, maxImageSize, maxUploadSize, maxUploadDimension, req.IsMultilang, req.Translations)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_plan")})
 		return
@@ -1041,13 +1299,14 @@ func (h *Handler) CreatePlan(c *gin.Context) {
 
 // GetPlan godoc
 // @Summary Obter plano por ID
-// @Description Retorna um plano específico com suas features
+// @Description Retorna um plano específico com suas features. Requer permissão manage_plans ou view_analytics.
 // @Tags Plans
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID do plano"
 // @Success 200 {object} swagger.PlanResponse
 // @Failure 404 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans/{id} [get]
 func (h *Handler) GetPlan(c *gin.Context) {
 	id := c.Param("id")
@@ -1061,7 +1320,7 @@ func (h *Handler) GetPlan(c *gin.Context) {
 
 // UpdatePlan godoc
 // @Summary Atualizar plano
-// @Description Atualiza um plano existente
+// @Description Atualiza um plano existente. Requer permissão manage_plans.
 // @Tags Plans
 // @Accept json
 // @Produce json
@@ -1071,6 +1330,7 @@ func (h *Handler) GetPlan(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans/{id} [put]
 func (h *Handler) UpdatePlan(c *gin.Context) {
 	id := c.Param("id")
@@ -1080,7 +1340,7 @@ func (h *Handler) UpdatePlan(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Repo().UpdatePlan(c.Request.Context(), id, req.Name, req.Description, req.Price, req.MaxUsers, req.IsMultilang, req.IsActive, req.Translations); err != nil {
+	if err := h.service.Repo().UpdatePlan(c.Request.Context(), id, req.Name, req.Description, req.Price, req.MaxUsers, req.MaxImageVariants, req.MaxImageSize, req.MaxUploadSize, req.MaxUploadDimension, req.IsMultilang, req.IsActive, req.Translations); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_plan")})
 		return
 	}
@@ -1089,17 +1349,18 @@ func (h *Handler) UpdatePlan(c *gin.Context) {
 
 // DeletePlan godoc
 // @Summary Remover plano
-// @Description Remove um plano do sistema
+// @Description Remove um plano do sistema. Requer permissão manage_plans.
 // @Tags Plans
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID do plano"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans/{id} [delete]
 func (h *Handler) DeletePlan(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.service.Repo().DeletePlan(c.Request.Context(), id); err != nil {
+	if err := h.service.DeletePlan(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_plan")})
 		return
 	}
@@ -1108,7 +1369,7 @@ func (h *Handler) DeletePlan(c *gin.Context) {
 
 // AddFeatureToPlan godoc
 // @Summary Adicionar feature ao plano
-// @Description Adiciona uma feature a um plano existente
+// @Description Adiciona uma feature a um plano existente. Requer permissão manage_plans.
 // @Tags Plans
 // @Accept json
 // @Produce json
@@ -1118,6 +1379,7 @@ func (h *Handler) DeletePlan(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans/{id}/features [post]
 func (h *Handler) AddFeatureToPlan(c *gin.Context) {
 	planID := c.Param("id")
@@ -1126,7 +1388,7 @@ func (h *Handler) AddFeatureToPlan(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
-	if err := h.service.Repo().AddFeatureToPlan(c.Request.Context(), planID, req.FeatureID); err != nil {
+	if err := h.service.AddFeatureToPlan(c.Request.Context(), planID, req.FeatureID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_add_feature")})
 		return
 	}
@@ -1135,7 +1397,7 @@ func (h *Handler) AddFeatureToPlan(c *gin.Context) {
 
 // RemoveFeatureFromPlan godoc
 // @Summary Remover feature do plano
-// @Description Remove uma feature de um plano
+// @Description Remove uma feature de um plano. Requer permissão manage_plans.
 // @Tags Plans
 // @Produce json
 // @Security BearerAuth
@@ -1143,11 +1405,12 @@ func (h *Handler) AddFeatureToPlan(c *gin.Context) {
 // @Param feat_id path string true "ID da feature"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /plans/{id}/features/{feat_id} [delete]
 func (h *Handler) RemoveFeatureFromPlan(c *gin.Context) {
 	planID := c.Param("id")
 	featureID := c.Param("feat_id")
-	if err := h.service.Repo().RemoveFeatureFromPlan(c.Request.Context(), planID, featureID); err != nil {
+	if err := h.service.RemoveFeatureFromPlan(c.Request.Context(), planID, featureID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_remove_feature")})
 		return
 	}
@@ -1158,12 +1421,13 @@ func (h *Handler) RemoveFeatureFromPlan(c *gin.Context) {
 
 // ListFeatures godoc
 // @Summary Listar features
-// @Description Retorna todas as features do sistema
+// @Description Retorna todas as features do sistema. Requer permissão manage_features, manage_plans ou view_analytics.
 // @Tags Features
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} swagger.FeatureListResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /features [get]
 func (h *Handler) ListFeatures(c *gin.Context) {
 	features, err := h.service.Repo().ListFeatures(c.Request.Context())
@@ -1176,7 +1440,7 @@ func (h *Handler) ListFeatures(c *gin.Context) {
 
 // CreateFeature godoc
 // @Summary Criar feature
-// @Description Cria uma nova feature no sistema
+// @Description Cria uma nova feature no sistema. Requer permissão manage_features.
 // @Tags Features
 // @Accept json
 // @Produce json
@@ -1185,6 +1449,7 @@ func (h *Handler) ListFeatures(c *gin.Context) {
 // @Success 201 {object} swagger.FeatureResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 409 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /features [post]
 func (h *Handler) CreateFeature(c *gin.Context) {
 	var req tenantModels.CreateFeatureRequest
@@ -1205,13 +1470,14 @@ func (h *Handler) CreateFeature(c *gin.Context) {
 
 // GetFeature godoc
 // @Summary Obter feature por ID
-// @Description Retorna uma feature específica
+// @Description Retorna uma feature específica. Requer permissão manage_features, manage_plans ou view_analytics.
 // @Tags Features
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID da feature"
 // @Success 200 {object} swagger.FeatureResponse
 // @Failure 404 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /features/{id} [get]
 func (h *Handler) GetFeature(c *gin.Context) {
 	id := c.Param("id")
@@ -1225,7 +1491,7 @@ func (h *Handler) GetFeature(c *gin.Context) {
 
 // UpdateFeature godoc
 // @Summary Atualizar feature
-// @Description Atualiza uma feature existente
+// @Description Atualiza uma feature existente. Requer permissão manage_features.
 // @Tags Features
 // @Accept json
 // @Produce json
@@ -1235,6 +1501,7 @@ func (h *Handler) GetFeature(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /features/{id} [put]
 func (h *Handler) UpdateFeature(c *gin.Context) {
 	id := c.Param("id")
@@ -1244,7 +1511,7 @@ func (h *Handler) UpdateFeature(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Repo().UpdateFeature(c.Request.Context(), id, req.Title, req.Description, req.IsActive, req.Translations); err != nil {
+	if err := h.service.UpdateFeature(c.Request.Context(), id, req.Title, req.Description, req.IsActive, req.Translations); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_feature")})
 		return
 	}
@@ -1253,17 +1520,18 @@ func (h *Handler) UpdateFeature(c *gin.Context) {
 
 // DeleteFeature godoc
 // @Summary Remover feature
-// @Description Remove uma feature do sistema
+// @Description Remove uma feature do sistema. Requer permissão manage_features.
 // @Tags Features
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID da feature"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /features/{id} [delete]
 func (h *Handler) DeleteFeature(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.service.Repo().DeleteFeature(c.Request.Context(), id); err != nil {
+	if err := h.service.DeleteFeature(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_feature")})
 		return
 	}
@@ -1274,12 +1542,13 @@ func (h *Handler) DeleteFeature(c *gin.Context) {
 
 // ListPromotions godoc
 // @Summary Listar promoções
-// @Description Retorna todas as promoções do sistema
+// @Description Retorna todas as promoções do sistema. Requer permissão manage_plans, manage_billing ou view_analytics.
 // @Tags Promotions
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} swagger.PromotionListResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /promotions [get]
 func (h *Handler) ListPromotions(c *gin.Context) {
 	promos, err := h.service.Repo().ListPromotions(c.Request.Context())
@@ -1292,7 +1561,7 @@ func (h *Handler) ListPromotions(c *gin.Context) {
 
 // CreatePromotion godoc
 // @Summary Criar promoção
-// @Description Cria uma nova promoção
+// @Description Cria uma nova promoção. Requer permissão manage_plans ou manage_billing.
 // @Tags Promotions
 // @Accept json
 // @Produce json
@@ -1301,6 +1570,7 @@ func (h *Handler) ListPromotions(c *gin.Context) {
 // @Success 201 {object} swagger.PromotionResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /promotions [post]
 func (h *Handler) CreatePromotion(c *gin.Context) {
 	var req tenantModels.CreatePromotionRequest
@@ -1321,13 +1591,14 @@ func (h *Handler) CreatePromotion(c *gin.Context) {
 
 // GetPromotion godoc
 // @Summary Obter promoção por ID
-// @Description Retorna uma promoção específica
+// @Description Retorna uma promoção específica. Requer permissão manage_plans, manage_billing ou view_analytics.
 // @Tags Promotions
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID da promoção"
 // @Success 200 {object} swagger.PromotionResponse
 // @Failure 404 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /promotions/{id} [get]
 func (h *Handler) GetPromotion(c *gin.Context) {
 	id := c.Param("id")
@@ -1341,7 +1612,7 @@ func (h *Handler) GetPromotion(c *gin.Context) {
 
 // UpdatePromotion godoc
 // @Summary Atualizar promoção
-// @Description Atualiza uma promoção existente
+// @Description Atualiza uma promoção existente. Requer permissão manage_plans ou manage_billing.
 // @Tags Promotions
 // @Accept json
 // @Produce json
@@ -1351,6 +1622,7 @@ func (h *Handler) GetPromotion(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /promotions/{id} [put]
 func (h *Handler) UpdatePromotion(c *gin.Context) {
 	id := c.Param("id")
@@ -1369,13 +1641,14 @@ func (h *Handler) UpdatePromotion(c *gin.Context) {
 
 // DeletePromotion godoc
 // @Summary Desativar promoção
-// @Description Desativa uma promoção (soft delete)
+// @Description Desativa uma promoção (soft delete). Requer permissão manage_plans ou manage_billing.
 // @Tags Promotions
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID da promoção"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 500 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
 // @Router /promotions/{id} [delete]
 func (h *Handler) DeletePromotion(c *gin.Context) {
 	id := c.Param("id")
@@ -1,17 +1,19 @@
 package app
 
 import (
+	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/cache"
 	"github.com/saas-single-db-api/internal/i18n"
 	_ "github.com/saas-single-db-api/internal/models/swagger"
 	repo "github.com/saas-single-db-api/internal/repository/app"
 	svc "github.com/saas-single-db-api/internal/services/app"
 	"github.com/saas-single-db-api/internal/storage"
+	"github.com/saas-single-db-api/internal/upload"
 	"github.com/saas-single-db-api/internal/utils"
 )
 
@@ -41,7 +43,7 @@ func NewHandler(s *svc.Service, r *repo.Repository, st storage.Provider, c *cach
 // @Router /{url_code}/auth/register [post]
 func (h *Handler) Register(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	urlCode := c.Param("url_code")
+	urlCode := c.GetString("url_code")
 
 	var req struct {
 		Name     string `json:"name" binding:"required"`
@@ -53,15 +55,16 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.Register(c.Request.Context(), tenantID, urlCode, req.Name, req.Email, req.Password)
+	result, err := h.service.Register(c.Request.Context(), tenantID, urlCode, req.Name, req.Email, req.Password, auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"user_id": result.UserID,
-		"token":   result.Token,
+		"user_id":       result.UserID,
+		"token":         result.Token,
+		"refresh_token": result.RefreshToken,
 	})
 }
 
@@ -75,10 +78,11 @@ func (h *Handler) Register(c *gin.Context) {
 // @Param request body swagger.AppLoginRequest true "Credenciais"
 // @Success 200 {object} swagger.AppAuthResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
 // @Router /{url_code}/auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	urlCode := c.Param("url_code")
+	urlCode := c.GetString("url_code")
 
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
@@ -89,23 +93,30 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.Login(c.Request.Context(), tenantID, urlCode, req.Email, req.Password)
+	result, err := h.service.Login(c.Request.Context(), tenantID, urlCode, req.Email, req.Password, auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
 	if err != nil {
+		var throttled *auth.LoginThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", throttled.RetryAfterSeconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(c, err.Error())})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token":   result.Token,
-		"user_id": result.UserID,
-		"name":    result.Name,
-		"email":   result.Email,
+		"token":         result.Token,
+		"refresh_token": result.RefreshToken,
+		"user_id":       result.UserID,
+		"name":          result.Name,
+		"email":         result.Email,
 	})
 }
 
 // Logout godoc
 // @Summary Logout de app user
-// @Description Invalida o token JWT do app user
+// @Description Encerra a sessão atual do app user, invalidando o access token e os refresh tokens da sessão
 // @Tags Auth
 // @Produce json
 // @Security BearerAuth
@@ -113,13 +124,42 @@ func (h *Handler) Login(c *gin.Context) {
 // @Success 200 {object} swagger.MessageResponse
 // @Router /{url_code}/auth/logout [post]
 func (h *Handler) Logout(c *gin.Context) {
-	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
-	if token != "" {
-		h.cache.SetBlacklist(c.Request.Context(), token)
-	}
+	_ = h.service.Logout(c.Request.Context(), c.GetString("app_user_id"), c.GetString("session_id"))
+
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "logged_out")})
 }
 
+// Refresh godoc
+// @Summary Renovar token de app user
+// @Description Troca um refresh token válido por um novo access token e um novo refresh token (rotação). Reutilizar um refresh token já usado revoga toda a família de tokens.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param url_code path string true "URL code do tenant"
+// @Param request body swagger.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} swagger.RefreshTokenResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /{url_code}/auth/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	token, refreshToken, err := h.service.Refresh(c.Request.Context(), tenantID, req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+}
+
 // Me godoc
 // @Summary Dados do app user logado
 // @Description Retorna os dados do app user autenticado
@@ -176,7 +216,7 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 // ForgotPassword godoc
 // @Summary Solicitar reset de senha
-// @Description Envia email com link para reset de senha
+// @Description Envia email com link de uso único (válido por 60 minutos) para reset de senha
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -194,13 +234,16 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	_ = h.service.ForgotPassword(c.Request.Context(), tenantID, req.Email)
+	if err := h.service.ForgotPassword(c.Request.Context(), tenantID, c.GetString("url_code"), req.Email, c.GetString("language")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_password_reset")})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "password_reset_sent")})
 }
 
 // ResetPassword godoc
 // @Summary Resetar senha
-// @Description Reseta a senha usando token recebido por email
+// @Description Reseta a senha usando token recebido por email. Invalida todas as sessões ativas do usuário.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -289,35 +332,105 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "profile_updated")})
 }
 
+// ListSessions godoc
+// @Summary Listar sessões do app user
+// @Description Lista as sessões ativas do app user (dispositivo, IP, criação e último acesso). A sessão atual vem marcada com current=true.
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {array} swagger.SessionDTO
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /{url_code}/profile/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), c.GetString("app_user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_sessions")})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revogar sessão
+// @Description Encerra uma sessão do app user. Os tokens da sessão deixam de ser aceitos imediatamente.
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da sessão"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/profile/sessions/{id} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if err := h.service.RevokeSession(c.Request.Context(), c.GetString("app_user_id"), c.Param("id")); err != nil {
+		if err == auth.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "session_not_found")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_revoke_session")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "session_revoked")})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revogar outras sessões
+// @Description Encerra todas as sessões do app user, exceto a atual
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /{url_code}/profile/sessions [delete]
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	if err := h.service.RevokeOtherSessions(c.Request.Context(), c.GetString("app_user_id"), c.GetString("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_revoke_session")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "sessions_revoked")})
+}
+
 // UploadAvatar godoc
 // @Summary Upload de avatar do app user
-// @Description Faz upload da foto de perfil do app user
+// @Description Faz upload da foto de perfil do app user, substituindo a anterior. Variantes quadradas são geradas em segundo plano.
 // @Tags Profile
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
 // @Param avatar formData file true "Imagem do avatar"
-// @Success 200 {object} swagger.UploadResponse
+// @Success 200 {object} swagger.ProfileImageResponse
 // @Failure 400 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
 // @Router /{url_code}/profile/avatar [put]
 func (h *Handler) UploadAvatar(c *gin.Context) {
 	userID := c.GetString("app_user_id")
+	upload.LimitBody(c.Writer, c.Request, upload.DefaultLimits, 1)
 	file, header, err := c.Request.FormFile("avatar")
 	if err != nil {
+		if upload.IsBodyTooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": i18n.T(c, "file_too_large")})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
 		return
 	}
 	defer file.Close()
 
-	publicURL, storagePath, err := h.storage.Upload(file, header, "app-avatars")
+	info, err := upload.Validate(file, header, upload.DefaultLimits)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
 
-	_ = h.repo.UpdateAppUserProfileAvatar(c.Request.Context(), userID, publicURL)
-	c.JSON(http.StatusOK, gin.H{"path": storagePath, "public_url": publicURL})
+	picture, err := h.service.ReplaceAvatar(c.Request.Context(), c.GetString("tenant_id"), userID, file, info, header.Filename)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
+		return
+	}
+	c.JSON(http.StatusOK, picture)
 }
 
 // ==================== CATALOG (Public) ====================
@@ -1,35 +1,49 @@
 package tenant
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/cache"
 	"github.com/saas-single-db-api/internal/i18n"
+	"github.com/saas-single-db-api/internal/imageevents"
+	"github.com/saas-single-db-api/internal/imagequeue"
+	"github.com/saas-single-db-api/internal/imagevariant"
 	_ "github.com/saas-single-db-api/internal/models/swagger"
 	repo "github.com/saas-single-db-api/internal/repository/tenant"
 	svc "github.com/saas-single-db-api/internal/services/tenant"
 	"github.com/saas-single-db-api/internal/storage"
+	"github.com/saas-single-db-api/internal/upload"
 	"github.com/saas-single-db-api/internal/utils"
+	"github.com/saas-single-db-api/internal/webhooks"
 )
 
 type Handler struct {
-	service   *svc.Service
-	repo      *repo.Repository
-	storage   storage.Provider
-	cache     *cache.RedisClient
-	jwtSecret string
-	jwtExpiry int
+	service *svc.Service
+	repo    *repo.Repository
+	storage storage.Provider
+	driver  string
+	cache   *cache.RedisClient
 }
 
-func NewHandler(s *svc.Service, r *repo.Repository, st storage.Provider, c *cache.RedisClient, jwtSecret string, jwtExpiry int) *Handler {
-	return &Handler{service: s, repo: r, storage: st, cache: c, jwtSecret: jwtSecret, jwtExpiry: jwtExpiry}
+// NewHandler creates the tenant API handler. driver is the configured
+// storage provider, recorded on the images it stores.
+func NewHandler(s *svc.Service, r *repo.Repository, st storage.Provider, driver string, c *cache.RedisClient) *Handler {
+	return &Handler{service: s, repo: r, storage: st, driver: driver, cache: c}
 }
 
 // ==================== PUBLIC: Subscription ====================
@@ -115,6 +129,7 @@ func (h *Handler) Subscribe(c *gin.Context) {
 		OwnerEmail:   req.Email,
 		OwnerPass:    req.Password,
 		Language:     lang,
+		Client:       auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()},
 	})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
@@ -122,11 +137,12 @@ func (h *Handler) Subscribe(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"tenant_id": result.TenantID,
-		"user_id":   result.UserID,
-		"url_code":  result.URLCode,
-		"token":     result.Token,
-		"language":  lang,
+		"tenant_id":     result.TenantID,
+		"user_id":       result.UserID,
+		"url_code":      result.URLCode,
+		"token":         result.Token,
+		"refresh_token": result.RefreshToken,
+		"language":      lang,
 	})
 }
 
@@ -182,7 +198,7 @@ func (h *Handler) ResendVerification(c *gin.Context) {
 // ==================== AUTH ====================
 
 // Login godoc
-// @Summary Login de usuário
+// @Description Autentica um usuário do backoffice com email e senha. Se o usuário tiver 2FA ativo, retorna mfa_required=true e um mfa_token para concluir o login em /auth/2fa/verify.
 // @Description Autentica um usuário do backoffice com email e senha
 // @Tags Auth
 // @Accept json
@@ -191,6 +207,7 @@ func (h *Handler) ResendVerification(c *gin.Context) {
 // @Success 200 {object} swagger.UserLoginResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 401 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
 // @Router /auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var req struct {
@@ -206,14 +223,79 @@ func (h *Handler) Login(c *gin.Context) {
 	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
 	c.Set("language", lang)
 
-	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
 	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": result.MFAToken})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":               result.Token,
+		"refresh_token":       result.RefreshToken,
+		"name":                result.Name,
+		"email":               result.Email,
+		"current_tenant_code": result.CurrentTenantCode,
+		"tenants":             result.Tenants,
+		"language":            result.Language,
+	})
+}
+
+// loginThrottled answers 429 with Retry-After when err is a login lockout
+func loginThrottled(c *gin.Context, err error) bool {
+	var throttled *auth.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	c.Header("Retry-After", throttled.RetryAfterSeconds())
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(c, err.Error())})
+	return true
+}
+
+// VerifyMFA godoc
+// @Summary Concluir login com 2FA
+// @Description Conclui o login usando o mfa_token retornado pelo login e um código TOTP ou um código de recuperação
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body swagger.MFAVerifyRequest true "Token de desafio e código"
+// @Success 200 {object} swagger.UserLoginResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/verify [post]
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+
+	result, err := h.service.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"token":               result.Token,
+		"refresh_token":       result.RefreshToken,
 		"name":                result.Name,
 		"email":               result.Email,
 		"current_tenant_code": result.CurrentTenantCode,
@@ -222,22 +304,288 @@ func (h *Handler) Login(c *gin.Context) {
 	})
 }
 
+// SetupMFA godoc
+// @Summary Iniciar configuração de 2FA
+// @Description Gera um novo segredo TOTP e a URI otpauth:// para o QR code. O 2FA só passa a valer após a confirmação em /auth/2fa/enable.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} swagger.TwoFactorSetupResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Router /auth/2fa/setup [post]
+func (h *Handler) SetupMFA(c *gin.Context) {
+	enrollment, err := h.service.SetupMFA(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// EnableMFA godoc
+// @Summary Ativar 2FA
+// @Description Confirma o segredo TOTP com um código do aplicativo autenticador e retorna os códigos de recuperação (exibidos uma única vez)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body swagger.MFACodeRequest true "Código TOTP"
+// @Success 200 {object} swagger.RecoveryCodesResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/enable [post]
+func (h *Handler) EnableMFA(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	codes, err := h.service.EnableMFA(c.Request.Context(), c.GetString("user_id"), req.Code, c.ClientIP())
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA godoc
+// @Summary Desativar 2FA
+// @Description Remove o 2FA do usuário. Exige a senha atual e um código válido.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body swagger.MFADisableRequest true "Senha e código"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/disable [post]
+func (h *Handler) DisableMFA(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	if err := h.service.DisableMFA(c.Request.Context(), c.GetString("user_id"), req.Password, req.Code, c.ClientIP()); err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "two_factor_disabled")})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Gerar novos códigos de recuperação
+// @Description Invalida os códigos de recuperação atuais e gera novos. Exige um código válido.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body swagger.MFACodeRequest true "Código TOTP ou de recuperação"
+// @Success 200 {object} swagger.RecoveryCodesResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("user_id"), req.Code, c.ClientIP())
+	if err != nil {
+		if loginThrottled(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
 // Logout godoc
 // @Summary Logout de usuário
-// @Description Invalida o token JWT do usuário
+// @Description Encerra a sessão atual do usuário, invalidando o access token e os refresh tokens da sessão
 // @Tags Auth
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} swagger.MessageResponse
 // @Router /auth/logout [post]
 func (h *Handler) Logout(c *gin.Context) {
-	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
-	if token != "" {
-		h.cache.SetBlacklist(c.Request.Context(), token)
-	}
+	_ = h.service.Logout(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id"))
+
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "logged_out")})
 }
 
+// Refresh godoc
+// @Summary Renovar token de usuário
+// @Description Troca um refresh token válido por um novo access token e um novo refresh token (rotação). Reutilizar um refresh token já usado revoga toda a família de tokens.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body swagger.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} swagger.RefreshTokenResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /auth/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+
+	token, refreshToken, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+}
+
+// ForgotPassword godoc
+// @Summary Solicitar reset de senha
+// @Description Envia email com link de uso único (válido por 60 minutos) para reset de senha. Limitado a 3 solicitações por hora por email.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body swagger.ForgotPasswordRequest true "Email"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 429 {object} swagger.ErrorResponse
+// @Router /auth/forgot-password [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email, lang); err != nil {
+		if err.Error() == "too_many_reset_requests" {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(c, err.Error())})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_password_reset")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "password_reset_sent")})
+}
+
+// ResetPassword godoc
+// @Summary Resetar senha
+// @Description Reseta a senha usando token recebido por email. Invalida todas as sessões ativas e envia email de confirmação.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body swagger.ResetPasswordRequest true "Token e nova senha"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Router /auth/reset-password [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.NewPassword, lang); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "password_reset_success")})
+}
+
+// GetInvitation godoc
+// @Summary Consultar convite
+// @Description Retorna os dados de um convite válido pelo token recebido por email. account_exists indica se o aceite vincula uma conta existente ou exige senha.
+// @Tags Invitations
+// @Produce json
+// @Param token path string true "Token do convite"
+// @Success 200 {object} swagger.InvitationInfoResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /invitations/{token} [get]
+func (h *Handler) GetInvitation(c *gin.Context) {
+	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+
+	info, err := h.service.GetInvitationByToken(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// AcceptInvitation godoc
+// @Summary Aceitar convite
+// @Description Aceita um convite de membro. Se já existe uma conta com o email convidado, ela é vinculada ao tenant; caso contrário, uma conta é criada com a senha informada.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param request body swagger.AcceptInvitationRequest true "Token do convite e dados da nova conta"
+// @Success 200 {object} swagger.AcceptInvitationResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Router /invitations/accept [post]
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Name     string `json:"name"`
+		Password string `json:"password" binding:"omitempty,min=6"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	lang := i18n.DetectLanguage(c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+
+	result, err := h.service.AcceptInvitation(c.Request.Context(), req.Token, req.Name, req.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":         result.UserID,
+		"url_code":        result.URLCode,
+		"account_created": result.AccountCreated,
+		"message":         i18n.T(c, "invitation_accepted"),
+	})
+}
+
 // Me godoc
 // @Summary Dados do usuário autenticado
 // @Description Retorna os dados do usuário logado com seus tenants
@@ -299,7 +647,7 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 // SelectTenant godoc
 // @Summary Selecionar tenant
-// @Description Troca o contexto do usuário para outro tenant, retornando um novo token com escopo
+// @Description Troca o contexto da sessão atual para outro tenant, retornando um novo token com escopo e um novo refresh token
 // @Tags Auth
 // @Produce json
 // @Security BearerAuth
@@ -325,16 +673,17 @@ func (h *Handler) SelectTenant(c *gin.Context) {
 
 	_ = h.repo.UpdateUserLastTenant(c.Request.Context(), userID, urlCode)
 
-	token, err := utils.GenerateUserToken(userID, tenantID, h.jwtSecret, h.jwtExpiry)
+	token, refreshToken, err := h.service.SwitchTenant(c.Request.Context(), userID, c.GetString("session_id"), tenantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_generate_token")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token":     token,
-		"tenant_id": tenantID,
-		"url_code":  urlCode,
+		"token":         token,
+		"refresh_token": refreshToken,
+		"tenant_id":     tenantID,
+		"url_code":      urlCode,
 	})
 }
 
@@ -387,38 +736,113 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "profile_updated")})
 }
 
+// ListSessions godoc
+// @Summary Listar sessões do usuário
+// @Description Lista as sessões ativas do usuário (dispositivo, IP, criação e último acesso). A sessão atual vem marcada com current=true.
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} swagger.SessionDTO
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /profile/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_sessions")})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revogar sessão
+// @Description Encerra uma sessão do usuário. Os tokens da sessão deixam de ser aceitos imediatamente.
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da sessão"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /profile/sessions/{id} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if err := h.service.RevokeSession(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		if err == auth.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "session_not_found")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_revoke_session")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "session_revoked")})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revogar outras sessões
+// @Description Encerra todas as sessões do usuário, exceto a atual
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 401 {object} swagger.ErrorResponse
+// @Router /profile/sessions [delete]
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	if err := h.service.RevokeOtherSessions(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_revoke_session")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "sessions_revoked")})
+}
+
 // UploadAvatar godoc
 // @Summary Upload de avatar
-// @Description Faz upload do avatar do usuário
+// @Description Faz upload do avatar do usuário, substituindo o anterior. Variantes quadradas são geradas em segundo plano.
 // @Tags Profile
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param avatar formData file true "Imagem do avatar"
-// @Success 200 {object} swagger.UploadResponse
+// @Success 200 {object} swagger.ProfileImageResponse
 // @Failure 400 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
 // @Router /profile/avatar [post]
 func (h *Handler) UploadAvatar(c *gin.Context) {
 	userID := c.GetString("user_id")
-	file, header, err := c.Request.FormFile("avatar")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
+	file, header, info, ok := h.readImage(c, "avatar", upload.DefaultLimits)
+	if !ok {
 		return
 	}
 	defer file.Close()
 
-	publicURL, storagePath, err := h.storage.Upload(file, header, "avatars")
+	picture, err := h.service.ReplaceAvatar(c.Request.Context(), userID, file, info, header.Filename)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
 		return
 	}
+	c.JSON(http.StatusOK, picture)
+}
 
-	_ = h.repo.UpdateUserProfileAvatar(c.Request.Context(), userID, publicURL)
+// readImage reads the image in the given form field and validates it
+// against limits. On failure it writes the error response and returns ok
+// false; otherwise the caller closes the file.
+func (h *Handler) readImage(c *gin.Context, field string, limits upload.Limits) (multipart.File, *multipart.FileHeader, *upload.Image, bool) {
+	upload.LimitBody(c.Writer, c.Request, limits, 1)
+	file, header, err := c.Request.FormFile(field)
+	if err != nil {
+		if upload.IsBodyTooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": i18n.T(c, "file_too_large")})
+			return nil, nil, nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
+		return nil, nil, nil, false
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"path":       storagePath,
-		"public_url": publicURL,
-	})
+	info, err := upload.Validate(file, header, limits)
+	if err != nil {
+		file.Close()
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return nil, nil, nil, false
+	}
+	return file, header, info, true
 }
 
 // ==================== BOOTSTRAP ====================
@@ -548,15 +972,16 @@ func (h *Handler) UpdateTenantProfile(c *gin.Context) {
 
 // UploadLogo godoc
 // @Summary Upload de logo do tenant
-// @Description Faz upload do logo do tenant. Apenas o owner pode enviar.
+// @Description Faz upload do logo do tenant, substituindo o anterior. Variantes quadradas são geradas em segundo plano. Apenas o owner pode enviar.
 // @Tags Tenant Profile
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
 // @Param logo formData file true "Imagem do logo"
-// @Success 200 {object} swagger.UploadResponse
+// @Success 200 {object} swagger.ProfileImageResponse
 // @Failure 403 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
 // @Router /{url_code}/tenant/logo [post]
 func (h *Handler) UploadLogo(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
@@ -566,24 +991,19 @@ func (h *Handler) UploadLogo(c *gin.Context) {
 		return
 	}
 
-	file, header, err := c.Request.FormFile("logo")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
+	limits := h.service.UploadLimits(c.Request.Context(), tenantID)
+	file, header, info, ok := h.readImage(c, "logo", limits)
+	if !ok {
 		return
 	}
 	defer file.Close()
 
-	publicURL, storagePath, err := h.storage.Upload(file, header, "logos")
+	picture, err := h.service.ReplaceLogo(c.Request.Context(), tenantID, file, info, header.Filename)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
 		return
 	}
-	_ = h.repo.UpdateTenantLogo(c.Request.Context(), tenantID, publicURL)
-
-	c.JSON(http.StatusOK, gin.H{
-		"path":       storagePath,
-		"public_url": publicURL,
-	})
+	c.JSON(http.StatusOK, picture)
 }
 
 // ==================== MEMBERS ====================
@@ -632,7 +1052,7 @@ func (h *Handler) GetMember(c *gin.Context) {
 
 // CanAddMember godoc
 // @Summary Verificar se pode adicionar membro
-// @Description Verifica se o tenant pode adicionar mais membros baseado no plano
+// @Description Verifica se o tenant pode adicionar mais membros baseado no plano. Convites pendentes ocupam uma vaga.
 // @Tags Members
 // @Produce json
 // @Security BearerAuth
@@ -642,21 +1062,22 @@ func (h *Handler) GetMember(c *gin.Context) {
 // @Router /{url_code}/members/can-add [get]
 func (h *Handler) CanAddMember(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	canAdd, maxUsers, current, err := h.service.CanAddMember(c.Request.Context(), tenantID)
+	capacity, err := h.service.CanAddMember(c.Request.Context(), tenantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"can_add":   canAdd,
-		"max_users": maxUsers,
-		"current":   current,
+		"can_add":         capacity.CanAdd,
+		"max_users":       capacity.MaxUsers,
+		"current":         capacity.Members,
+		"pending_invites": capacity.PendingInvites,
 	})
 }
 
 // InviteMember godoc
 // @Summary Convidar membro
-// @Description Adiciona um novo membro ao tenant. Requer permissão user_m ou ser owner.
+// @Description Envia um convite por email para participar do tenant. O convidado vira membro ao aceitar o convite. Requer permissão user_m ou ser owner.
 // @Tags Members
 // @Accept json
 // @Produce json
@@ -678,23 +1099,110 @@ func (h *Handler) InviteMember(c *gin.Context) {
 	}
 
 	var req struct {
-		Name     string `json:"name" binding:"required"`
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
-		Role     string `json:"role" binding:"required"`
+		Name  string `json:"name" binding:"required"`
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
 
-	newUserID, err := h.service.InviteMember(c.Request.Context(), tenantID, req.Email, req.Name, req.Password, req.Role)
+	invitationID, expiresAt, err := h.service.InviteMember(c.Request.Context(), tenantID, userID, req.Email, req.Name, req.Role, c.GetString("language"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invitation_id": invitationID, "expires_at": expiresAt, "message": i18n.T(c, "invitation_sent")})
+}
+
+// ListInvitations godoc
+// @Summary Listar convites pendentes
+// @Description Lista os convites de membros ainda não aceitos nem revogados, incluindo os expirados. Requer permissão user_m ou ser owner.
+// @Tags Members
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {object} swagger.InvitationListResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/members/invitations [get]
+func (h *Handler) ListInvitations(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "user_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+		return
+	}
+
+	invitations, err := h.service.ListInvitations(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_invitations")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invitations})
+}
+
+// ResendInvitation godoc
+// @Summary Reenviar convite
+// @Description Gera um novo link para o convite, invalidando o anterior, renova a validade e reenvia o email. Requer permissão user_m ou ser owner.
+// @Tags Members
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do convite"
+// @Success 200 {object} swagger.InviteMemberResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/members/invitations/{id}/resend [post]
+func (h *Handler) ResendInvitation(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "user_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+		return
+	}
+
+	invitationID := c.Param("id")
+	expiresAt, err := h.service.ResendInvitation(c.Request.Context(), tenantID, userID, invitationID, c.GetString("language"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"invitation_id": invitationID, "expires_at": expiresAt, "message": i18n.T(c, "invitation_sent")})
+}
+
+// RevokeInvitation godoc
+// @Summary Revogar convite
+// @Description Cancela um convite pendente, liberando a vaga reservada. Requer permissão user_m ou ser owner.
+// @Tags Members
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do convite"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/members/invitations/{id} [delete]
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "user_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+		return
+	}
 
-	c.JSON(http.StatusCreated, gin.H{"user_id": newUserID, "message": i18n.T(c, "member_added")})
+	if err := h.service.RevokeInvitation(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "invitation_revoked")})
 }
 
 // UpdateMemberRole godoc
@@ -737,6 +1245,35 @@ func (h *Handler) UpdateMemberRole(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "member_role_updated")})
 }
 
+// UnlockMember godoc
+// @Summary Desbloquear login de membro
+// @Description Remove o bloqueio temporário de login de um membro causado por tentativas de senha malsucedidas. Requer permissão user_m ou ser owner.
+// @Tags Members
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do membro"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/members/{id}/unlock [post]
+func (h *Handler) UnlockMember(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "user_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+		return
+	}
+
+	if err := h.service.UnlockMember(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "account_unlocked")})
+}
+
 // RemoveMember godoc
 // @Summary Remover membro
 // @Description Remove um membro do tenant. Apenas o owner pode remover.
@@ -770,244 +1307,828 @@ func (h *Handler) RemoveMember(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "member_removed")})
 }
 
-// ==================== ROLES ====================
-
-// ListPermissions godoc
-// @Summary Listar permissões disponíveis
-// @Description Retorna todas as permissões do sistema disponíveis para atribuir a roles
-// @Tags Roles
-// @Produce json
-// @Security BearerAuth
-// @Param url_code path string true "URL code do tenant"
-// @Success 200 {object} swagger.AdminPermissionListResponse
-// @Failure 500 {object} swagger.ErrorResponse
-// @Router /{url_code}/permissions [get]
-func (h *Handler) ListPermissions(c *gin.Context) {
-	perms, err := h.repo.ListPermissions(c.Request.Context())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_permissions")})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"data": perms})
-}
+// ==================== OWNERSHIP TRANSFER ====================
 
-// ListRoles godoc
-// @Summary Listar roles do tenant
-// @Description Retorna todas as roles do tenant
-// @Tags Roles
+// GetOwnershipTransfer godoc
+// @Summary Consultar transferência de propriedade
+// @Description Retorna a transferência de propriedade pendente do tenant. Apenas o owner.
+// @Tags Tenant
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Success 200 {array} swagger.UserRoleResponse
-// @Failure 500 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles [get]
-func (h *Handler) ListRoles(c *gin.Context) {
+// @Success 200 {object} swagger.OwnershipTransferDTO
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/tenant/ownership-transfer [get]
+func (h *Handler) GetOwnershipTransfer(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	roles, err := h.repo.ListTenantRoles(c.Request.Context(), tenantID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_roles")})
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_transfer")})
 		return
 	}
-	c.JSON(http.StatusOK, roles)
-}
 
-// GetRole godoc
-// @Summary Obter role com permissões
-// @Description Retorna uma role do tenant com suas permissões
-// @Tags Roles
-// @Produce json
-// @Security BearerAuth
-// @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID da role"
-// @Success 200 {object} swagger.RoleDetailResponse
-// @Failure 404 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles/{id} [get]
-func (h *Handler) GetRole(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	roleID := c.Param("id")
-	role, err := h.repo.GetTenantRoleByID(c.Request.Context(), tenantID, roleID)
+	transfer, err := h.service.GetOwnershipTransfer(c.Request.Context(), tenantID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "role_not_found")})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
-	perms, _ := h.repo.GetRolePermissions(c.Request.Context(), roleID)
-	c.JSON(http.StatusOK, gin.H{
-		"role":        role,
-		"permissions": perms,
-	})
+	c.JSON(http.StatusOK, transfer)
 }
 
-// CreateRole godoc
-// @Summary Criar role
-// @Description Cria uma nova role no tenant. Apenas o owner pode criar.
-// @Tags Roles
+// StartOwnershipTransfer godoc
+// @Summary Iniciar transferência de propriedade
+// @Description Oferece a propriedade do tenant a outro membro ativo e envia a ele um email de confirmação. A propriedade só muda quando o membro confirma; o owner atual passa então à role admin. Uma transferência pendente anterior é cancelada. Apenas o owner.
+// @Tags Tenant
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param request body swagger.CreateRoleRequest true "Dados da role"
-// @Success 201 {object} swagger.UserRoleResponse
+// @Param request body swagger.StartOwnershipTransferRequest true "Membro que receberá o tenant"
+// @Success 201 {object} swagger.OwnershipTransferResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles [post]
-func (h *Handler) CreateRole(c *gin.Context) {
+// @Router /{url_code}/tenant/ownership-transfer [post]
+func (h *Handler) StartOwnershipTransfer(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
+
 	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_roles")})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_transfer")})
 		return
 	}
 
 	var req struct {
-		Title string `json:"title" binding:"required"`
+		UserID string `json:"user_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
 
-	slug := utils.Slugify(req.Title)
-	role, err := h.repo.CreateTenantRole(c.Request.Context(), tenantID, req.Title, slug)
+	transferID, expiresAt, err := h.service.StartOwnershipTransfer(c.Request.Context(), tenantID, userID, req.UserID, c.GetString("language"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_role")})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
-	c.JSON(http.StatusCreated, role)
+	c.JSON(http.StatusCreated, gin.H{"transfer_id": transferID, "expires_at": expiresAt, "message": i18n.T(c, "ownership_transfer_sent")})
 }
 
-// UpdateRole godoc
-// @Summary Atualizar role
-// @Description Atualiza uma role do tenant. Apenas o owner pode atualizar.
-// @Tags Roles
-// @Accept json
+// CancelOwnershipTransfer godoc
+// @Summary Cancelar transferência de propriedade
+// @Description Cancela a transferência de propriedade pendente, invalidando o link enviado. Apenas o owner.
+// @Tags Tenant
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID da role"
-// @Param request body swagger.UpdateRoleRequest true "Dados para atualização"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles/{id} [put]
-func (h *Handler) UpdateRole(c *gin.Context) {
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/tenant/ownership-transfer [delete]
+func (h *Handler) CancelOwnershipTransfer(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
-	roleID := c.Param("id")
+
 	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_roles")})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_transfer")})
+		return
+	}
+
+	if err := h.service.CancelOwnershipTransfer(c.Request.Context(), tenantID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "ownership_transfer_cancelled")})
+}
 
+// ConfirmOwnershipTransfer godoc
+// @Summary Confirmar transferência de propriedade
+// @Description Torna o usuário autenticado owner do tenant usando o token recebido por email. Deve ser chamado pelo membro que recebeu a transferência, enquanto o owner que a iniciou ainda for o owner.
+// @Tags Tenant
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body swagger.ConfirmOwnershipTransferRequest true "Token da transferência"
+// @Success 200 {object} swagger.ConfirmOwnershipTransferResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /ownership-transfer/confirm [post]
+func (h *Handler) ConfirmOwnershipTransfer(c *gin.Context) {
 	var req struct {
-		Title *string `json:"title"`
+		Token string `json:"token" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
 
-	if err := h.repo.UpdateTenantRole(c.Request.Context(), tenantID, roleID, req.Title); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_role")})
+	urlCode, err := h.service.ConfirmOwnershipTransfer(c.Request.Context(), req.Token, c.GetString("user_id"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "ownership_transfer_other_user" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "role_updated")})
+	c.JSON(http.StatusOK, gin.H{"url_code": urlCode, "message": i18n.T(c, "ownership_transfer_confirmed")})
 }
 
-// DeleteRole godoc
-// @Summary Remover role
-// @Description Remove uma role do tenant. Apenas o owner pode remover.
-// @Tags Roles
+// ==================== DOMAINS ====================
+
+// ListDomains godoc
+// @Summary Listar domínios personalizados
+// @Description Lista os domínios personalizados do tenant com o registro TXT que deve ser criado no DNS para verificá-los. Apenas o owner.
+// @Tags Domains
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID da role"
-// @Success 200 {object} swagger.MessageResponse
+// @Success 200 {object} swagger.TenantDomainListResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles/{id} [delete]
-func (h *Handler) DeleteRole(c *gin.Context) {
+// @Router /{url_code}/domains [get]
+func (h *Handler) ListDomains(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
-	roleID := c.Param("id")
+
 	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_roles")})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_domains")})
 		return
 	}
 
-	if err := h.repo.DeleteTenantRole(c.Request.Context(), tenantID, roleID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_role")})
+	domains, err := h.service.ListDomains(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_domains")})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "role_deleted")})
+	c.JSON(http.StatusOK, gin.H{"data": domains})
 }
 
-// AssignPermission godoc
-// @Summary Atribuir permissão a role
-// @Description Atribui uma permissão a uma role do tenant. Apenas o owner.
-// @Tags Roles
+// AddDomain godoc
+// @Summary Adicionar domínio personalizado
+// @Description Registra um domínio personalizado para a loja do tenant. O domínio só passa a atender o tenant depois de verificado pelo registro TXT retornado. Apenas o owner.
+// @Tags Domains
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID da role"
-// @Param request body swagger.AssignPermissionRequest true "ID da permissão"
-// @Success 200 {object} swagger.MessageResponse
+// @Param request body swagger.AddDomainRequest true "Domínio"
+// @Success 201 {object} swagger.TenantDomainDTO
+// @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles/{id}/permissions [post]
-func (h *Handler) AssignPermission(c *gin.Context) {
+// @Router /{url_code}/domains [post]
+func (h *Handler) AddDomain(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
-	roleID := c.Param("id")
+
 	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_perms")})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_domains")})
 		return
 	}
 
 	var req struct {
-		PermissionID string `json:"permission_id" binding:"required"`
+		Domain string `json:"domain" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
 
-	if err := h.repo.AssignPermissionToRole(c.Request.Context(), roleID, req.PermissionID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_assign_permission")})
+	domain, err := h.service.AddDomain(c.Request.Context(), tenantID, req.Domain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "permission_assigned")})
+	c.JSON(http.StatusCreated, domain)
 }
 
-// RemovePermission godoc
-// @Summary Remover permissão da role
-// @Description Remove uma permissão de uma role do tenant. Apenas o owner.
-// @Tags Roles
+// VerifyDomain godoc
+// @Summary Verificar domínio personalizado
+// @Description Consulta o registro TXT do domínio no DNS. Se encontrado, o domínio é marcado como verificado e passa a atender o tenant. Apenas o owner.
+// @Tags Domains
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID da role"
-// @Param permId path string true "ID da permissão"
-// @Success 200 {object} swagger.MessageResponse
+// @Param id path string true "ID do domínio"
+// @Success 200 {object} swagger.VerifyDomainResponse
+// @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/roles/{id}/permissions/{permId} [delete]
-func (h *Handler) RemovePermission(c *gin.Context) {
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/domains/{id}/verify [post]
+func (h *Handler) VerifyDomain(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
-	roleID := c.Param("id")
+
 	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_perms")})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_domains")})
 		return
 	}
 
-	permID := c.Param("permId")
-	if err := h.repo.RemovePermissionFromRole(c.Request.Context(), roleID, permID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_remove_permission")})
+	domain, err := h.service.VerifyDomain(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "domain_not_found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": i18n.T(c, err.Error())})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "permission_removed")})
+
+	message := "domain_verified"
+	if !domain.Verified {
+		message = "domain_verification_pending"
+	}
+	c.JSON(http.StatusOK, gin.H{"domain": domain, "message": i18n.T(c, message)})
 }
 
-// ==================== PRODUCTS ====================
+// RemoveDomain godoc
+// @Summary Remover domínio personalizado
+// @Description Remove um domínio personalizado; ele deixa de atender o tenant imediatamente. Apenas o owner.
+// @Tags Domains
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do domínio"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/domains/{id} [delete]
+func (h *Handler) RemoveDomain(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
 
-func (h *Handler) requireFeature(c *gin.Context, slug string) bool {
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_domains")})
+		return
+	}
+
+	if err := h.service.RemoveDomain(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "domain_removed")})
+}
+
+// ==================== WEBHOOKS ====================
+
+// ListWebhookEvents godoc
+// @Summary Listar eventos de webhook
+// @Description Lista os tipos de evento que podem ser assinados por um webhook. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {object} swagger.WebhookEventListResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/events [get]
+func (h *Handler) ListWebhookEvents(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": webhooks.Events})
+}
+
+// ListWebhooks godoc
+// @Summary Listar webhooks
+// @Description Lista os endpoints de webhook do tenant. O segredo de assinatura não é retornado. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {object} swagger.WebhookListResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	endpoints, err := h.service.ListWebhooks(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_webhooks")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": endpoints})
+}
+
+// CreateWebhook godoc
+// @Summary Criar webhook
+// @Description Registra um endpoint que recebe os eventos assinados via POST. Cada requisição é assinada com HMAC-SHA256 (header X-Webhook-Signature, "sha256=" + hex de HMAC(secret, timestamp + "." + corpo)). A URL deve resolver para endereços públicos: endereços locais, privados e link-local são recusados, e redirecionamentos não são seguidos. O segredo só é retornado nesta resposta. Apenas o owner.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param request body swagger.CreateWebhookRequest true "Endpoint"
+// @Success 201 {object} swagger.WebhookDTO
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	var req struct {
+		URL         string   `json:"url" binding:"required"`
+		Description *string  `json:"description"`
+		Events      []string `json:"events" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	endpoint, err := h.service.CreateWebhook(c.Request.Context(), tenantID, req.URL, req.Description, req.Events)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// GetWebhook godoc
+// @Summary Obter webhook
+// @Description Retorna um endpoint de webhook do tenant. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Success 200 {object} swagger.WebhookDTO
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	endpoint, err := h.service.GetWebhook(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "webhook_not_found")})
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// UpdateWebhook godoc
+// @Summary Atualizar webhook
+// @Description Altera URL, descrição, eventos assinados ou ativa/desativa o endpoint. Campos omitidos não são alterados. Entregas pendentes de um endpoint desativado aguardam até ele ser reativado. Apenas o owner.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Param request body swagger.UpdateWebhookRequest true "Campos a alterar"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id} [put]
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	var req struct {
+		URL         *string  `json:"url"`
+		Description *string  `json:"description"`
+		Events      []string `json:"events"`
+		IsActive    *bool    `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	if err := h.service.UpdateWebhook(c.Request.Context(), tenantID, c.Param("id"), req.URL, req.Description, req.Events, req.IsActive); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, webhooks.ErrEndpointNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "webhook_updated")})
+}
+
+// DeleteWebhook godoc
+// @Summary Remover webhook
+// @Description Remove o endpoint e o histórico de entregas dele. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	if err := h.service.DeleteWebhook(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "webhook_not_found")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "webhook_deleted")})
+}
+
+// RotateWebhookSecret godoc
+// @Summary Gerar novo segredo do webhook
+// @Description Substitui o segredo de assinatura do endpoint. Entregas enviadas a partir de agora, inclusive novas tentativas, usam o novo segredo. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Success 200 {object} swagger.WebhookSecretResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id}/rotate-secret [post]
+func (h *Handler) RotateWebhookSecret(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	secret, err := h.service.RotateWebhookSecret(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "webhook_not_found")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"secret": secret})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary Listar entregas do webhook
+// @Description Lista as entregas do endpoint, das mais recentes para as mais antigas. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Param status query string false "Filtrar por status (pending, delivered, failed)"
+// @Param page query int false "Página"
+// @Param page_size query int false "Itens por página"
+// @Success 200 {object} swagger.WebhookDeliveryListResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	pag := utils.GetPagination(c)
+	deliveries, total, err := h.service.ListWebhookDeliveries(c.Request.Context(), tenantID, c.Param("id"), c.Query("status"), pag.PageSize, pag.Offset)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrEndpointNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "webhook_not_found")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_webhook_deliveries")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":      deliveries,
+		"total":     total,
+		"page":      pag.Page,
+		"page_size": pag.PageSize,
+	})
+}
+
+// GetWebhookDelivery godoc
+// @Summary Obter entrega do webhook
+// @Description Retorna uma entrega com o payload enviado e o log de cada tentativa (status e corpo da resposta, erro, duração). Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Param deliveryId path string true "ID da entrega"
+// @Success 200 {object} swagger.WebhookDeliveryDTO
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id}/deliveries/{deliveryId} [get]
+func (h *Handler) GetWebhookDelivery(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	delivery, err := h.service.GetWebhookDelivery(c.Request.Context(), tenantID, c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "webhook_delivery_not_found")})
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhook godoc
+// @Summary Reenviar entrega do webhook
+// @Description Coloca a entrega de volta na fila para ser enviada imediatamente, com um novo ciclo de tentativas. Apenas o owner.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do webhook"
+// @Param deliveryId path string true "ID da entrega"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_webhooks")})
+		return
+	}
+
+	if err := h.service.RedeliverWebhook(c.Request.Context(), tenantID, c.Param("id"), c.Param("deliveryId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "webhook_delivery_not_found")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "webhook_redelivery_queued")})
+}
+
+// ==================== ROLES ====================
+
+// ListPermissions godoc
+// @Summary Listar permissões disponíveis
+// @Description Retorna todas as permissões do sistema disponíveis para atribuir a roles
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {object} swagger.AdminPermissionListResponse
+// @Failure 500 {object} swagger.ErrorResponse
+// @Router /{url_code}/permissions [get]
+func (h *Handler) ListPermissions(c *gin.Context) {
+	perms, err := h.repo.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_permissions")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": perms})
+}
+
+// ListRoles godoc
+// @Summary Listar roles do tenant
+// @Description Retorna todas as roles do tenant
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {array} swagger.UserRoleResponse
+// @Failure 500 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles [get]
+func (h *Handler) ListRoles(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	roles, err := h.repo.ListTenantRoles(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_roles")})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole godoc
+// @Summary Obter role com permissões
+// @Description Retorna uma role do tenant com suas permissões
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da role"
+// @Success 200 {object} swagger.RoleDetailResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles/{id} [get]
+func (h *Handler) GetRole(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	roleID := c.Param("id")
+	role, err := h.repo.GetTenantRoleByID(c.Request.Context(), tenantID, roleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "role_not_found")})
+		return
+	}
+	perms, _ := h.repo.GetRolePermissions(c.Request.Context(), roleID)
+	c.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": perms,
+	})
+}
+
+// CreateRole godoc
+// @Summary Criar role
+// @Description Cria uma nova role no tenant. Apenas o owner pode criar.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param request body swagger.CreateRoleRequest true "Dados da role"
+// @Success 201 {object} swagger.UserRoleResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles [post]
+func (h *Handler) CreateRole(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_roles")})
+		return
+	}
+
+	var req struct {
+		Title string `json:"title" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	slug := utils.Slugify(req.Title)
+	role, err := h.repo.CreateTenantRole(c.Request.Context(), tenantID, req.Title, slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_role")})
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole godoc
+// @Summary Atualizar role
+// @Description Atualiza uma role do tenant. Apenas o owner pode atualizar.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da role"
+// @Param request body swagger.UpdateRoleRequest true "Dados para atualização"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles/{id} [put]
+func (h *Handler) UpdateRole(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	roleID := c.Param("id")
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_roles")})
+		return
+	}
+
+	var req struct {
+		Title *string `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	if err := h.repo.UpdateTenantRole(c.Request.Context(), tenantID, roleID, req.Title); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_role")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "role_updated")})
+}
+
+// DeleteRole godoc
+// @Summary Remover role
+// @Description Remove uma role do tenant. Apenas o owner pode remover.
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da role"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles/{id} [delete]
+func (h *Handler) DeleteRole(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	roleID := c.Param("id")
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_roles")})
+		return
+	}
+
+	if err := h.repo.DeleteTenantRole(c.Request.Context(), tenantID, roleID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_role")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "role_deleted")})
+}
+
+// AssignPermission godoc
+// @Summary Atribuir permissão a role
+// @Description Atribui uma permissão a uma role do tenant. Apenas o owner.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da role"
+// @Param request body swagger.AssignPermissionRequest true "ID da permissão"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles/{id}/permissions [post]
+func (h *Handler) AssignPermission(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	roleID := c.Param("id")
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_perms")})
+		return
+	}
+
+	var req struct {
+		PermissionID string `json:"permission_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	if err := h.repo.AssignPermissionToRole(c.Request.Context(), roleID, req.PermissionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_assign_permission")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "permission_assigned")})
+}
+
+// RemovePermission godoc
+// @Summary Remover permissão da role
+// @Description Remove uma permissão de uma role do tenant. Apenas o owner.
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da role"
+// @Param permId path string true "ID da permissão"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/roles/{id}/permissions/{permId} [delete]
+func (h *Handler) RemovePermission(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	roleID := c.Param("id")
+	if !h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "only_owner_manage_perms")})
+		return
+	}
+
+	permID := c.Param("permId")
+	if err := h.repo.RemovePermissionFromRole(c.Request.Context(), roleID, permID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_remove_permission")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "permission_removed")})
+}
+
+// ==================== PRODUCTS ====================
+
+func (h *Handler) requireFeature(c *gin.Context, slug string) bool {
 	features, _ := c.Get("features")
 	if feats, ok := features.([]string); ok {
 		for _, f := range feats {
@@ -1016,27 +2137,237 @@ func (h *Handler) requireFeature(c *gin.Context, slug string) bool {
 			}
 		}
 	}
-	c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tf(c, "feature_not_in_plan", slug)})
-	return false
+	c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tf(c, "feature_not_in_plan", slug)})
+	return false
+}
+
+func (h *Handler) requirePermission(c *gin.Context, permSlug string) bool {
+	userID := c.GetString("user_id")
+	tenantID := c.GetString("tenant_id")
+	if h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		return true
+	}
+	if h.service.HasPermission(c.Request.Context(), userID, tenantID, permSlug) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+	return false
+}
+
+// ListProducts godoc
+// @Summary Listar produtos
+// @Description Retorna produtos do tenant paginados. Requer feature 'products'.
+// @Tags Products
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param page query int false "Página" default(1)
+// @Param page_size query int false "Itens por página" default(20)
+// @Success 200 {object} swagger.PaginatedResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/products [get]
+func (h *Handler) ListProducts(c *gin.Context) {
+	if !h.requireFeature(c, "products") {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+	pag := utils.GetPagination(c)
+
+	products, total, err := h.repo.ListProducts(c.Request.Context(), tenantID, pag.PageSize, pag.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_products")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":      products,
+		"total":     total,
+		"page":      pag.Page,
+		"page_size": pag.PageSize,
+	})
+}
+
+// GetProduct godoc
+// @Summary Obter produto
+// @Description Retorna um produto específico. Requer feature 'products'.
+// @Tags Products
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do produto"
+// @Success 200 {object} swagger.ProductResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/products/{id} [get]
+func (h *Handler) GetProduct(c *gin.Context) {
+	if !h.requireFeature(c, "products") {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+	productID := c.Param("id")
+	product, err := h.repo.GetProduct(c.Request.Context(), tenantID, productID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "product_not_found")})
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// CreateProduct godoc
+// @Summary Criar produto
+// @Description Cria um novo produto. Requer feature 'products' e permissão 'prod_c'.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param request body swagger.CreateProductRequest true "Dados do produto"
+// @Success 201 {object} swagger.CreateIDResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/products [post]
+func (h *Handler) CreateProduct(c *gin.Context) {
+	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_c") {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+	var req struct {
+		Name         string      `json:"name" binding:"required"`
+		Description  *string     `json:"description"`
+		Price        float64     `json:"price" binding:"required,min=0"`
+		SKU          *string     `json:"sku"`
+		Stock        int         `json:"stock"`
+		Translations interface{} `json:"translations"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	var translationsJSON interface{}
+	if req.Translations != nil {
+		b, _ := json.Marshal(req.Translations)
+		translationsJSON = string(b)
+	}
+
+	id, err := h.repo.CreateProduct(c.Request.Context(), tenantID, req.Name, req.Description, req.Price, req.SKU, req.Stock, translationsJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_product")})
+		return
+	}
+	h.emitProduct(c, webhooks.EventProductCreated, tenantID, id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// UpdateProduct godoc
+// @Summary Atualizar produto
+// @Description Atualiza um produto. Requer feature 'products' e permissão 'prod_u'.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do produto"
+// @Param request body swagger.UpdateProductRequest true "Dados para atualização"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/products/{id} [put]
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_u") {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+	productID := c.Param("id")
+	var req struct {
+		Name         *string     `json:"name"`
+		Description  *string     `json:"description"`
+		Price        *float64    `json:"price"`
+		SKU          *string     `json:"sku"`
+		Stock        *int        `json:"stock"`
+		IsActive     *bool       `json:"is_active"`
+		Translations interface{} `json:"translations"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	var translationsJSON interface{}
+	if req.Translations != nil {
+		b, _ := json.Marshal(req.Translations)
+		translationsJSON = string(b)
+	}
+
+	if err := h.repo.UpdateProduct(c.Request.Context(), tenantID, productID, req.Name, req.Description, req.Price, req.SKU, req.Stock, req.IsActive, translationsJSON); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_product")})
+		return
+	}
+	h.emitProduct(c, webhooks.EventProductUpdated, tenantID, productID)
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "product_updated")})
+}
+
+// DeleteProduct godoc
+// @Summary Remover produto
+// @Description Remove um produto. Requer feature 'products' e permissão 'prod_d'.
+// @Tags Products
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do produto"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/products/{id} [delete]
+func (h *Handler) DeleteProduct(c *gin.Context) {
+	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_d") {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+	productID := c.Param("id")
+	if err := h.repo.DeleteProduct(c.Request.Context(), tenantID, productID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_product")})
+		return
+	}
+	h.service.EmitWebhook(c.Request.Context(), tenantID, webhooks.EventProductDeleted, gin.H{"id": productID})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "product_deleted")})
+}
+
+// emitProduct queues a product webhook event carrying the product as stored
+func (h *Handler) emitProduct(c *gin.Context, event, tenantID, productID string) {
+	product, err := h.repo.GetProduct(c.Request.Context(), tenantID, productID)
+	if err != nil {
+		return
+	}
+	h.service.EmitWebhook(c.Request.Context(), tenantID, event, product)
+}
+
+// UploadProductImage godoc
+// @Summary Upload de imagens do produto
+// @Description Faz upload de uma ou mais imagens para um produto. Requer feature 'products' e permissão 'prod_u'.
+// @Tags Products
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do produto"
+// @Param images formData file true "Imagens do produto (campo 'images' ou 'image')"
+// @Param visibility formData string false "public (padrão) ou private"
+// @Success 200 {object} swagger.ImageUploadResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
+// @Router /{url_code}/products/{id}/images [post]
+func (h *Handler) UploadProductImage(c *gin.Context) {
+	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_u") {
+		return
+	}
+	h.uploadImages(c, "products", c.Param("id"))
 }
 
-func (h *Handler) requirePermission(c *gin.Context, permSlug string) bool {
-	userID := c.GetString("user_id")
-	tenantID := c.GetString("tenant_id")
-	if h.service.IsOwner(c.Request.Context(), userID, tenantID) {
-		return true
-	}
-	if h.service.HasPermission(c.Request.Context(), userID, tenantID, permSlug) {
-		return true
-	}
-	c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
-	return false
-}
+// ==================== SERVICES ====================
 
-// ListProducts godoc
-// @Summary Listar produtos
-// @Description Retorna produtos do tenant paginados. Requer feature 'products'.
-// @Tags Products
+// ListServices godoc
+// @Summary Listar serviços
+// @Description Retorna serviços do tenant paginados. Requer feature 'services'.
+// @Tags Services
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
@@ -1044,67 +2375,67 @@ func (h *Handler) requirePermission(c *gin.Context, permSlug string) bool {
 // @Param page_size query int false "Itens por página" default(20)
 // @Success 200 {object} swagger.PaginatedResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/products [get]
-func (h *Handler) ListProducts(c *gin.Context) {
-	if !h.requireFeature(c, "products") {
+// @Router /{url_code}/services [get]
+func (h *Handler) ListServices(c *gin.Context) {
+	if !h.requireFeature(c, "services") {
 		return
 	}
 	tenantID := c.GetString("tenant_id")
 	pag := utils.GetPagination(c)
 
-	products, total, err := h.repo.ListProducts(c.Request.Context(), tenantID, pag.PageSize, pag.Offset)
+	services, total, err := h.repo.ListServices(c.Request.Context(), tenantID, pag.PageSize, pag.Offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_products")})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_services")})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"data":      products,
+		"data":      services,
 		"total":     total,
 		"page":      pag.Page,
 		"page_size": pag.PageSize,
 	})
 }
 
-// GetProduct godoc
-// @Summary Obter produto
-// @Description Retorna um produto específico. Requer feature 'products'.
-// @Tags Products
+// GetService godoc
+// @Summary Obter serviço
+// @Description Retorna um serviço específico. Requer feature 'services'.
+// @Tags Services
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do produto"
-// @Success 200 {object} swagger.ProductResponse
+// @Param id path string true "ID do serviço"
+// @Success 200 {object} swagger.ServiceResponse
 // @Failure 404 {object} swagger.ErrorResponse
-// @Router /{url_code}/products/{id} [get]
-func (h *Handler) GetProduct(c *gin.Context) {
-	if !h.requireFeature(c, "products") {
+// @Router /{url_code}/services/{id} [get]
+func (h *Handler) GetService(c *gin.Context) {
+	if !h.requireFeature(c, "services") {
 		return
 	}
 	tenantID := c.GetString("tenant_id")
-	productID := c.Param("id")
-	product, err := h.repo.GetProduct(c.Request.Context(), tenantID, productID)
+	serviceID := c.Param("id")
+	service, err := h.repo.GetService(c.Request.Context(), tenantID, serviceID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "product_not_found")})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "service_not_found")})
 		return
 	}
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, service)
 }
 
-// CreateProduct godoc
-// @Summary Criar produto
-// @Description Cria um novo produto. Requer feature 'products' e permissão 'prod_c'.
-// @Tags Products
+// CreateService godoc
+// @Summary Criar serviço
+// @Description Cria um novo serviço. Requer feature 'services' e permissão 'serv_c'.
+// @Tags Services
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param request body swagger.CreateProductRequest true "Dados do produto"
+// @Param request body swagger.CreateServiceRequest true "Dados do serviço"
 // @Success 201 {object} swagger.CreateIDResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/products [post]
-func (h *Handler) CreateProduct(c *gin.Context) {
-	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_c") {
+// @Router /{url_code}/services [post]
+func (h *Handler) CreateService(c *gin.Context) {
+	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_c") {
 		return
 	}
 	tenantID := c.GetString("tenant_id")
@@ -1112,8 +2443,7 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		Name         string      `json:"name" binding:"required"`
 		Description  *string     `json:"description"`
 		Price        float64     `json:"price" binding:"required,min=0"`
-		SKU          *string     `json:"sku"`
-		Stock        int         `json:"stock"`
+		Duration     *int        `json:"duration"`
 		Translations interface{} `json:"translations"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1127,40 +2457,40 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		translationsJSON = string(b)
 	}
 
-	id, err := h.repo.CreateProduct(c.Request.Context(), tenantID, req.Name, req.Description, req.Price, req.SKU, req.Stock, translationsJSON)
+	id, err := h.repo.CreateService(c.Request.Context(), tenantID, req.Name, req.Description, req.Price, req.Duration, translationsJSON)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_product")})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_service")})
 		return
 	}
+	h.emitService(c, webhooks.EventServiceCreated, tenantID, id)
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
-// UpdateProduct godoc
-// @Summary Atualizar produto
-// @Description Atualiza um produto. Requer feature 'products' e permissão 'prod_u'.
-// @Tags Products
+// UpdateService godoc
+// @Summary Atualizar serviço
+// @Description Atualiza um serviço. Requer feature 'services' e permissão 'serv_u'.
+// @Tags Services
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do produto"
-// @Param request body swagger.UpdateProductRequest true "Dados para atualização"
+// @Param id path string true "ID do serviço"
+// @Param request body swagger.UpdateServiceRequest true "Dados para atualização"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/products/{id} [put]
-func (h *Handler) UpdateProduct(c *gin.Context) {
-	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_u") {
+// @Router /{url_code}/services/{id} [put]
+func (h *Handler) UpdateService(c *gin.Context) {
+	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_u") {
 		return
 	}
 	tenantID := c.GetString("tenant_id")
-	productID := c.Param("id")
+	serviceID := c.Param("id")
 	var req struct {
 		Name         *string     `json:"name"`
 		Description  *string     `json:"description"`
 		Price        *float64    `json:"price"`
-		SKU          *string     `json:"sku"`
-		Stock        *int        `json:"stock"`
+		Duration     *int        `json:"duration"`
 		IsActive     *bool       `json:"is_active"`
 		Translations interface{} `json:"translations"`
 	}
@@ -1175,60 +2505,86 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		translationsJSON = string(b)
 	}
 
-	if err := h.repo.UpdateProduct(c.Request.Context(), tenantID, productID, req.Name, req.Description, req.Price, req.SKU, req.Stock, req.IsActive, translationsJSON); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_product")})
+	if err := h.repo.UpdateService(c.Request.Context(), tenantID, serviceID, req.Name, req.Description, req.Price, req.Duration, req.IsActive, translationsJSON); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_service")})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "product_updated")})
+	h.emitService(c, webhooks.EventServiceUpdated, tenantID, serviceID)
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "service_updated")})
 }
 
-// DeleteProduct godoc
-// @Summary Remover produto
-// @Description Remove um produto. Requer feature 'products' e permissão 'prod_d'.
-// @Tags Products
+// DeleteService godoc
+// @Summary Remover serviço
+// @Description Remove um serviço. Requer feature 'services' e permissão 'serv_d'.
+// @Tags Services
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do produto"
+// @Param id path string true "ID do serviço"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/products/{id} [delete]
-func (h *Handler) DeleteProduct(c *gin.Context) {
-	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_d") {
+// @Router /{url_code}/services/{id} [delete]
+func (h *Handler) DeleteService(c *gin.Context) {
+	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_d") {
 		return
 	}
 	tenantID := c.GetString("tenant_id")
-	productID := c.Param("id")
-	if err := h.repo.DeleteProduct(c.Request.Context(), tenantID, productID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_product")})
+	serviceID := c.Param("id")
+	if err := h.repo.DeleteService(c.Request.Context(), tenantID, serviceID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_service")})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "product_deleted")})
+	h.service.EmitWebhook(c.Request.Context(), tenantID, webhooks.EventServiceDeleted, gin.H{"id": serviceID})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "service_deleted")})
 }
 
-// UploadProductImage godoc
-// @Summary Upload de imagens do produto
-// @Description Faz upload de uma ou mais imagens para um produto. Requer feature 'products' e permissão 'prod_u'.
-// @Tags Products
+// emitService queues a service webhook event carrying the service as stored
+func (h *Handler) emitService(c *gin.Context, event, tenantID, serviceID string) {
+	svc, err := h.repo.GetService(c.Request.Context(), tenantID, serviceID)
+	if err != nil {
+		return
+	}
+	h.service.EmitWebhook(c.Request.Context(), tenantID, event, svc)
+}
+
+// UploadServiceImage godoc
+// @Summary Upload de imagens do serviço
+// @Description Faz upload de uma ou mais imagens para um serviço. Requer feature 'services' e permissão 'serv_u'.
+// @Tags Services
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do produto"
-// @Param images formData file true "Imagens do produto (campo 'images' ou 'image')"
+// @Param id path string true "ID do serviço"
+// @Param images formData file true "Imagens do serviço (campo 'images' ou 'image')"
+// @Param visibility formData string false "public (padrão) ou private"
 // @Success 200 {object} swagger.ImageUploadResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/products/{id}/images [post]
-func (h *Handler) UploadProductImage(c *gin.Context) {
-	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_u") {
+// @Failure 413 {object} swagger.ErrorResponse
+// @Router /{url_code}/services/{id}/images [post]
+func (h *Handler) UploadServiceImage(c *gin.Context) {
+	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_u") {
 		return
 	}
+	h.uploadImages(c, "services", c.Param("id"))
+}
+
+// uploadImages stores the images of a multi-file upload for a product or
+// service and queues them for processing. Every file is validated on its
+// own against the plan's limits; rejected files are reported next to the
+// stored ones and only fail the request when nothing was stored.
+func (h *Handler) uploadImages(c *gin.Context, imageableType, imageableID string) {
 	tenantID := c.GetString("tenant_id")
-	productID := c.Param("id")
+	limits := h.service.UploadLimits(c.Request.Context(), tenantID)
+	upload.LimitBody(c.Writer, c.Request, limits, upload.MaxFiles)
 
 	form, err := c.MultipartForm()
 	if err != nil {
+		if upload.IsBodyTooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": i18n.T(c, "file_too_large")})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
 		return
 	}
@@ -1241,286 +2597,485 @@ func (h *Handler) UploadProductImage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
 		return
 	}
+	if len(files) > upload.MaxFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "too_many_files")})
+		return
+	}
+	visibility := c.DefaultPostForm("visibility", imageVisibilityPublic)
+	if !isImageVisibility(visibility) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid_image_visibility")})
+		return
+	}
 
-	var results []gin.H
+	uploadPath := imageDir(tenantID, imageableType, imageableID, visibility)
+	results := []gin.H{}
+	rejected := []gin.H{}
+	var firstErr error
 	for _, header := range files {
-		file, err := header.Open()
+		result, err := h.storeImage(c, header, limits, imageableType, imageableID, uploadPath, visibility)
 		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			rejected = append(rejected, gin.H{"filename": header.Filename, "error": i18n.T(c, err.Error())})
 			continue
 		}
+		results = append(results, result)
+	}
 
-		uploadPath := fmt.Sprintf("tenants/%s/images/products/%s", tenantID, productID)
-		publicURL, storagePath, err := h.storage.Upload(file, header, uploadPath)
-		file.Close()
-		if err != nil {
-			continue
+	if len(results) == 0 {
+		status := http.StatusBadRequest
+		if firstErr.Error() == "failed_upload" {
+			status = http.StatusInternalServerError
 		}
+		c.JSON(status, gin.H{"error": i18n.T(c, firstErr.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"images": results, "rejected": rejected})
+}
 
-		ext := strings.TrimPrefix(filepath.Ext(header.Filename), ".")
-		mimeType := header.Header.Get("Content-Type")
-		storageDriver := "local"
+// storeImage validates and stores one file of uploadImages and creates its
+// pending image record. Content the tenant already has is neither stored nor
+// processed again: the image shares the files of the existing one and is
+// completed right away. Errors are i18n keys.
+func (h *Handler) storeImage(c *gin.Context, header *multipart.FileHeader, limits upload.Limits, imageableType, imageableID, uploadPath, visibility string) (gin.H, error) {
+	ctx := c.Request.Context()
+	tenantID := c.GetString("tenant_id")
 
-		imageID, err := h.repo.CreateImageRecord(c.Request.Context(), tenantID, "products", productID, header.Filename, mimeType, ext, storageDriver, storagePath, publicURL, header.Size, nil)
-		if err != nil {
-			continue
-		}
+	file, err := header.Open()
+	if err != nil {
+		return nil, errors.New("failed_upload")
+	}
+	defer file.Close()
 
-		// Publish to Redis for async processing
-		msg, _ := json.Marshal(map[string]string{"image_id": imageID})
-		h.cache.Publish(c.Request.Context(), "image:process", string(msg))
+	info, err := upload.Validate(file, header, limits)
+	if err != nil {
+		return nil, err
+	}
 
-		results = append(results, gin.H{"image_id": imageID, "path": storagePath, "public_url": publicURL})
+	imageID, storagePath, publicURL, err := h.repo.CreateDuplicateImage(ctx, tenantID, imageableType, imageableID, header.Filename, info.ContentHash, visibility, nil)
+	if err != nil {
+		// Storing the file again is only wasteful
+		log.Printf("Warning: failed to look up duplicates of %s: %v", info.ContentHash, err)
+	}
+	if imageID != "" {
+		h.imageCompleted(ctx, tenantID, imageID)
+		return h.storedImage(ctx, imageID, storagePath, publicURL, "completed"), nil
 	}
 
-	if len(results) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
-		return
+	storagePath = info.StoragePath(uploadPath)
+	publicURL, err = h.storage.Put(ctx, storagePath, file, info.MimeType)
+	if err != nil {
+		return nil, errors.New("failed_upload")
+	}
+
+	imageID, err = h.repo.CreateImageRecord(ctx, tenantID, imageableType, imageableID, header.Filename, info.MimeType, info.Extension, h.driver, storagePath, publicURL, info.Size, nil, visibility, info.ContentHash)
+	if err != nil {
+		return nil, errors.New("failed_upload")
 	}
-	c.JSON(http.StatusOK, gin.H{"images": results})
+
+	// Queue for async processing; if this fails the worker's sweep picks the
+	// image up later
+	h.service.EnqueueImage(ctx, imageID)
+	return h.storedImage(ctx, imageID, storagePath, publicURL, "pending"), nil
 }
 
-// ==================== SERVICES ====================
+// storedImage is the result of storeImage. The file is stored either way,
+// so a private file whose URL cannot be signed is reported without a URL.
+func (h *Handler) storedImage(ctx context.Context, imageID, storagePath, publicURL, status string) gin.H {
+	result := gin.H{"image_id": imageID, "path": storagePath, "processing_status": status}
+	url, err := h.fileURL(ctx, storagePath, publicURL)
+	if err != nil {
+		log.Printf("Warning: failed to sign %s: %v", storagePath, err)
+		return result
+	}
+	result["public_url"] = url
+	return result
+}
 
-// ListServices godoc
-// @Summary Listar serviços
-// @Description Retorna serviços do tenant paginados. Requer feature 'services'.
-// @Tags Services
-// @Produce json
-// @Security BearerAuth
-// @Param url_code path string true "URL code do tenant"
-// @Param page query int false "Página" default(1)
-// @Param page_size query int false "Itens por página" default(20)
-// @Success 200 {object} swagger.PaginatedResponse
-// @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/services [get]
-func (h *Handler) ListServices(c *gin.Context) {
-	if !h.requireFeature(c, "services") {
-		return
+// imageCompleted announces an image that needed no processing the way the
+// worker announces a processed one: to SSE subscribers and with the
+// image.completed webhook
+func (h *Handler) imageCompleted(ctx context.Context, tenantID, imageID string) {
+	payload, _ := json.Marshal(map[string]string{"image_id": imageID})
+	h.cache.Publish(ctx, "image:done:"+imageID, string(payload))
+	if err := h.service.AppendImageEvent(ctx, imageID, imageevents.Completed, ""); err != nil {
+		log.Printf("Warning: failed to record completed event of image %s: %v", imageID, err)
 	}
-	tenantID := c.GetString("tenant_id")
-	pag := utils.GetPagination(c)
 
-	services, total, err := h.repo.ListServices(c.Request.Context(), tenantID, pag.PageSize, pag.Offset)
+	img, err := h.repo.GetImage(ctx, tenantID, imageID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_services")})
+		log.Printf("Warning: failed to load image %s for webhooks: %v", imageID, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"data":      services,
-		"total":     total,
-		"page":      pag.Page,
-		"page_size": pag.PageSize,
+	h.service.EmitWebhook(ctx, tenantID, webhooks.EventImageCompleted, gin.H{
+		"id":             img.ID,
+		"imageable_type": img.ImageableType,
+		"imageable_id":   img.ImageableID,
+		"width":          img.Width,
+		"height":         img.Height,
+		"original_url":   img.OriginalURL,
+		"variants":       img.Variants,
+		"blurhash":       img.BlurHash,
+		"dominant_color": img.DominantColor,
 	})
 }
 
-// GetService godoc
-// @Summary Obter serviço
-// @Description Retorna um serviço específico. Requer feature 'services'.
-// @Tags Services
-// @Produce json
-// @Security BearerAuth
-// @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do serviço"
-// @Success 200 {object} swagger.ServiceResponse
-// @Failure 404 {object} swagger.ErrorResponse
-// @Router /{url_code}/services/{id} [get]
-func (h *Handler) GetService(c *gin.Context) {
-	if !h.requireFeature(c, "services") {
+// directUploadTTL is how long a presigned upload URL stays valid
+const directUploadTTL = 15 * time.Minute
+
+// Image visibilities. Private images are only served through signed URLs
+// that expire after signedURLTTL.
+const (
+	imageVisibilityPublic  = "public"
+	imageVisibilityPrivate = "private"
+	signedURLTTL           = 15 * time.Minute
+)
+
+func isImageVisibility(v string) bool {
+	return v == imageVisibilityPublic || v == imageVisibilityPrivate
+}
+
+// imageDir is where the images of a product or service are stored
+func imageDir(tenantID, imageableType, imageableID, visibility string) string {
+	dir := fmt.Sprintf("tenants/%s/images/%s/%s", tenantID, imageableType, imageableID)
+	if visibility == imageVisibilityPrivate {
+		return storage.PrivatePrefix + dir
+	}
+	return dir
+}
+
+// fileURL returns publicURL for a public file and a signed URL for a
+// private one. A private file never gets its unsigned URL, so signing
+// errors are returned.
+func (h *Handler) fileURL(ctx context.Context, storagePath, publicURL string) (string, error) {
+	if !storage.IsPrivate(storagePath) {
+		return publicURL, nil
+	}
+	return h.storage.SignedURL(ctx, storagePath, signedURLTTL)
+}
+
+// signImageURLs replaces the URLs of a private image with signed ones. URLs
+// that cannot be signed are left out.
+func (h *Handler) signImageURLs(ctx context.Context, img *repo.ImageRow) {
+	if img.Visibility != imageVisibilityPrivate {
 		return
 	}
-	tenantID := c.GetString("tenant_id")
-	serviceID := c.Param("id")
-	service, err := h.repo.GetService(c.Request.Context(), tenantID, serviceID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "service_not_found")})
+	if img.OriginalURL != nil {
+		signed, err := h.fileURL(ctx, img.OriginalPath, *img.OriginalURL)
+		if err != nil {
+			log.Printf("Warning: failed to sign %s: %v", img.OriginalPath, err)
+			img.OriginalURL = nil
+		} else {
+			img.OriginalURL = &signed
+		}
+	}
+	for name, v := range img.Variants {
+		signed, err := h.fileURL(ctx, v.Path, v.URL)
+		if err != nil {
+			log.Printf("Warning: failed to sign %s: %v", v.Path, err)
+		}
+		v.URL = signed
+		img.Variants[name] = v
+	}
+}
+
+// CreateProductDirectUpload godoc
+// @Summary Iniciar upload direto de imagem do produto
+// @Description Cria a imagem com status 'uploading' e retorna uma URL assinada para enviar o arquivo via PUT direto ao storage, sem passar pela API. Depois do envio, chame POST /images/{id}/complete. Requer feature 'products' e permissão 'prod_u'.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do produto"
+// @Param request body swagger.DirectUploadRequest true "Arquivo a enviar"
+// @Success 201 {object} swagger.DirectUploadResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
+// @Router /{url_code}/products/{id}/images/direct [post]
+func (h *Handler) CreateProductDirectUpload(c *gin.Context) {
+	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_u") {
 		return
 	}
-	c.JSON(http.StatusOK, service)
+	h.createDirectUpload(c, "products", c.Param("id"))
 }
 
-// CreateService godoc
-// @Summary Criar serviço
-// @Description Cria um novo serviço. Requer feature 'services' e permissão 'serv_c'.
+// CreateServiceDirectUpload godoc
+// @Summary Iniciar upload direto de imagem do serviço
+// @Description Cria a imagem com status 'uploading' e retorna uma URL assinada para enviar o arquivo via PUT direto ao storage, sem passar pela API. Depois do envio, chame POST /images/{id}/complete. Requer feature 'services' e permissão 'serv_u'.
 // @Tags Services
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param request body swagger.CreateServiceRequest true "Dados do serviço"
-// @Success 201 {object} swagger.CreateIDResponse
+// @Param id path string true "ID do serviço"
+// @Param request body swagger.DirectUploadRequest true "Arquivo a enviar"
+// @Success 201 {object} swagger.DirectUploadResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/services [post]
-func (h *Handler) CreateService(c *gin.Context) {
-	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_c") {
+// @Failure 413 {object} swagger.ErrorResponse
+// @Router /{url_code}/services/{id}/images/direct [post]
+func (h *Handler) CreateServiceDirectUpload(c *gin.Context) {
+	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_u") {
 		return
 	}
+	h.createDirectUpload(c, "services", c.Param("id"))
+}
+
+func (h *Handler) createDirectUpload(c *gin.Context, imageableType, imageableID string) {
 	tenantID := c.GetString("tenant_id")
+
 	var req struct {
-		Name         string      `json:"name" binding:"required"`
-		Description  *string     `json:"description"`
-		Price        float64     `json:"price" binding:"required,min=0"`
-		Duration     *int        `json:"duration"`
-		Translations interface{} `json:"translations"`
+		Filename    string `json:"filename" binding:"required,max=255"`
+		ContentType string `json:"content_type" binding:"required"`
+		Size        int64  `json:"size" binding:"required,min=1"`
+		Visibility  string `json:"visibility" binding:"omitempty,oneof=public private"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
 
-	var translationsJSON interface{}
-	if req.Translations != nil {
-		b, _ := json.Marshal(req.Translations)
-		translationsJSON = string(b)
+	// The declared type and size are checked again against the stored file
+	// when the upload is completed
+	ext, ok := upload.Allowed[req.ContentType]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "unsupported_file_type")})
+		return
+	}
+	limits := h.service.UploadLimits(c.Request.Context(), tenantID)
+	if req.Size > limits.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": i18n.T(c, "file_too_large")})
+		return
 	}
 
-	id, err := h.repo.CreateService(c.Request.Context(), tenantID, req.Name, req.Description, req.Price, req.Duration, translationsJSON)
+	if req.Visibility == "" {
+		req.Visibility = imageVisibilityPublic
+	}
+
+	info := &upload.Image{MimeType: req.ContentType, Extension: ext, Size: req.Size}
+	storagePath := info.StoragePath(imageDir(tenantID, imageableType, imageableID, req.Visibility))
+	expiresAt := time.Now().Add(directUploadTTL)
+	uploadURL, headers, err := h.storage.PresignPut(c.Request.Context(), storagePath, req.ContentType, req.Size, directUploadTTL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_service")})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_upload")})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"id": id})
+
+	imageID, err := h.repo.CreateUploadingImage(c.Request.Context(), tenantID, imageableType, imageableID, req.Filename, info.MimeType, info.Extension, h.driver, storagePath, h.storage.URL(storagePath), req.Size, req.Visibility)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_create_upload")})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"image_id":   imageID,
+		"upload_url": uploadURL,
+		"method":     http.MethodPut,
+		"headers":    headers,
+		"expires_at": expiresAt,
+	})
 }
 
-// UpdateService godoc
-// @Summary Atualizar serviço
-// @Description Atualiza um serviço. Requer feature 'services' e permissão 'serv_u'.
-// @Tags Services
-// @Accept json
+// CompleteDirectUpload godoc
+// @Summary Concluir upload direto
+// @Description Confirma que o arquivo de um upload direto foi enviado. O arquivo é conferido no storage (tamanho, tipo real e dimensões) e a imagem é enfileirada para processamento. Um arquivo rejeitado é apagado junto com a imagem. Se o tenant já tem uma imagem processada com o mesmo conteúdo, a nova imagem passa a usar os mesmos arquivos e é concluída na hora (200).
+// @Tags Images
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do serviço"
-// @Param request body swagger.UpdateServiceRequest true "Dados para atualização"
-// @Success 200 {object} swagger.MessageResponse
+// @Param id path string true "ID da imagem"
+// @Success 200 {object} swagger.DirectUploadCompleteResponse
+// @Success 202 {object} swagger.DirectUploadCompleteResponse
 // @Failure 400 {object} swagger.ErrorResponse
-// @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/services/{id} [put]
-func (h *Handler) UpdateService(c *gin.Context) {
-	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_u") {
+// @Failure 404 {object} swagger.ErrorResponse
+// @Failure 409 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
+// @Router /{url_code}/images/{id}/complete [post]
+func (h *Handler) CompleteDirectUpload(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	imageID := c.Param("id")
+	ctx := c.Request.Context()
+
+	img, err := h.repo.GetUploadingImage(ctx, tenantID, imageID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "image_not_found")})
 		return
 	}
-	tenantID := c.GetString("tenant_id")
-	serviceID := c.Param("id")
-	var req struct {
-		Name         *string     `json:"name"`
-		Description  *string     `json:"description"`
-		Price        *float64    `json:"price"`
-		Duration     *int        `json:"duration"`
-		IsActive     *bool       `json:"is_active"`
-		Translations interface{} `json:"translations"`
+	if img.ProcessingStatus != "uploading" {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "image_already_uploaded")})
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+
+	obj, err := storage.Stat(ctx, h.storage, img.OriginalPath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "upload_not_found")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_complete_upload")})
 		return
 	}
 
-	var translationsJSON interface{}
-	if req.Translations != nil {
-		b, _ := json.Marshal(req.Translations)
-		translationsJSON = string(b)
+	limits := h.service.UploadLimits(ctx, tenantID)
+	if obj.Size > limits.MaxBytes {
+		h.rejectDirectUpload(c, tenantID, imageID, img.OriginalPath, http.StatusRequestEntityTooLarge, upload.ErrFileTooLarge)
+		return
+	}
+	reader, err := h.storage.GetReader(img.OriginalPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_complete_upload")})
+		return
+	}
+	info, err := upload.InspectAll(reader, limits)
+	reader.Close()
+	if err != nil {
+		h.rejectDirectUpload(c, tenantID, imageID, img.OriginalPath, http.StatusBadRequest, err)
+		return
 	}
 
-	if err := h.repo.UpdateService(c.Request.Context(), tenantID, serviceID, req.Name, req.Description, req.Price, req.Duration, req.IsActive, translationsJSON); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_service")})
+	// Content the tenant already has is not processed again; the image
+	// shares the existing files and the uploaded copy is dropped
+	deduplicated, err := h.repo.CompleteDuplicateUpload(ctx, tenantID, imageID, info.ContentHash)
+	if err != nil {
+		log.Printf("Warning: failed to look up duplicates of %s: %v", info.ContentHash, err)
+	}
+	if deduplicated {
+		h.storage.Delete(img.OriginalPath)
+		h.imageCompleted(ctx, tenantID, imageID)
+		c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "image_upload_completed"), "image_id": imageID, "processing_status": "completed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "service_updated")})
-}
 
-// DeleteService godoc
-// @Summary Remover serviço
-// @Description Remove um serviço. Requer feature 'services' e permissão 'serv_d'.
-// @Tags Services
-// @Produce json
-// @Security BearerAuth
-// @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do serviço"
-// @Success 200 {object} swagger.MessageResponse
-// @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/services/{id} [delete]
-func (h *Handler) DeleteService(c *gin.Context) {
-	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_d") {
+	completed, err := h.repo.CompleteImageUpload(ctx, tenantID, imageID, info.MimeType, info.Extension, obj.Size, info.ContentHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_complete_upload")})
 		return
 	}
-	tenantID := c.GetString("tenant_id")
-	serviceID := c.Param("id")
-	if err := h.repo.DeleteService(c.Request.Context(), tenantID, serviceID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_service")})
+	if !completed {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "image_already_uploaded")})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "service_deleted")})
+
+	// If this fails the worker's sweep picks the image up later
+	h.service.EnqueueImage(ctx, imageID)
+	c.JSON(http.StatusAccepted, gin.H{"message": i18n.T(c, "image_upload_completed"), "image_id": imageID, "processing_status": "pending"})
 }
 
-// UploadServiceImage godoc
-// @Summary Upload de imagens do serviço
-// @Description Faz upload de uma ou mais imagens para um serviço. Requer feature 'services' e permissão 'serv_u'.
-// @Tags Services
-// @Accept multipart/form-data
-// @Produce json
-// @Security BearerAuth
-// @Param url_code path string true "URL code do tenant"
-// @Param id path string true "ID do serviço"
-// @Param images formData file true "Imagens do serviço (campo 'images' ou 'image')"
-// @Success 200 {object} swagger.ImageUploadResponse
-// @Failure 400 {object} swagger.ErrorResponse
-// @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/services/{id}/images [post]
-func (h *Handler) UploadServiceImage(c *gin.Context) {
-	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_u") {
+// rejectDirectUpload deletes a direct upload that failed validation, file
+// and image, and responds with the validation error
+func (h *Handler) rejectDirectUpload(c *gin.Context, tenantID, imageID, storagePath string, status int, cause error) {
+	if err := h.repo.DeleteImageRecord(c.Request.Context(), tenantID, imageID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_complete_upload")})
 		return
 	}
-	tenantID := c.GetString("tenant_id")
-	serviceID := c.Param("id")
+	h.storage.Delete(storagePath)
+	c.JSON(status, gin.H{"error": i18n.T(c, cause.Error())})
+}
 
-	form, err := c.MultipartForm()
+// PutLocalUpload godoc
+// @Summary Receber upload direto (storage local)
+// @Description Recebe o arquivo de um upload direto quando o storage é local. A URL, com assinatura e validade, é gerada por POST /products/{id}/images/direct ou /services/{id}/images/direct. Só é aceito enquanto a imagem estiver com status 'uploading': depois de concluído o upload, ou removida a imagem, retorna 409.
+// @Tags Images
+// @Accept octet-stream
+// @Param filepath path string true "Caminho do arquivo"
+// @Success 200
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 409 {object} swagger.ErrorResponse
+// @Failure 413 {object} swagger.ErrorResponse
+// @Router /uploads/{filepath} [put]
+func (h *Handler) PutLocalUpload(c *gin.Context) {
+	local, ok := h.storage.(*storage.LocalProvider)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	storagePath := strings.TrimPrefix(c.Param("filepath"), "/")
+	contentType, maxBytes, err := local.VerifyPut(storagePath, c.Request.URL.Query())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "invalid_upload_signature")})
 		return
 	}
-	files := form.File["images"]
-	if len(files) == 0 {
-		files = form.File["image"]
+	// The URL stays valid until it expires; once the upload is completed,
+	// or the image deleted, the file must not be replaced behind the
+	// worker's back
+	pending, err := h.repo.UploadPending(c.Request.Context(), storagePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
+		return
 	}
-	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "no_file_provided")})
+	if !pending {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "image_already_uploaded")})
 		return
 	}
 
-	var results []gin.H
-	for _, header := range files {
-		file, err := header.Open()
-		if err != nil {
-			continue
-		}
-
-		uploadPath := fmt.Sprintf("tenants/%s/images/services/%s", tenantID, serviceID)
-		publicURL, storagePath, err := h.storage.Upload(file, header, uploadPath)
-		file.Close()
-		if err != nil {
-			continue
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	if _, err := local.Put(c.Request.Context(), storagePath, body, contentType); err != nil {
+		if upload.IsBodyTooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": i18n.T(c, "file_too_large")})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
+		return
+	}
+	c.Status(http.StatusOK)
+}
 
-		ext := strings.TrimPrefix(filepath.Ext(header.Filename), ".")
-		mimeType := header.Header.Get("Content-Type")
-		storageDriver := "local"
-
-		imageID, err := h.repo.CreateImageRecord(c.Request.Context(), tenantID, "services", serviceID, header.Filename, mimeType, ext, storageDriver, storagePath, publicURL, header.Size, nil)
-		if err != nil {
-			continue
+// ServeUpload serves files of the local storage provider. Public files are
+// served to anyone; private files only with a valid URL from SignedURL, and
+// are reported missing otherwise.
+func (h *Handler) ServeUpload(c *gin.Context) {
+	local, ok := h.storage.(*storage.LocalProvider)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	storagePath := strings.TrimPrefix(path.Clean(c.Param("filepath")), "/")
+	if storage.IsPrivate(storagePath) {
+		if err := local.VerifyGet(storagePath, c.Request.URL.Query()); err != nil {
+			c.Status(http.StatusNotFound)
+			return
 		}
+		c.Header("Cache-Control", "private, no-store")
+	}
+	local.ServeFile(c.Writer, c.Request, storagePath)
+}
 
-		msg, _ := json.Marshal(map[string]string{"image_id": imageID})
-		h.cache.Publish(c.Request.Context(), "image:process", string(msg))
+// DownloadMedia godoc
+// @Summary Baixar arquivo do tenant
+// @Description Redireciona para uma URL assinada e temporária do arquivo, público ou privado. Só aceita caminhos do próprio tenant (tenants/{tenant_id}/... ou private/tenants/{tenant_id}/...).
+// @Tags Images
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param filepath path string true "Caminho do arquivo no storage"
+// @Success 302
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/media/{filepath} [get]
+func (h *Handler) DownloadMedia(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	storagePath := strings.TrimPrefix(path.Clean(c.Param("filepath")), "/")
 
-		results = append(results, gin.H{"image_id": imageID, "path": storagePath, "public_url": publicURL})
+	// Another tenant's files are reported missing, not forbidden, so their
+	// paths cannot be probed
+	own := "tenants/" + tenantID + "/"
+	if !strings.HasPrefix(storagePath, own) && !strings.HasPrefix(storagePath, storage.PrivatePrefix+own) {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "file_not_found")})
+		return
+	}
+	if _, err := storage.Stat(c.Request.Context(), h.storage, storagePath); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "file_not_found")})
+		return
 	}
 
-	if len(results) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_upload")})
+	url, err := h.storage.SignedURL(c.Request.Context(), storagePath, signedURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_sign_url")})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"images": results})
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, url)
 }
 
 // ==================== SETTINGS ====================
@@ -1646,12 +3201,88 @@ func (h *Handler) GetSettings(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param url_code path string true "URL code do tenant"
-// @Param request body swagger.UpdateTenantSettingsRequest true "Configurações do tenant"
+// @Param request body swagger.UpdateTenantSettingsRequest true "Configurações do tenant"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/settings [put]
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "setg_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+		return
+	}
+
+	var req struct {
+		Layout      interface{} `json:"layout"`
+		ConvertWebp *bool       `json:"convert_webp"`
+		Language    string      `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	// Validate language if provided
+	var langPtr *string
+	if req.Language != "" {
+		if !i18n.IsValidLanguage(req.Language) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid_language")})
+			return
+		}
+		langPtr = &req.Language
+	}
+
+	if err := h.repo.UpsertTenantSettings(c.Request.Context(), tenantID, req.Layout, req.ConvertWebp, langPtr); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_save_settings")})
+		return
+	}
+
+	// Invalidate tenant cache if language changed
+	if langPtr != nil {
+		h.service.InvalidateTenantContext(c.Request.Context(), tenantID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "settings_saved")})
+}
+
+// GetImageVariants godoc
+// @Summary Obter variantes de imagem
+// @Description Retorna os presets de variantes gerados para as imagens do tenant e os limites do plano
+// @Tags Settings
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 200 {object} swagger.ImageVariantsResponse
+// @Failure 500 {object} swagger.ErrorResponse
+// @Router /{url_code}/settings/image-variants [get]
+func (h *Handler) GetImageVariants(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	variants, err := h.service.GetImageVariants(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_load_image_variants")})
+		return
+	}
+	c.JSON(http.StatusOK, variants)
+}
+
+// UpdateImageVariants godoc
+// @Summary Atualizar variantes de imagem
+// @Description Define os presets de variantes (nome, tamanho máximo, modo fit/fill/crop e qualidade) dentro dos limites do plano. Lista vazia restaura os presets padrão. Imagens existentes mantêm as variantes atuais até serem re-renderizadas. Requer permissão 'setg_m' ou ser owner.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param request body swagger.UpdateImageVariantsRequest true "Presets de variantes"
 // @Success 200 {object} swagger.MessageResponse
 // @Failure 400 {object} swagger.ErrorResponse
 // @Failure 403 {object} swagger.ErrorResponse
-// @Router /{url_code}/settings [put]
-func (h *Handler) UpdateSettings(c *gin.Context) {
+// @Router /{url_code}/settings/image-variants [put]
+func (h *Handler) UpdateImageVariants(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
 
@@ -1662,40 +3293,47 @@ func (h *Handler) UpdateSettings(c *gin.Context) {
 	}
 
 	var req struct {
-		Layout      interface{} `json:"layout"`
-		ConvertWebp *bool       `json:"convert_webp"`
-		Language    string      `json:"language"`
+		Variants []imagevariant.Preset `json:"variants"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
 		return
 	}
 
-	// Validate language if provided
-	var langPtr *string
-	if req.Language != "" {
-		if !i18n.IsValidLanguage(req.Language) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid_language")})
-			return
-		}
-		langPtr = &req.Language
+	if err := h.service.SaveImageVariants(c.Request.Context(), tenantID, req.Variants); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, err.Error())})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "image_variants_saved")})
+}
 
-	if err := h.repo.UpsertTenantSettings(c.Request.Context(), tenantID, req.Layout, req.ConvertWebp, langPtr); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_save_settings")})
+// RerenderImages godoc
+// @Summary Re-renderizar variantes de imagem
+// @Description Enfileira todas as imagens já processadas do tenant para gerar novamente as variantes com os presets atuais. As imagens voltam a 'pending' até serem reprocessadas. Requer permissão 'setg_m' ou ser owner.
+// @Tags Settings
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 202 {object} swagger.ImageRerenderResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 500 {object} swagger.ErrorResponse
+// @Router /{url_code}/settings/image-variants/rerender [post]
+func (h *Handler) RerenderImages(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "setg_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
 		return
 	}
 
-	// Invalidate tenant cache if language changed
-	if langPtr != nil {
-		urlCode := c.Param("url_code")
-		if urlCode != "" {
-			cacheKey := fmt.Sprintf("tenant:urlcode:%s", urlCode)
-			h.cache.DelCache(c.Request.Context(), cacheKey)
-		}
+	queued, err := h.service.RerenderImages(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_rerender_images")})
+		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "settings_saved")})
+	c.JSON(http.StatusAccepted, gin.H{"message": i18n.T(c, "image_rerender_queued"), "queued": queued})
 }
 
 // ==================== IMAGES ====================
@@ -1722,6 +3360,9 @@ func (h *Handler) ListProductImages(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_images")})
 		return
 	}
+	for _, img := range images {
+		h.signImageURLs(c.Request.Context(), img)
+	}
 	c.JSON(http.StatusOK, gin.H{"images": images})
 }
 
@@ -1747,12 +3388,206 @@ func (h *Handler) ListServiceImages(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_list_images")})
 		return
 	}
+	for _, img := range images {
+		h.signImageURLs(c.Request.Context(), img)
+	}
 	c.JSON(http.StatusOK, gin.H{"images": images})
 }
 
+// ReorderProductImages godoc
+// @Summary Reordenar imagens do produto
+// @Description Define a ordem de exibição das imagens do produto. A lista deve conter cada imagem do produto exatamente uma vez. Requer feature 'products' e permissão 'prod_u'.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do produto"
+// @Param request body swagger.ReorderImagesRequest true "IDs das imagens na nova ordem"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/products/{id}/images/order [put]
+func (h *Handler) ReorderProductImages(c *gin.Context) {
+	if !h.requireFeature(c, "products") || !h.requirePermission(c, "prod_u") {
+		return
+	}
+	h.reorderImages(c, "products")
+}
+
+// ReorderServiceImages godoc
+// @Summary Reordenar imagens do serviço
+// @Description Define a ordem de exibição das imagens do serviço. A lista deve conter cada imagem do serviço exatamente uma vez. Requer feature 'services' e permissão 'serv_u'.
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do serviço"
+// @Param request body swagger.ReorderImagesRequest true "IDs das imagens na nova ordem"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Router /{url_code}/services/{id}/images/order [put]
+func (h *Handler) ReorderServiceImages(c *gin.Context) {
+	if !h.requireFeature(c, "services") || !h.requirePermission(c, "serv_u") {
+		return
+	}
+	h.reorderImages(c, "services")
+}
+
+func (h *Handler) reorderImages(c *gin.Context, imageableType string) {
+	tenantID := c.GetString("tenant_id")
+	imageableID := c.Param("id")
+
+	var req struct {
+		ImageIDs []string `json:"image_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
+
+	if err := h.service.ReorderImages(c.Request.Context(), tenantID, imageableType, imageableID, req.ImageIDs); err != nil {
+		if err.Error() == "invalid_image_order" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid_image_order")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_reorder_images")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "image_order_updated")})
+}
+
+const (
+	// imageEventsBatch is how many logged events a tenant's image event
+	// stream reads at a time while it catches up
+	imageEventsBatch = 500
+	// imageEventsHeartbeat is how often an idle image event stream sends a
+	// comment, so proxies do not close the connection
+	imageEventsHeartbeat = 15 * time.Second
+)
+
+// tenantImageEvent is an event of the tenant's image event stream. Completed
+// events carry the image as it is now.
+type tenantImageEvent struct {
+	imageevents.Event
+	Image *repo.ImageRow `json:"image,omitempty"`
+}
+
+// StreamTenantImageEvents godoc
+// @Summary Stream SSE de processamento de todas as imagens do tenant
+// @Description Abre uma única conexão SSE com as mudanças de estado de todas as imagens do tenant: 'processing', 'completed' (com a imagem, incluindo blurhash e dominant_color) e 'failed' (com o motivo em reason). Cada evento tem um id; ao reconectar com o header Last-Event-ID (ou ?last_event_id) os eventos perdidos, das últimas 24h, são reenviados. Sem ele, só eventos novos são enviados. Um comentário de heartbeat é enviado a cada 15s.
+// @Tags Images
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param Last-Event-ID header int false "ID do último evento recebido"
+// @Param last_event_id query int false "ID do último evento recebido, para clientes que não enviam o header"
+// @Success 200 {object} swagger.ImageEventResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Router /{url_code}/images/events [get]
+func (h *Handler) StreamTenantImageEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := c.GetString("tenant_id")
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid_last_event_id")})
+			return
+		}
+		lastID = id
+	}
+
+	// Subscribe before reading the log, so no event falls in between
+	pubsub := h.service.SubscribeImageEvents(ctx, tenantID)
+	defer pubsub.Close()
+
+	if lastEventID == "" {
+		latest, err := h.service.LatestImageEvent(ctx, tenantID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_load_image_events")})
+			return
+		}
+		lastID = latest
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeader(http.StatusOK)
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	// sendNew writes the events logged after lastID. A notification only
+	// says there is something new; the log is what is sent, and a tenant's
+	// events commit in id order, so events are never skipped or repeated.
+	sendNew := func() bool {
+		for {
+			events, err := h.service.ImageEventsSince(ctx, tenantID, lastID, imageEventsBatch)
+			if err != nil {
+				log.Printf("Error loading image events of tenant %s: %v", tenantID, err)
+				return false
+			}
+			for _, e := range events {
+				data := tenantImageEvent{Event: e}
+				if e.Type == imageevents.Completed {
+					if img, err := h.repo.GetImage(ctx, tenantID, e.ImageID); err == nil {
+						h.signImageURLs(ctx, img)
+						data.Image = img
+					}
+				}
+				b, _ := json.Marshal(data)
+				fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, string(b))
+				lastID = e.ID
+			}
+			c.Writer.Flush()
+			if len(events) < imageEventsBatch {
+				return true
+			}
+		}
+	}
+
+	if !sendNew() {
+		return
+	}
+
+	ch := pubsub.Channel()
+	heartbeat := time.NewTicker(imageEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+			// Also picks up events whose notification was lost
+			if !sendNew() {
+				return
+			}
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !sendNew() {
+				return
+			}
+		}
+	}
+}
+
 // StreamImageEvents godoc
 // @Summary Stream SSE de processamento de imagem
-// @Description Abre conexão SSE. Envia evento 'pending' ao conectar, 'completed' quando o processamento terminar, 'timeout' após 90s.
+// @Description Abre conexão SSE. Envia evento 'pending' ao conectar, 'completed' quando o processamento terminar (com a imagem, incluindo blurhash e dominant_color), 'failed' se o processamento falhar em todas as tentativas, 'timeout' após 90s. Para acompanhar várias imagens numa só conexão, use /{url_code}/images/events.
 // @Tags Images
 // @Produce text/event-stream
 // @Security BearerAuth
@@ -1785,14 +3620,10 @@ func (h *Handler) StreamImageEvents(c *gin.Context) {
 		sendEvent("error", gin.H{"error": "image not found"})
 		return
 	}
-	if b, e := json.Marshal(img); e == nil {
-		var m map[string]interface{}
-		if json.Unmarshal(b, &m) == nil {
-			if status, _ := m["processing_status"].(string); status == "completed" {
-				sendEvent("completed", img)
-				return
-			}
-		}
+	if status := img.ProcessingStatus; status == "completed" || status == "failed" {
+		h.signImageURLs(c.Request.Context(), img)
+		sendEvent(status, img)
+		return
 	}
 
 	// Notify client connection is established
@@ -1816,6 +3647,11 @@ func (h *Handler) StreamImageEvents(c *gin.Context) {
 			updatedImg, err := h.repo.GetImage(c.Request.Context(), tenantID, imageID)
 			if err != nil {
 				sendEvent("error", gin.H{"error": "failed to load image"})
+				return
+			}
+			h.signImageURLs(c.Request.Context(), updatedImg)
+			if updatedImg.ProcessingStatus == "failed" {
+				sendEvent("failed", updatedImg)
 			} else {
 				sendEvent("completed", updatedImg)
 			}
@@ -1865,7 +3701,7 @@ func (h *Handler) UpdateImageTitle(c *gin.Context) {
 
 // DeleteImage godoc
 // @Summary Remover imagem
-// @Description Remove uma imagem e seus arquivos de variantes
+// @Description Remove uma imagem e seus arquivos de variantes. Arquivos compartilhados com outras imagens de mesmo conteúdo só são apagados junto com a última delas.
 // @Tags Images
 // @Produce json
 // @Security BearerAuth
@@ -1878,33 +3714,218 @@ func (h *Handler) DeleteImage(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	imageID := c.Param("id")
 
-	// Get paths before deletion to clean up files
-	origPath, medPath, smPath, thPath, _, _, err := h.repo.GetImagePaths(c.Request.Context(), tenantID, imageID)
-	if err != nil {
+	if _, _, _, _, err := h.repo.GetImagePaths(c.Request.Context(), tenantID, imageID); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "image_not_found")})
 		return
 	}
 
-	if err := h.repo.DeleteImageRecord(c.Request.Context(), tenantID, imageID); err != nil {
+	// Files shared with images of the same content stay until the last of
+	// them is deleted
+	paths, err := h.repo.DeleteImage(c.Request.Context(), tenantID, imageID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_delete_image")})
 		return
 	}
+	for _, p := range paths {
+		h.storage.Delete(p)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "image_deleted")})
+}
+
+// imageUpdatePermissions is the permission needed to change an image, by
+// imageable type. Profile pictures have no visibility of their own.
+var imageUpdatePermissions = map[string]string{
+	"products": "prod_u",
+	"services": "serv_u",
+}
+
+// UpdateImageVisibility godoc
+// @Summary Alterar visibilidade da imagem
+// @Description Torna uma imagem de produto ou serviço pública ou privada. O original e as variantes são movidos para o novo local; imagens privadas só são servidas por URLs assinadas que expiram. A imagem não pode estar em processamento. Requer permissão 'prod_u' ou 'serv_u'.
+// @Tags Images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da imagem"
+// @Param request body swagger.UpdateImageVisibilityRequest true "Nova visibilidade"
+// @Success 200 {object} swagger.ImageResponse
+// @Failure 400 {object} swagger.ErrorResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Failure 409 {object} swagger.ErrorResponse
+// @Router /{url_code}/images/{id}/visibility [put]
+func (h *Handler) UpdateImageVisibility(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	imageID := c.Param("id")
+
+	var req struct {
+		Visibility string `json:"visibility" binding:"required,oneof=public private"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(err, c)})
+		return
+	}
 
-	// Clean up files
-	if origPath != "" {
-		h.storage.Delete(origPath)
+	img, err := h.repo.GetImage(c.Request.Context(), tenantID, imageID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "image_not_found")})
+		return
 	}
-	if medPath != "" {
-		h.storage.Delete(medPath)
+	permission, ok := imageUpdatePermissions[img.ImageableType]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid_image_visibility")})
+		return
 	}
-	if smPath != "" {
-		h.storage.Delete(smPath)
+	if !h.requirePermission(c, permission) {
+		return
 	}
-	if thPath != "" {
-		h.storage.Delete(thPath)
+
+	if img.Visibility != req.Visibility {
+		if img.ProcessingStatus != "completed" && img.ProcessingStatus != "failed" {
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "image_already_processing")})
+			return
+		}
+		moved, err := h.moveImageFiles(c.Request.Context(), tenantID, img, req.Visibility)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_image_visibility")})
+			return
+		}
+		if !moved {
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "image_already_processing")})
+			return
+		}
+		if img, err = h.repo.GetImage(c.Request.Context(), tenantID, imageID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_image_visibility")})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "image_deleted")})
+	h.signImageURLs(c.Request.Context(), img)
+	c.JSON(http.StatusOK, img)
+}
+
+// moveImageFiles copies the original and variants of img to where images of
+// the given visibility are stored, points the image at the copies and
+// deletes the old files unless other images of the same content still use
+// them. It reports false, leaving the image as it was, when the image was
+// queued for processing in the meantime.
+func (h *Handler) moveImageFiles(ctx context.Context, tenantID string, img *repo.ImageRow, visibility string) (bool, error) {
+	// The copies get a name of their own, so they never overwrite files
+	// another image uses. Variants are named after the original.
+	dir := path.Dir(img.OriginalPath)
+	if visibility == imageVisibilityPrivate {
+		dir = storage.PrivatePrefix + dir
+	} else {
+		dir = strings.TrimPrefix(dir, storage.PrivatePrefix)
+	}
+	stem := strings.TrimSuffix(path.Base(img.OriginalPath), path.Ext(img.OriginalPath))
+	newStem := uuid.New().String()
+	target := func(p string) string {
+		return path.Join(dir, newStem+strings.TrimPrefix(path.Base(p), stem))
+	}
+	deleteFiles := func(paths []string) {
+		for _, p := range paths {
+			if err := h.storage.Delete(p); err != nil {
+				log.Printf("Warning: failed to delete %s: %v", p, err)
+			}
+		}
+	}
+
+	var copied, stale []string
+	originalPath := target(img.OriginalPath)
+	originalURL, err := storage.Copy(ctx, h.storage, img.OriginalPath, originalPath, mime.TypeByExtension(path.Ext(img.OriginalPath)))
+	if err != nil {
+		return false, err
+	}
+	copied = append(copied, originalPath)
+	stale = append(stale, img.OriginalPath)
+
+	variants := make(map[string]imagevariant.Variant, len(img.Variants))
+	for name, v := range img.Variants {
+		if v.Path != "" {
+			to := target(v.Path)
+			url, err := storage.Copy(ctx, h.storage, v.Path, to, mime.TypeByExtension(path.Ext(v.Path)))
+			if err != nil {
+				deleteFiles(copied)
+				return false, err
+			}
+			copied = append(copied, to)
+			stale = append(stale, v.Path)
+			v.Path, v.URL = to, url
+		}
+		variants[name] = v
+	}
+
+	moved, staleUsed, err := h.repo.MoveImageFiles(ctx, tenantID, img.ID, visibility, img.OriginalPath, originalPath, originalURL, variants)
+	if err != nil || !moved {
+		deleteFiles(copied)
+		return false, err
+	}
+	if !staleUsed {
+		deleteFiles(stale)
+	}
+	return true, nil
+}
+
+// ReprocessImage godoc
+// @Summary Reprocessar imagem
+// @Description Volta a imagem para 'pending' e a enfileira novamente para o worker, com novas tentativas. Serve para imagens com falha ou para gerar de novo as variantes de uma imagem concluída.
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID da imagem"
+// @Success 202 {object} swagger.MessageResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Failure 409 {object} swagger.ErrorResponse
+// @Router /{url_code}/images/{id}/reprocess [post]
+func (h *Handler) ReprocessImage(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	imageID := c.Param("id")
+
+	if err := h.service.ReprocessImage(c.Request.Context(), tenantID, imageID); err != nil {
+		switch {
+		case errors.Is(err, imagequeue.ErrImageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "image_not_found")})
+		case errors.Is(err, imagequeue.ErrAlreadyQueued):
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.T(c, "image_already_processing")})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_reprocess_images")})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": i18n.T(c, "image_reprocess_queued")})
+}
+
+// ReprocessFailedImages godoc
+// @Summary Reprocessar imagens com falha
+// @Description Enfileira novamente todas as imagens do tenant cujo processamento falhou. Requer permissão 'setg_m' ou ser owner.
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Success 202 {object} swagger.ImageReprocessResponse
+// @Failure 403 {object} swagger.ErrorResponse
+// @Failure 500 {object} swagger.ErrorResponse
+// @Router /{url_code}/images/reprocess-failed [post]
+func (h *Handler) ReprocessFailedImages(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+
+	if !h.service.HasPermission(c.Request.Context(), userID, tenantID, "setg_m") &&
+		!h.service.IsOwner(c.Request.Context(), userID, tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "insufficient_permissions")})
+		return
+	}
+
+	queued, err := h.service.ReprocessFailedImages(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_reprocess_images")})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": i18n.T(c, "image_reprocess_queued"), "queued": queued})
 }
 
 // ==================== APP USERS (managed from backoffice) ====================
@@ -1987,9 +4008,32 @@ func (h *Handler) UpdateAppUserStatus(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed_update_status")})
 		return
 	}
+	if user, err := h.repo.GetAppUser(c.Request.Context(), tenantID, appUserID); err == nil {
+		h.service.EmitWebhook(c.Request.Context(), tenantID, webhooks.EventAppUserStatusChange, user)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "status_updated")})
 }
 
+// UnlockAppUser godoc
+// @Summary Desbloquear login do app user
+// @Description Remove o bloqueio temporário de login de um app user causado por tentativas de senha malsucedidas
+// @Tags App Users
+// @Produce json
+// @Security BearerAuth
+// @Param url_code path string true "URL code do tenant"
+// @Param id path string true "ID do app user"
+// @Success 200 {object} swagger.MessageResponse
+// @Failure 404 {object} swagger.ErrorResponse
+// @Router /{url_code}/app-users/{id}/unlock [post]
+func (h *Handler) UnlockAppUser(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	if err := h.service.UnlockAppUser(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "account_unlocked")})
+}
+
 // DeleteAppUser godoc
 // @Summary Remover app user
 // @Description Remove (soft delete) um app user
@@ -2053,11 +4097,7 @@ func (h *Handler) UpdateLanguage(c *gin.Context) {
 	}
 
 	// Invalidate tenant cache so language takes effect
-	urlCode := c.Param("url_code")
-	if urlCode != "" {
-		cacheKey := fmt.Sprintf("tenant:urlcode:%s", urlCode)
-		h.cache.DelCache(c.Request.Context(), cacheKey)
-	}
+	h.service.InvalidateTenantContext(c.Request.Context(), tenantID)
 
 	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, "language_updated")})
 }
@@ -7,27 +7,46 @@ var messages = map[string]map[string]string{
 	// ═══════════════════════════════════════════════════
 	LangPtBR: {
 		// --- Auth / Credentials ---
-		"invalid_credentials":            "Credenciais inválidas",
-		"account_not_active":             "Conta não está ativa",
-		"account_suspended":              "Conta suspensa",
-		"authorization_required":         "Autorização necessária",
-		"token_invalidated":              "Token invalidado",
-		"invalid_token":                  "Token inválido",
-		"token_required":                 "Token é obrigatório",
-		"email_already_in_use":           "E-mail já está em uso",
-		"email_already_registered":       "E-mail já cadastrado",
-		"email_already_verified":         "E-mail já verificado",
-		"invalid_verification_token":     "Token de verificação inválido ou expirado",
-		"email_verified":                 "E-mail verificado",
-		"verification_email_sent":        "E-mail de verificação enviado",
-		"logged_out":                     "Desconectado",
-		"user_not_found":                 "Usuário não encontrado",
-		"password_changed":               "Senha alterada",
-		"current_password_incorrect":     "Senha atual incorreta",
-		"failed_change_password":         "Falha ao alterar senha",
-		"password_reset_sent":            "Se o e-mail existir, um link de redefinição foi enviado",
-		"password_reset_success":         "Senha redefinida com sucesso",
-		"password_reset_not_implemented": "Redefinição de senha via token ainda não implementada",
+		"invalid_credentials":         "Credenciais inválidas",
+		"account_not_active":          "Conta não está ativa",
+		"account_suspended":           "Conta suspensa",
+		"authorization_required":      "Autorização necessária",
+		"token_invalidated":           "Token invalidado",
+		"invalid_token":               "Token inválido",
+		"token_required":              "Token é obrigatório",
+		"email_already_in_use":        "E-mail já está em uso",
+		"email_already_registered":    "E-mail já cadastrado",
+		"email_already_verified":      "E-mail já verificado",
+		"invalid_verification_token":  "Token de verificação inválido ou expirado",
+		"email_verified":              "E-mail verificado",
+		"verification_email_sent":     "E-mail de verificação enviado",
+		"logged_out":                  "Desconectado",
+		"user_not_found":              "Usuário não encontrado",
+		"password_changed":            "Senha alterada",
+		"current_password_incorrect":  "Senha atual incorreta",
+		"failed_change_password":      "Falha ao alterar senha",
+		"password_reset_sent":         "Se o e-mail existir, um link de redefinição foi enviado",
+		"password_reset_success":      "Senha redefinida com sucesso",
+		"invalid_reset_token":         "Token de redefinição inválido ou expirado",
+		"failed_password_reset":       "Falha ao solicitar redefinição de senha",
+		"too_many_reset_requests":     "Muitas solicitações de redefinição. Tente novamente mais tarde",
+		"invalid_refresh_token":       "Refresh token inválido ou expirado",
+		"refresh_token_reused":        "Refresh token já utilizado. Todas as sessões relacionadas foram encerradas",
+		"session_not_found":           "Sessão não encontrada",
+		"session_revoked":             "Sessão encerrada",
+		"sessions_revoked":            "Outras sessões encerradas",
+		"failed_list_sessions":        "Falha ao listar sessões",
+		"failed_revoke_session":       "Falha ao encerrar sessão",
+		"invalid_mfa_token":           "Desafio de autenticação inválido ou expirado",
+		"invalid_mfa_code":            "Código de verificação inválido",
+		"two_factor_already_enabled":  "A autenticação em dois fatores já está ativa",
+		"two_factor_not_enabled":      "A autenticação em dois fatores não está ativa",
+		"two_factor_setup_required":   "Inicie a configuração da autenticação em dois fatores primeiro",
+		"two_factor_required_by_role": "Sua função exige autenticação em dois fatores",
+		"two_factor_disabled":         "Autenticação em dois fatores desativada",
+		"too_many_login_attempts":     "Muitas tentativas de login. Tente novamente mais tarde",
+		"account_temporarily_locked":  "Conta bloqueada temporariamente após várias tentativas de login malsucedidas. Tente novamente mais tarde",
+		"account_unlocked":            "Conta desbloqueada",
 
 		// --- Tenant ---
 		"tenant_not_found":      "Tenant não encontrado",
@@ -64,16 +83,48 @@ var messages = map[string]map[string]string{
 		"failed_upload":    "Falha no upload",
 
 		// --- Members ---
-		"failed_list_members":      "Falha ao listar membros",
-		"member_not_found":         "Membro não encontrado",
-		"member_added":             "Membro adicionado",
-		"member_role_updated":      "Função do membro atualizada",
-		"failed_update_role":       "Falha ao atualizar função",
-		"only_owner_remove":        "Apenas o proprietário pode remover membros",
-		"cannot_remove_self":       "Não é possível remover a si mesmo",
-		"member_removed":           "Membro removido",
-		"failed_remove_member":     "Falha ao remover membro",
-		"insufficient_permissions": "Permissões insuficientes",
+		"failed_list_members":           "Falha ao listar membros",
+		"member_not_found":              "Membro não encontrado",
+		"member_added":                  "Membro adicionado",
+		"invitation_sent":               "Convite enviado",
+		"invitation_revoked":            "Convite revogado",
+		"invitation_accepted":           "Convite aceito",
+		"invitation_not_found":          "Convite não encontrado",
+		"invitation_already_pending":    "Já existe um convite pendente para este email",
+		"invalid_invitation":            "Convite inválido ou expirado",
+		"password_required":             "Informe uma senha para criar sua conta",
+		"failed_list_invitations":       "Falha ao listar convites",
+		"only_owner_transfer":           "Apenas o proprietário pode transferir a propriedade",
+		"cannot_transfer_to_self":       "Você já é o proprietário",
+		"transfer_target_inactive":      "O membro precisa estar ativo para receber a propriedade",
+		"ownership_transfer_sent":       "Transferência de propriedade enviada",
+		"ownership_transfer_cancelled":  "Transferência de propriedade cancelada",
+		"ownership_transfer_not_found":  "Nenhuma transferência de propriedade pendente",
+		"invalid_ownership_transfer":    "Transferência de propriedade inválida ou expirada",
+		"ownership_transfer_other_user": "Esta transferência foi enviada para outro usuário",
+		"ownership_transfer_confirmed":  "Você agora é o proprietário",
+		"already_tenant_owner":          "O membro já é o proprietário",
+		"ownership_transferred":         "Propriedade transferida",
+		"failed_transfer_ownership":     "Falha ao transferir propriedade",
+		"only_owner_manage_domains":     "Apenas o proprietário pode gerenciar domínios",
+		"invalid_domain":                "Domínio inválido",
+		"domain_reserved":               "Este domínio é reservado",
+		"domain_limit_reached":          "Limite de domínios atingido",
+		"domain_in_use":                 "Este domínio já está em uso por outro tenant",
+		"domain_already_added":          "Este domínio já foi adicionado",
+		"domain_not_found":              "Domínio não encontrado",
+		"domain_lookup_failed":          "Não foi possível consultar o DNS do domínio",
+		"domain_verified":               "Domínio verificado",
+		"domain_verification_pending":   "Registro TXT não encontrado; a propagação do DNS pode levar algum tempo",
+		"domain_removed":                "Domínio removido",
+		"failed_list_domains":           "Falha ao listar domínios",
+		"member_role_updated":           "Função do membro atualizada",
+		"failed_update_role":            "Falha ao atualizar função",
+		"only_owner_remove":             "Apenas o proprietário pode remover membros",
+		"cannot_remove_self":            "Não é possível remover a si mesmo",
+		"member_removed":                "Membro removido",
+		"failed_remove_member":          "Falha ao remover membro",
+		"insufficient_permissions":      "Permissões insuficientes",
 
 		// --- Roles ---
 		"failed_list_roles":       "Falha ao listar funções",
@@ -190,6 +241,61 @@ var messages = map[string]map[string]string{
 		"failed_update_promotion":     "Falha ao atualizar promoção",
 		"failed_deactivate_promotion": "Falha ao desativar promoção",
 
+		// --- Webhooks ---
+		"only_owner_manage_webhooks":     "Apenas o owner pode gerenciar webhooks",
+		"failed_list_webhooks":           "Falha ao listar webhooks",
+		"invalid_webhook_url":            "URL do webhook inválida. Use uma URL http(s) absoluta",
+		"invalid_webhook_events":         "Informe ao menos um evento válido",
+		"webhook_url_not_allowed":        "O webhook não pode apontar para um endereço local, privado ou reservado",
+		"webhook_limit_reached":          "Limite de webhooks atingido",
+		"webhook_not_found":              "Webhook não encontrado",
+		"webhook_updated":                "Webhook atualizado",
+		"webhook_deleted":                "Webhook removido",
+		"failed_list_webhook_deliveries": "Falha ao listar entregas do webhook",
+		"webhook_delivery_not_found":     "Entrega não encontrada",
+		"webhook_redelivery_queued":      "Entrega colocada na fila para reenvio",
+
+		// --- Image variants ---
+		"failed_load_image_variants":    "Falha ao carregar variantes de imagem",
+		"image_variant_limit_reached":   "Seu plano não permite mais variantes de imagem",
+		"invalid_image_variant_name":    "Nome de variante inválido (use letras minúsculas, números e _ , até 30 caracteres)",
+		"duplicate_image_variant_name":  "Nome de variante duplicado",
+		"invalid_image_variant_size":    "Tamanho de variante inválido ou acima do permitido pelo plano",
+		"invalid_image_variant_mode":    "Modo de variante inválido (use fit, fill ou crop)",
+		"invalid_image_variant_quality": "Qualidade de variante deve estar entre 1 e 100",
+		"image_variants_saved":          "Variantes de imagem salvas",
+		"invalid_image_order":           "A lista deve conter cada imagem exatamente uma vez",
+		"failed_reorder_images":         "Falha ao reordenar imagens",
+		"image_order_updated":           "Ordem das imagens atualizada",
+		"image_already_processing":      "A imagem já está na fila ou sendo processada",
+		"image_reprocess_queued":        "Processamento enfileirado",
+		"failed_reprocess_images":       "Falha ao enfileirar imagens para processamento",
+		"image_rerender_queued":         "Imagens enfileiradas para re-renderização",
+		"failed_rerender_images":        "Falha ao enfileirar re-renderização das imagens",
+
+		// --- Uploads ---
+		"file_too_large":             "O arquivo excede o tamanho máximo permitido pelo plano",
+		"unsupported_file_type":      "Tipo de arquivo não suportado. Envie JPEG, PNG, GIF ou WebP",
+		"invalid_image":              "O arquivo não é uma imagem válida",
+		"image_dimensions_too_large": "As dimensões da imagem excedem o máximo permitido pelo plano",
+		"too_many_files":             "Muitos arquivos em um único envio",
+
+		// --- Direct uploads ---
+		"failed_create_upload":     "Erro ao iniciar o upload",
+		"upload_not_found":         "O arquivo ainda não foi enviado",
+		"image_already_uploaded":   "O upload desta imagem já foi concluído",
+		"failed_complete_upload":   "Erro ao concluir o upload",
+		"image_upload_completed":   "Upload concluído",
+		"invalid_upload_signature": "URL de upload inválida ou expirada",
+
+		// --- Private media ---
+		"invalid_image_visibility":       "Visibilidade inválida; use public ou private",
+		"failed_update_image_visibility": "Falha ao alterar a visibilidade da imagem",
+		"file_not_found":                 "Arquivo não encontrado",
+		"failed_sign_url":                "Falha ao gerar o link de download",
+		"invalid_last_event_id":          "Last-Event-ID inválido",
+		"failed_load_image_events":       "Falha ao carregar os eventos de imagens",
+
 		// --- Validation templates ---
 		"validation.required": "%s é obrigatório",
 		"validation.email":    "%s deve ser um e-mail válido",
@@ -209,27 +315,46 @@ var messages = map[string]map[string]string{
 	// ═══════════════════════════════════════════════════
 	LangPt: {
 		// --- Auth / Credentials ---
-		"invalid_credentials":            "Credenciais inválidas",
-		"account_not_active":             "Conta não está ativa",
-		"account_suspended":              "Conta suspensa",
-		"authorization_required":         "Autorização necessária",
-		"token_invalidated":              "Token invalidado",
-		"invalid_token":                  "Token inválido",
-		"token_required":                 "Token é obrigatório",
-		"email_already_in_use":           "E-mail já está em uso",
-		"email_already_registered":       "E-mail já registado",
-		"email_already_verified":         "E-mail já verificado",
-		"invalid_verification_token":     "Token de verificação inválido ou expirado",
-		"email_verified":                 "E-mail verificado",
-		"verification_email_sent":        "E-mail de verificação enviado",
-		"logged_out":                     "Sessão terminada",
-		"user_not_found":                 "Utilizador não encontrado",
-		"password_changed":               "Palavra-passe alterada",
-		"current_password_incorrect":     "Palavra-passe atual incorreta",
-		"failed_change_password":         "Falha ao alterar palavra-passe",
-		"password_reset_sent":            "Se o e-mail existir, um link de redefinição foi enviado",
-		"password_reset_success":         "Palavra-passe redefinida com sucesso",
-		"password_reset_not_implemented": "Redefinição de palavra-passe via token ainda não implementada",
+		"invalid_credentials":         "Credenciais inválidas",
+		"account_not_active":          "Conta não está ativa",
+		"account_suspended":           "Conta suspensa",
+		"authorization_required":      "Autorização necessária",
+		"token_invalidated":           "Token invalidado",
+		"invalid_token":               "Token inválido",
+		"token_required":              "Token é obrigatório",
+		"email_already_in_use":        "E-mail já está em uso",
+		"email_already_registered":    "E-mail já registado",
+		"email_already_verified":      "E-mail já verificado",
+		"invalid_verification_token":  "Token de verificação inválido ou expirado",
+		"email_verified":              "E-mail verificado",
+		"verification_email_sent":     "E-mail de verificação enviado",
+		"logged_out":                  "Sessão terminada",
+		"user_not_found":              "Utilizador não encontrado",
+		"password_changed":            "Palavra-passe alterada",
+		"current_password_incorrect":  "Palavra-passe atual incorreta",
+		"failed_change_password":      "Falha ao alterar palavra-passe",
+		"password_reset_sent":         "Se o e-mail existir, um link de redefinição foi enviado",
+		"password_reset_success":      "Palavra-passe redefinida com sucesso",
+		"invalid_reset_token":         "Token de redefinição inválido ou expirado",
+		"failed_password_reset":       "Falha ao pedir a redefinição de palavra-passe",
+		"too_many_reset_requests":     "Demasiados pedidos de redefinição. Tente novamente mais tarde",
+		"invalid_refresh_token":       "Refresh token inválido ou expirado",
+		"refresh_token_reused":        "Refresh token já utilizado. Todas as sessões relacionadas foram terminadas",
+		"session_not_found":           "Sessão não encontrada",
+		"session_revoked":             "Sessão terminada",
+		"sessions_revoked":            "Outras sessões terminadas",
+		"failed_list_sessions":        "Falha ao listar sessões",
+		"failed_revoke_session":       "Falha ao terminar sessão",
+		"invalid_mfa_token":           "Desafio de autenticação inválido ou expirado",
+		"invalid_mfa_code":            "Código de verificação inválido",
+		"two_factor_already_enabled":  "A autenticação em dois fatores já está ativa",
+		"two_factor_not_enabled":      "A autenticação em dois fatores não está ativa",
+		"two_factor_setup_required":   "Inicie primeiro a configuração da autenticação em dois fatores",
+		"two_factor_required_by_role": "A sua função exige autenticação em dois fatores",
+		"two_factor_disabled":         "Autenticação em dois fatores desativada",
+		"too_many_login_attempts":     "Demasiadas tentativas de início de sessão. Tente novamente mais tarde",
+		"account_temporarily_locked":  "Conta bloqueada temporariamente após várias tentativas de início de sessão falhadas. Tente novamente mais tarde",
+		"account_unlocked":            "Conta desbloqueada",
 
 		// --- Tenant ---
 		"tenant_not_found":      "Tenant não encontrado",
@@ -266,16 +391,48 @@ var messages = map[string]map[string]string{
 		"failed_upload":    "Falha no envio",
 
 		// --- Members ---
-		"failed_list_members":      "Falha ao listar membros",
-		"member_not_found":         "Membro não encontrado",
-		"member_added":             "Membro adicionado",
-		"member_role_updated":      "Função do membro atualizada",
-		"failed_update_role":       "Falha ao atualizar função",
-		"only_owner_remove":        "Apenas o proprietário pode remover membros",
-		"cannot_remove_self":       "Não é possível remover-se a si próprio",
-		"member_removed":           "Membro removido",
-		"failed_remove_member":     "Falha ao remover membro",
-		"insufficient_permissions": "Permissões insuficientes",
+		"failed_list_members":           "Falha ao listar membros",
+		"member_not_found":              "Membro não encontrado",
+		"member_added":                  "Membro adicionado",
+		"invitation_sent":               "Convite enviado",
+		"invitation_revoked":            "Convite revogado",
+		"invitation_accepted":           "Convite aceite",
+		"invitation_not_found":          "Convite não encontrado",
+		"invitation_already_pending":    "Já existe um convite pendente para este email",
+		"invalid_invitation":            "Convite inválido ou expirado",
+		"password_required":             "Indique uma palavra-passe para criar a sua conta",
+		"failed_list_invitations":       "Falha ao listar convites",
+		"only_owner_transfer":           "Apenas o proprietário pode transferir a propriedade",
+		"cannot_transfer_to_self":       "Já é o proprietário",
+		"transfer_target_inactive":      "O membro precisa de estar ativo para receber a propriedade",
+		"ownership_transfer_sent":       "Transferência de propriedade enviada",
+		"ownership_transfer_cancelled":  "Transferência de propriedade cancelada",
+		"ownership_transfer_not_found":  "Nenhuma transferência de propriedade pendente",
+		"invalid_ownership_transfer":    "Transferência de propriedade inválida ou expirada",
+		"ownership_transfer_other_user": "Esta transferência foi enviada para outro utilizador",
+		"ownership_transfer_confirmed":  "Agora é o proprietário",
+		"already_tenant_owner":          "O membro já é o proprietário",
+		"ownership_transferred":         "Propriedade transferida",
+		"failed_transfer_ownership":     "Falha ao transferir propriedade",
+		"only_owner_manage_domains":     "Apenas o proprietário pode gerir domínios",
+		"invalid_domain":                "Domínio inválido",
+		"domain_reserved":               "Este domínio é reservado",
+		"domain_limit_reached":          "Limite de domínios atingido",
+		"domain_in_use":                 "Este domínio já está a ser usado por outro tenant",
+		"domain_already_added":          "Este domínio já foi adicionado",
+		"domain_not_found":              "Domínio não encontrado",
+		"domain_lookup_failed":          "Não foi possível consultar o DNS do domínio",
+		"domain_verified":               "Domínio verificado",
+		"domain_verification_pending":   "Registo TXT não encontrado; a propagação do DNS pode demorar algum tempo",
+		"domain_removed":                "Domínio removido",
+		"failed_list_domains":           "Falha ao listar domínios",
+		"member_role_updated":           "Função do membro atualizada",
+		"failed_update_role":            "Falha ao atualizar função",
+		"only_owner_remove":             "Apenas o proprietário pode remover membros",
+		"cannot_remove_self":            "Não é possível remover-se a si próprio",
+		"member_removed":                "Membro removido",
+		"failed_remove_member":          "Falha ao remover membro",
+		"insufficient_permissions":      "Permissões insuficientes",
 
 		// --- Roles ---
 		"failed_list_roles":       "Falha ao listar funções",
@@ -392,6 +549,61 @@ var messages = map[string]map[string]string{
 		"failed_update_promotion":     "Falha ao atualizar promoção",
 		"failed_deactivate_promotion": "Falha ao desativar promoção",
 
+		// --- Webhooks ---
+		"only_owner_manage_webhooks":     "Apenas o proprietário pode gerir webhooks",
+		"failed_list_webhooks":           "Falha ao listar webhooks",
+		"invalid_webhook_url":            "URL do webhook inválido. Utilize um URL http(s) absoluto",
+		"invalid_webhook_events":         "Indique pelo menos um evento válido",
+		"webhook_url_not_allowed":        "O webhook não pode apontar para um endereço local, privado ou reservado",
+		"webhook_limit_reached":          "Limite de webhooks atingido",
+		"webhook_not_found":              "Webhook não encontrado",
+		"webhook_updated":                "Webhook atualizado",
+		"webhook_deleted":                "Webhook removido",
+		"failed_list_webhook_deliveries": "Falha ao listar entregas do webhook",
+		"webhook_delivery_not_found":     "Entrega não encontrada",
+		"webhook_redelivery_queued":      "Entrega colocada na fila para reenvio",
+
+		// --- Image variants ---
+		"failed_load_image_variants":    "Falha ao carregar variantes de imagem",
+		"image_variant_limit_reached":   "O seu plano não permite mais variantes de imagem",
+		"invalid_image_variant_name":    "Nome de variante inválido (use letras minúsculas, números e _ , até 30 caracteres)",
+		"duplicate_image_variant_name":  "Nome de variante duplicado",
+		"invalid_image_variant_size":    "Tamanho de variante inválido ou acima do permitido pelo plano",
+		"invalid_image_variant_mode":    "Modo de variante inválido (use fit, fill ou crop)",
+		"invalid_image_variant_quality": "A qualidade da variante deve estar entre 1 e 100",
+		"image_variants_saved":          "Variantes de imagem guardadas",
+		"invalid_image_order":           "A lista deve conter cada imagem exatamente uma vez",
+		"failed_reorder_images":         "Falha ao reordenar imagens",
+		"image_order_updated":           "Ordem das imagens atualizada",
+		"image_already_processing":      "A imagem já está na fila ou a ser processada",
+		"image_reprocess_queued":        "Processamento colocado na fila",
+		"failed_reprocess_images":       "Falha ao colocar imagens na fila de processamento",
+		"image_rerender_queued":         "Imagens colocadas na fila para re-renderização",
+		"failed_rerender_images":        "Falha ao colocar as imagens na fila de re-renderização",
+
+		// --- Uploads ---
+		"file_too_large":             "O ficheiro excede o tamanho máximo permitido pelo plano",
+		"unsupported_file_type":      "Tipo de ficheiro não suportado. Envie JPEG, PNG, GIF ou WebP",
+		"invalid_image":              "O ficheiro não é uma imagem válida",
+		"image_dimensions_too_large": "As dimensões da imagem excedem o máximo permitido pelo plano",
+		"too_many_files":             "Demasiados ficheiros num único envio",
+
+		// --- Direct uploads ---
+		"failed_create_upload":     "Erro ao iniciar o envio",
+		"upload_not_found":         "O ficheiro ainda não foi enviado",
+		"image_already_uploaded":   "O envio desta imagem já foi concluído",
+		"failed_complete_upload":   "Erro ao concluir o envio",
+		"image_upload_completed":   "Envio concluído",
+		"invalid_upload_signature": "URL de envio inválida ou expirada",
+
+		// --- Private media ---
+		"invalid_image_visibility":       "Visibilidade inválida; utilize public ou private",
+		"failed_update_image_visibility": "Falha ao alterar a visibilidade da imagem",
+		"file_not_found":                 "Ficheiro não encontrado",
+		"failed_sign_url":                "Falha ao gerar a ligação de transferência",
+		"invalid_last_event_id":          "Last-Event-ID inválido",
+		"failed_load_image_events":       "Falha ao carregar os eventos de imagens",
+
 		// --- Validation templates ---
 		"validation.required": "%s é obrigatório",
 		"validation.email":    "%s deve ser um e-mail válido",
@@ -411,27 +623,46 @@ var messages = map[string]map[string]string{
 	// ═══════════════════════════════════════════════════
 	LangEn: {
 		// --- Auth / Credentials ---
-		"invalid_credentials":            "Invalid credentials",
-		"account_not_active":             "Account is not active",
-		"account_suspended":              "Account suspended",
-		"authorization_required":         "Authorization required",
-		"token_invalidated":              "Token invalidated",
-		"invalid_token":                  "Invalid token",
-		"token_required":                 "Token is required",
-		"email_already_in_use":           "Email already in use",
-		"email_already_registered":       "Email already registered",
-		"email_already_verified":         "Email already verified",
-		"invalid_verification_token":     "Invalid or expired verification token",
-		"email_verified":                 "Email verified",
-		"verification_email_sent":        "Verification email sent",
-		"logged_out":                     "Logged out",
-		"user_not_found":                 "User not found",
-		"password_changed":               "Password changed",
-		"current_password_incorrect":     "Current password is incorrect",
-		"failed_change_password":         "Failed to change password",
-		"password_reset_sent":            "If the email exists, a reset link was sent",
-		"password_reset_success":         "Password reset successfully",
-		"password_reset_not_implemented": "Password reset via token not yet implemented",
+		"invalid_credentials":         "Invalid credentials",
+		"account_not_active":          "Account is not active",
+		"account_suspended":           "Account suspended",
+		"authorization_required":      "Authorization required",
+		"token_invalidated":           "Token invalidated",
+		"invalid_token":               "Invalid token",
+		"token_required":              "Token is required",
+		"email_already_in_use":        "Email already in use",
+		"email_already_registered":    "Email already registered",
+		"email_already_verified":      "Email already verified",
+		"invalid_verification_token":  "Invalid or expired verification token",
+		"email_verified":              "Email verified",
+		"verification_email_sent":     "Verification email sent",
+		"logged_out":                  "Logged out",
+		"user_not_found":              "User not found",
+		"password_changed":            "Password changed",
+		"current_password_incorrect":  "Current password is incorrect",
+		"failed_change_password":      "Failed to change password",
+		"password_reset_sent":         "If the email exists, a reset link was sent",
+		"password_reset_success":      "Password reset successfully",
+		"invalid_reset_token":         "Invalid or expired reset token",
+		"failed_password_reset":       "Failed to request password reset",
+		"too_many_reset_requests":     "Too many reset requests. Try again later",
+		"invalid_refresh_token":       "Invalid or expired refresh token",
+		"refresh_token_reused":        "Refresh token already used. All related sessions were revoked",
+		"session_not_found":           "Session not found",
+		"session_revoked":             "Session revoked",
+		"sessions_revoked":            "Other sessions revoked",
+		"failed_list_sessions":        "Failed to list sessions",
+		"failed_revoke_session":       "Failed to revoke session",
+		"invalid_mfa_token":           "Invalid or expired authentication challenge",
+		"invalid_mfa_code":            "Invalid verification code",
+		"two_factor_already_enabled":  "Two-factor authentication is already enabled",
+		"two_factor_not_enabled":      "Two-factor authentication is not enabled",
+		"two_factor_setup_required":   "Start two-factor setup first",
+		"two_factor_required_by_role": "Your role requires two-factor authentication",
+		"two_factor_disabled":         "Two-factor authentication disabled",
+		"too_many_login_attempts":     "Too many login attempts. Try again later",
+		"account_temporarily_locked":  "Account temporarily locked after too many failed login attempts. Try again later",
+		"account_unlocked":            "Account unlocked",
 
 		// --- Tenant ---
 		"tenant_not_found":      "Tenant not found",
@@ -468,16 +699,48 @@ var messages = map[string]map[string]string{
 		"failed_upload":    "Failed to upload",
 
 		// --- Members ---
-		"failed_list_members":      "Failed to list members",
-		"member_not_found":         "Member not found",
-		"member_added":             "Member added",
-		"member_role_updated":      "Member role updated",
-		"failed_update_role":       "Failed to update role",
-		"only_owner_remove":        "Only owner can remove members",
-		"cannot_remove_self":       "Cannot remove yourself",
-		"member_removed":           "Member removed",
-		"failed_remove_member":     "Failed to remove member",
-		"insufficient_permissions": "Insufficient permissions",
+		"failed_list_members":           "Failed to list members",
+		"member_not_found":              "Member not found",
+		"member_added":                  "Member added",
+		"invitation_sent":               "Invitation sent",
+		"invitation_revoked":            "Invitation revoked",
+		"invitation_accepted":           "Invitation accepted",
+		"invitation_not_found":          "Invitation not found",
+		"invitation_already_pending":    "There is already a pending invitation for this email",
+		"invalid_invitation":            "Invalid or expired invitation",
+		"password_required":             "A password is required to create your account",
+		"failed_list_invitations":       "Failed to list invitations",
+		"only_owner_transfer":           "Only the owner can transfer ownership",
+		"cannot_transfer_to_self":       "You are already the owner",
+		"transfer_target_inactive":      "The member must be active to receive ownership",
+		"ownership_transfer_sent":       "Ownership transfer sent",
+		"ownership_transfer_cancelled":  "Ownership transfer cancelled",
+		"ownership_transfer_not_found":  "No pending ownership transfer",
+		"invalid_ownership_transfer":    "Invalid or expired ownership transfer",
+		"ownership_transfer_other_user": "This transfer was sent to another user",
+		"ownership_transfer_confirmed":  "You are now the owner",
+		"already_tenant_owner":          "The member is already the owner",
+		"ownership_transferred":         "Ownership transferred",
+		"failed_transfer_ownership":     "Failed to transfer ownership",
+		"only_owner_manage_domains":     "Only the owner can manage domains",
+		"invalid_domain":                "Invalid domain",
+		"domain_reserved":               "This domain is reserved",
+		"domain_limit_reached":          "Domain limit reached",
+		"domain_in_use":                 "This domain is already used by another tenant",
+		"domain_already_added":          "This domain has already been added",
+		"domain_not_found":              "Domain not found",
+		"domain_lookup_failed":          "Could not look up the domain's DNS",
+		"domain_verified":               "Domain verified",
+		"domain_verification_pending":   "TXT record not found; DNS propagation may take a while",
+		"domain_removed":                "Domain removed",
+		"failed_list_domains":           "Failed to list domains",
+		"member_role_updated":           "Member role updated",
+		"failed_update_role":            "Failed to update role",
+		"only_owner_remove":             "Only owner can remove members",
+		"cannot_remove_self":            "Cannot remove yourself",
+		"member_removed":                "Member removed",
+		"failed_remove_member":          "Failed to remove member",
+		"insufficient_permissions":      "Insufficient permissions",
 
 		// --- Roles ---
 		"failed_list_roles":       "Failed to list roles",
@@ -594,6 +857,61 @@ var messages = map[string]map[string]string{
 		"failed_update_promotion":     "Failed to update promotion",
 		"failed_deactivate_promotion": "Failed to deactivate promotion",
 
+		// --- Webhooks ---
+		"only_owner_manage_webhooks":     "Only the owner can manage webhooks",
+		"failed_list_webhooks":           "Failed to list webhooks",
+		"invalid_webhook_url":            "Invalid webhook URL. Use an absolute http(s) URL",
+		"invalid_webhook_events":         "Provide at least one valid event",
+		"webhook_url_not_allowed":        "Webhooks cannot point to a local, private or reserved address",
+		"webhook_limit_reached":          "Webhook limit reached",
+		"webhook_not_found":              "Webhook not found",
+		"webhook_updated":                "Webhook updated",
+		"webhook_deleted":                "Webhook deleted",
+		"failed_list_webhook_deliveries": "Failed to list webhook deliveries",
+		"webhook_delivery_not_found":     "Delivery not found",
+		"webhook_redelivery_queued":      "Delivery queued for redelivery",
+
+		// --- Image variants ---
+		"failed_load_image_variants":    "Failed to load image variants",
+		"image_variant_limit_reached":   "Your plan does not allow more image variants",
+		"invalid_image_variant_name":    "Invalid variant name (use lowercase letters, digits and _, up to 30 characters)",
+		"duplicate_image_variant_name":  "Duplicate variant name",
+		"invalid_image_variant_size":    "Variant size is invalid or above what your plan allows",
+		"invalid_image_variant_mode":    "Invalid variant mode (use fit, fill or crop)",
+		"invalid_image_variant_quality": "Variant quality must be between 1 and 100",
+		"image_variants_saved":          "Image variants saved",
+		"invalid_image_order":           "The list must contain each image exactly once",
+		"failed_reorder_images":         "Failed to reorder images",
+		"image_order_updated":           "Image order updated",
+		"image_already_processing":      "The image is already queued or being processed",
+		"image_reprocess_queued":        "Processing queued",
+		"failed_reprocess_images":       "Failed to queue images for processing",
+		"image_rerender_queued":         "Images queued for re-rendering",
+		"failed_rerender_images":        "Failed to queue images for re-rendering",
+
+		// --- Uploads ---
+		"file_too_large":             "The file exceeds the maximum size allowed by the plan",
+		"unsupported_file_type":      "Unsupported file type. Upload a JPEG, PNG, GIF or WebP image",
+		"invalid_image":              "The file is not a valid image",
+		"image_dimensions_too_large": "The image dimensions exceed the maximum allowed by the plan",
+		"too_many_files":             "Too many files in a single upload",
+
+		// --- Direct uploads ---
+		"failed_create_upload":     "Failed to start the upload",
+		"upload_not_found":         "The file has not been uploaded yet",
+		"image_already_uploaded":   "This image's upload was already completed",
+		"failed_complete_upload":   "Failed to complete the upload",
+		"image_upload_completed":   "Upload completed",
+		"invalid_upload_signature": "Invalid or expired upload URL",
+
+		// --- Private media ---
+		"invalid_image_visibility":       "Invalid visibility; use public or private",
+		"failed_update_image_visibility": "Failed to change the image visibility",
+		"file_not_found":                 "File not found",
+		"failed_sign_url":                "Failed to create the download link",
+		"invalid_last_event_id":          "Invalid Last-Event-ID",
+		"failed_load_image_events":       "Failed to load image events",
+
 		// --- Validation templates ---
 		"validation.required": "%s is required",
 		"validation.email":    "%s must be a valid email address",
@@ -613,27 +931,46 @@ var messages = map[string]map[string]string{
 	// ═══════════════════════════════════════════════════
 	LangEs: {
 		// --- Auth / Credentials ---
-		"invalid_credentials":            "Credenciales inválidas",
-		"account_not_active":             "La cuenta no está activa",
-		"account_suspended":              "Cuenta suspendida",
-		"authorization_required":         "Autorización requerida",
-		"token_invalidated":              "Token invalidado",
-		"invalid_token":                  "Token inválido",
-		"token_required":                 "Token es obligatorio",
-		"email_already_in_use":           "El correo electrónico ya está en uso",
-		"email_already_registered":       "El correo electrónico ya está registrado",
-		"email_already_verified":         "El correo electrónico ya está verificado",
-		"invalid_verification_token":     "Token de verificación inválido o expirado",
-		"email_verified":                 "Correo electrónico verificado",
-		"verification_email_sent":        "Correo de verificación enviado",
-		"logged_out":                     "Sesión cerrada",
-		"user_not_found":                 "Usuario no encontrado",
-		"password_changed":               "Contraseña cambiada",
-		"current_password_incorrect":     "La contraseña actual es incorrecta",
-		"failed_change_password":         "Error al cambiar la contraseña",
-		"password_reset_sent":            "Si el correo existe, se envió un enlace de restablecimiento",
-		"password_reset_success":         "Contraseña restablecida con éxito",
-		"password_reset_not_implemented": "Restablecimiento de contraseña por token aún no implementado",
+		"invalid_credentials":         "Credenciales inválidas",
+		"account_not_active":          "La cuenta no está activa",
+		"account_suspended":           "Cuenta suspendida",
+		"authorization_required":      "Autorización requerida",
+		"token_invalidated":           "Token invalidado",
+		"invalid_token":               "Token inválido",
+		"token_required":              "Token es obligatorio",
+		"email_already_in_use":        "El correo electrónico ya está en uso",
+		"email_already_registered":    "El correo electrónico ya está registrado",
+		"email_already_verified":      "El correo electrónico ya está verificado",
+		"invalid_verification_token":  "Token de verificación inválido o expirado",
+		"email_verified":              "Correo electrónico verificado",
+		"verification_email_sent":     "Correo de verificación enviado",
+		"logged_out":                  "Sesión cerrada",
+		"user_not_found":              "Usuario no encontrado",
+		"password_changed":            "Contraseña cambiada",
+		"current_password_incorrect":  "La contraseña actual es incorrecta",
+		"failed_change_password":      "Error al cambiar la contraseña",
+		"password_reset_sent":         "Si el correo existe, se envió un enlace de restablecimiento",
+		"password_reset_success":      "Contraseña restablecida con éxito",
+		"invalid_reset_token":         "Token de restablecimiento inválido o expirado",
+		"failed_password_reset":       "Error al solicitar el restablecimiento de contraseña",
+		"too_many_reset_requests":     "Demasiadas solicitudes de restablecimiento. Inténtalo más tarde",
+		"invalid_refresh_token":       "Refresh token inválido o expirado",
+		"refresh_token_reused":        "Refresh token ya utilizado. Todas las sesiones relacionadas fueron revocadas",
+		"session_not_found":           "Sesión no encontrada",
+		"session_revoked":             "Sesión cerrada",
+		"sessions_revoked":            "Otras sesiones cerradas",
+		"failed_list_sessions":        "Error al listar las sesiones",
+		"failed_revoke_session":       "Error al cerrar la sesión",
+		"invalid_mfa_token":           "Desafío de autenticación inválido o expirado",
+		"invalid_mfa_code":            "Código de verificación inválido",
+		"two_factor_already_enabled":  "La autenticación en dos pasos ya está activada",
+		"two_factor_not_enabled":      "La autenticación en dos pasos no está activada",
+		"two_factor_setup_required":   "Inicie primero la configuración de la autenticación en dos pasos",
+		"two_factor_required_by_role": "Tu rol requiere autenticación en dos pasos",
+		"two_factor_disabled":         "Autenticación en dos pasos desactivada",
+		"too_many_login_attempts":     "Demasiados intentos de inicio de sesión. Inténtalo de nuevo más tarde",
+		"account_temporarily_locked":  "Cuenta bloqueada temporalmente tras varios intentos fallidos de inicio de sesión. Inténtalo de nuevo más tarde",
+		"account_unlocked":            "Cuenta desbloqueada",
 
 		// --- Tenant ---
 		"tenant_not_found":      "Tenant no encontrado",
@@ -670,16 +1007,48 @@ var messages = map[string]map[string]string{
 		"failed_upload":    "Error en la carga",
 
 		// --- Members ---
-		"failed_list_members":      "Error al listar miembros",
-		"member_not_found":         "Miembro no encontrado",
-		"member_added":             "Miembro agregado",
-		"member_role_updated":      "Rol del miembro actualizado",
-		"failed_update_role":       "Error al actualizar rol",
-		"only_owner_remove":        "Solo el propietario puede eliminar miembros",
-		"cannot_remove_self":       "No puede eliminarse a sí mismo",
-		"member_removed":           "Miembro eliminado",
-		"failed_remove_member":     "Error al eliminar miembro",
-		"insufficient_permissions": "Permisos insuficientes",
+		"failed_list_members":           "Error al listar miembros",
+		"member_not_found":              "Miembro no encontrado",
+		"member_added":                  "Miembro agregado",
+		"invitation_sent":               "Invitación enviada",
+		"invitation_revoked":            "Invitación revocada",
+		"invitation_accepted":           "Invitación aceptada",
+		"invitation_not_found":          "Invitación no encontrada",
+		"invitation_already_pending":    "Ya existe una invitación pendiente para este correo",
+		"invalid_invitation":            "Invitación inválida o caducada",
+		"password_required":             "Indica una contraseña para crear tu cuenta",
+		"failed_list_invitations":       "Error al listar invitaciones",
+		"only_owner_transfer":           "Solo el propietario puede transferir la propiedad",
+		"cannot_transfer_to_self":       "Ya eres el propietario",
+		"transfer_target_inactive":      "El miembro debe estar activo para recibir la propiedad",
+		"ownership_transfer_sent":       "Transferencia de propiedad enviada",
+		"ownership_transfer_cancelled":  "Transferencia de propiedad cancelada",
+		"ownership_transfer_not_found":  "No hay ninguna transferencia de propiedad pendiente",
+		"invalid_ownership_transfer":    "Transferencia de propiedad inválida o caducada",
+		"ownership_transfer_other_user": "Esta transferencia se envió a otro usuario",
+		"ownership_transfer_confirmed":  "Ahora eres el propietario",
+		"already_tenant_owner":          "El miembro ya es el propietario",
+		"ownership_transferred":         "Propiedad transferida",
+		"failed_transfer_ownership":     "Error al transferir la propiedad",
+		"only_owner_manage_domains":     "Solo el propietario puede gestionar dominios",
+		"invalid_domain":                "Dominio inválido",
+		"domain_reserved":               "Este dominio está reservado",
+		"domain_limit_reached":          "Límite de dominios alcanzado",
+		"domain_in_use":                 "Este dominio ya está en uso por otro tenant",
+		"domain_already_added":          "Este dominio ya fue agregado",
+		"domain_not_found":              "Dominio no encontrado",
+		"domain_lookup_failed":          "No se pudo consultar el DNS del dominio",
+		"domain_verified":               "Dominio verificado",
+		"domain_verification_pending":   "Registro TXT no encontrado; la propagación del DNS puede tardar un tiempo",
+		"domain_removed":                "Dominio eliminado",
+		"failed_list_domains":           "Error al listar dominios",
+		"member_role_updated":           "Rol del miembro actualizado",
+		"failed_update_role":            "Error al actualizar rol",
+		"only_owner_remove":             "Solo el propietario puede eliminar miembros",
+		"cannot_remove_self":            "No puede eliminarse a sí mismo",
+		"member_removed":                "Miembro eliminado",
+		"failed_remove_member":          "Error al eliminar miembro",
+		"insufficient_permissions":      "Permisos insuficientes",
 
 		// --- Roles ---
 		"failed_list_roles":       "Error al listar roles",
@@ -796,6 +1165,61 @@ var messages = map[string]map[string]string{
 		"failed_update_promotion":     "Error al actualizar promoción",
 		"failed_deactivate_promotion": "Error al desactivar promoción",
 
+		// --- Webhooks ---
+		"only_owner_manage_webhooks":     "Solo el propietario puede gestionar webhooks",
+		"failed_list_webhooks":           "Error al listar webhooks",
+		"invalid_webhook_url":            "URL del webhook inválida. Usa una URL http(s) absoluta",
+		"invalid_webhook_events":         "Indica al menos un evento válido",
+		"webhook_url_not_allowed":        "El webhook no puede apuntar a una dirección local, privada o reservada",
+		"webhook_limit_reached":          "Límite de webhooks alcanzado",
+		"webhook_not_found":              "Webhook no encontrado",
+		"webhook_updated":                "Webhook actualizado",
+		"webhook_deleted":                "Webhook eliminado",
+		"failed_list_webhook_deliveries": "Error al listar las entregas del webhook",
+		"webhook_delivery_not_found":     "Entrega no encontrada",
+		"webhook_redelivery_queued":      "Entrega en cola para reenvío",
+
+		// --- Image variants ---
+		"failed_load_image_variants":    "Error al cargar las variantes de imagen",
+		"image_variant_limit_reached":   "Tu plan no permite más variantes de imagen",
+		"invalid_image_variant_name":    "Nombre de variante inválido (usa minúsculas, números y _, hasta 30 caracteres)",
+		"duplicate_image_variant_name":  "Nombre de variante duplicado",
+		"invalid_image_variant_size":    "Tamaño de variante inválido o superior al permitido por el plan",
+		"invalid_image_variant_mode":    "Modo de variante inválido (usa fit, fill o crop)",
+		"invalid_image_variant_quality": "La calidad de la variante debe estar entre 1 y 100",
+		"image_variants_saved":          "Variantes de imagen guardadas",
+		"invalid_image_order":           "La lista debe contener cada imagen exactamente una vez",
+		"failed_reorder_images":         "Error al reordenar las imágenes",
+		"image_order_updated":           "Orden de las imágenes actualizado",
+		"image_already_processing":      "La imagen ya está en cola o procesándose",
+		"image_reprocess_queued":        "Procesamiento en cola",
+		"failed_reprocess_images":       "Error al poner en cola las imágenes para procesamiento",
+		"image_rerender_queued":         "Imágenes en cola para volver a renderizar",
+		"failed_rerender_images":        "Error al poner en cola las imágenes para volver a renderizar",
+
+		// --- Uploads ---
+		"file_too_large":             "El archivo excede el tamaño máximo permitido por el plan",
+		"unsupported_file_type":      "Tipo de archivo no soportado. Suba una imagen JPEG, PNG, GIF o WebP",
+		"invalid_image":              "El archivo no es una imagen válida",
+		"image_dimensions_too_large": "Las dimensiones de la imagen exceden el máximo permitido por el plan",
+		"too_many_files":             "Demasiados archivos en una sola subida",
+
+		// --- Direct uploads ---
+		"failed_create_upload":     "Error al iniciar la subida",
+		"upload_not_found":         "El archivo aún no se ha subido",
+		"image_already_uploaded":   "La subida de esta imagen ya se completó",
+		"failed_complete_upload":   "Error al completar la subida",
+		"image_upload_completed":   "Subida completada",
+		"invalid_upload_signature": "URL de subida inválida o caducada",
+
+		// --- Private media ---
+		"invalid_image_visibility":       "Visibilidad no válida; use public o private",
+		"failed_update_image_visibility": "Error al cambiar la visibilidad de la imagen",
+		"file_not_found":                 "Archivo no encontrado",
+		"failed_sign_url":                "Error al generar el enlace de descarga",
+		"invalid_last_event_id":          "Last-Event-ID inválido",
+		"failed_load_image_events":       "Error al cargar los eventos de imágenes",
+
 		// --- Validation templates ---
 		"validation.required": "%s es obligatorio",
 		"validation.email":    "%s debe ser un correo electrónico válido",
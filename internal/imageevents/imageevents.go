@@ -0,0 +1,154 @@
+package imageevents
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Event types, one per processing state change
+const (
+	Processing = "processing"
+	Completed  = "completed"
+	Failed     = "failed"
+)
+
+// Retention is how long events are kept for clients resuming a stream
+const Retention = 24 * time.Hour
+
+// Event is a processing state change of an image. Reason explains a
+// failure.
+type Event struct {
+	ID            int64     `json:"id"`
+	ImageID       string    `json:"image_id"`
+	ImageableType string    `json:"imageable_type"`
+	ImageableID   string    `json:"imageable_id"`
+	Type          string    `json:"type"`
+	Reason        *string   `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Log is the image_events table. Appending also wakes up the tenant's
+// streams through Redis; the streams then read the log, so a lost
+// notification only delays events.
+type Log struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+// NewLog creates an event log
+func NewLog(db *pgxpool.Pool, rdb *redis.Client) *Log {
+	return &Log{db: db, rdb: rdb}
+}
+
+// Channel is the Redis channel signalled when an event of the tenant is
+// appended
+func Channel(tenantID string) string {
+	return "image:events:" + tenantID
+}
+
+// Append records an event of an image. Images without a tenant, i.e. user
+// avatars, and images deleted in the meantime have no events.
+//
+// Ids come from a sequence, so concurrent inserts can commit out of order
+// and a stream that already read a later id would skip the earlier one.
+// Appends of a tenant are therefore serialized: the id is drawn under a
+// per-tenant lock held until commit, so a tenant's events become visible
+// in id order.
+func (l *Log) Append(ctx context.Context, imageID, eventType, reason string) error {
+	tx, err := l.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var tenantID string
+	err = tx.QueryRow(ctx,
+		`SELECT tenant_id::text FROM images WHERE id = $1 AND tenant_id IS NOT NULL`, imageID,
+	).Scan(&tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('image_events:' || $1))`, tenantID); err != nil {
+		return err
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx,
+		`INSERT INTO image_events (tenant_id, image_id, imageable_type, imageable_id, type, reason)
+		 SELECT tenant_id, id, imageable_type, imageable_id, $2, NULLIF($3, '')
+		 FROM images WHERE id = $1
+		 RETURNING id`,
+		imageID, eventType, reason,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return l.rdb.Publish(ctx, Channel(tenantID), id).Err()
+}
+
+// Since returns up to limit events of the tenant after the event afterID,
+// oldest first
+func (l *Log) Since(ctx context.Context, tenantID string, afterID int64, limit int) ([]Event, error) {
+	rows, err := l.db.Query(ctx,
+		`SELECT id, image_id, imageable_type, imageable_id, type, reason, created_at
+		 FROM image_events
+		 WHERE tenant_id = $1 AND id > $2
+		 ORDER BY id LIMIT $3`,
+		tenantID, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.ImageID, &e.ImageableType, &e.ImageableID, &e.Type, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Latest returns the id of the tenant's latest event, 0 when there is none
+func (l *Log) Latest(ctx context.Context, tenantID string) (int64, error) {
+	var id int64
+	err := l.db.QueryRow(ctx,
+		`SELECT COALESCE(MAX(id), 0) FROM image_events WHERE tenant_id = $1`, tenantID,
+	).Scan(&id)
+	return id, err
+}
+
+// Subscribe listens for appended events of the tenant. Messages carry the
+// event id; read the events with Since.
+func (l *Log) Subscribe(ctx context.Context, tenantID string) *redis.PubSub {
+	return l.rdb.Subscribe(ctx, Channel(tenantID))
+}
+
+// Prune deletes events older than Retention and returns how many
+func (l *Log) Prune(ctx context.Context) (int64, error) {
+	tag, err := l.db.Exec(ctx,
+		`DELETE FROM image_events WHERE created_at < NOW() - make_interval(secs => $1)`,
+		Retention.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,378 @@
+package imagequeue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the Postgres NOTIFY channel signalled when a job is queued, so
+// idle workers wake up without waiting for their next poll
+const Channel = "image_jobs"
+
+const (
+	// lease is how long a claimed job belongs to its worker. A job still
+	// running when its lease ends is assumed lost and handed out again.
+	lease = 10 * time.Minute
+	// retryMax caps the delay between attempts
+	retryMax = time.Hour
+)
+
+var (
+	ErrJobNotFound   = errors.New("image_job_not_found")
+	ErrImageNotFound = errors.New("image_not_found")
+	ErrAlreadyQueued = errors.New("image_already_processing")
+)
+
+// Config tunes retries. A failed job waits RetryBase before its second
+// attempt, twice that before the third and so on; after MaxAttempts it is
+// moved to the dead-letter list.
+type Config struct {
+	MaxAttempts int
+	RetryBase   time.Duration
+}
+
+// Queue is the image processing queue, stored in image_jobs. Any number of
+// workers can consume it concurrently.
+type Queue struct {
+	db  *pgxpool.Pool
+	cfg Config
+}
+
+// NewQueue creates a queue. Zero config values fall back to 5 attempts and
+// a 30 second first retry.
+func NewQueue(db *pgxpool.Pool, cfg Config) *Queue {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.RetryBase <= 0 {
+		cfg.RetryBase = 30 * time.Second
+	}
+	return &Queue{db: db, cfg: cfg}
+}
+
+// Job is a claimed unit of work. Attempt counts this run. TenantID is empty
+// for user avatars.
+type Job struct {
+	ID       string
+	ImageID  string
+	TenantID string
+	Attempt  int
+}
+
+// DeadJob is an entry of the dead-letter list
+type DeadJob struct {
+	ID        string    `json:"id"`
+	ImageID   string    `json:"image_id"`
+	TenantID  string    `json:"tenant_id"`
+	Attempts  int       `json:"attempts"`
+	LastError *string   `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// Enqueue queues an image for processing. An image that already has a job
+// waiting or running is left alone; a dead job of the image is replaced.
+func (q *Queue) Enqueue(ctx context.Context, imageID string) error {
+	_, err := q.db.Exec(ctx,
+		`WITH dead AS (
+			DELETE FROM image_jobs WHERE image_id = $1 AND status = 'dead'
+		 ), job AS (
+			INSERT INTO image_jobs (image_id, tenant_id)
+			SELECT id, tenant_id FROM images WHERE id = $1
+			ON CONFLICT (image_id) WHERE status IN ('queued', 'running') DO NOTHING
+			RETURNING id
+		 )
+		 SELECT pg_notify($2, id::text) FROM job`,
+		imageID, Channel,
+	)
+	return err
+}
+
+// Requeue queues a completed or failed image of the tenant to be processed
+// again with a fresh set of attempts. The image goes back to pending until
+// its job has run. It returns ErrImageNotFound when the image is not the
+// tenant's or its file is still being uploaded, and ErrAlreadyQueued when it
+// is waiting or being processed.
+func (q *Queue) Requeue(ctx context.Context, tenantID, imageID string) error {
+	tx, err := q.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE images SET processing_status = 'pending', updated_at = NOW()
+		 WHERE tenant_id = $1 AND id = $2 AND processing_status <> 'uploading'`,
+		tenantID, imageID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrImageNotFound
+	}
+
+	tag, err = tx.Exec(ctx,
+		`WITH dead AS (
+			DELETE FROM image_jobs WHERE image_id = $1 AND status = 'dead'
+		 ), job AS (
+			INSERT INTO image_jobs (image_id, tenant_id)
+			SELECT id, tenant_id FROM images WHERE id = $1
+			ON CONFLICT (image_id) WHERE status IN ('queued', 'running') DO NOTHING
+			RETURNING id
+		 )
+		 SELECT pg_notify($2, id::text) FROM job`,
+		imageID, Channel,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyQueued
+	}
+	return tx.Commit(ctx)
+}
+
+// RequeueTenant queues every image of a tenant in the given status
+// (completed or failed) to be processed again, e.g. to render a changed set
+// of variants or retry failures. The images go back to pending until their
+// job has run. It returns how many were queued.
+func (q *Queue) RequeueTenant(ctx context.Context, tenantID, status string) (int, error) {
+	tx, err := q.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`WITH target AS (
+			SELECT id, tenant_id FROM images
+			WHERE tenant_id = $1 AND processing_status = $2
+		 ), dead AS (
+			DELETE FROM image_jobs
+			WHERE status = 'dead' AND image_id IN (SELECT id FROM target)
+		 ), job AS (
+			INSERT INTO image_jobs (image_id, tenant_id)
+			SELECT id, tenant_id FROM target
+			ON CONFLICT (image_id) WHERE status IN ('queued', 'running') DO NOTHING
+			RETURNING image_id
+		 )
+		 UPDATE images SET processing_status = 'pending', updated_at = NOW()
+		 FROM job WHERE images.id = job.image_id`,
+		tenantID, status,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, Channel); err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), tx.Commit(ctx)
+}
+
+// Claim takes the next due job, or returns nil when there is none
+func (q *Queue) Claim(ctx context.Context) (*Job, error) {
+	var j Job
+	err := q.db.QueryRow(ctx,
+		`UPDATE image_jobs SET status = 'running', attempts = attempts + 1,
+			locked_until = NOW() + make_interval(secs => $1), updated_at = NOW()
+		 WHERE id = (
+			SELECT id FROM image_jobs
+			WHERE status = 'queued' AND run_at <= NOW()
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id, image_id, COALESCE(tenant_id::text, ''), attempts`,
+		lease.Seconds(),
+	).Scan(&j.ID, &j.ImageID, &j.TenantID, &j.Attempt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Complete removes a job that ran successfully
+func (q *Queue) Complete(ctx context.Context, job *Job) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM image_jobs WHERE id = $1`, job.ID)
+	return err
+}
+
+// Fail records a failed run. The job is scheduled for another attempt, or
+// moved to the dead-letter list when it has none left; dead reports which.
+func (q *Queue) Fail(ctx context.Context, job *Job, cause error) (dead bool, err error) {
+	msg := cause.Error()
+	if job.Attempt >= q.cfg.MaxAttempts {
+		_, err = q.db.Exec(ctx,
+			`UPDATE image_jobs SET status = 'dead', last_error = $2, locked_until = NULL, updated_at = NOW() WHERE id = $1`,
+			job.ID, msg,
+		)
+		return true, err
+	}
+	_, err = q.db.Exec(ctx,
+		`UPDATE image_jobs SET status = 'queued', last_error = $2, run_at = $3, locked_until = NULL, updated_at = NOW() WHERE id = $1`,
+		job.ID, msg, time.Now().Add(q.RetryDelay(job.Attempt)),
+	)
+	return false, err
+}
+
+// RetryDelay is how long to wait after the given (1-based) failed attempt
+func (q *Queue) RetryDelay(attempt int) time.Duration {
+	delay := q.cfg.RetryBase
+	for i := 1; i < attempt && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
+
+// Sweep recovers work that fell through the cracks:
+//   - running jobs whose lease expired (their worker died) are queued again,
+//     or declared dead when that was their last attempt, failing their image
+//   - images still pending or processing after staleAfter without a live
+//     job are queued, so an upload whose enqueue failed is not lost
+//
+// It returns how many jobs were requeued or created, and the jobs it
+// declared dead so the caller can report their images as failed. Sweeping
+// is idempotent and safe to run from several workers at once.
+func (q *Queue) Sweep(ctx context.Context, staleAfter time.Duration) (int, []DeadJob, error) {
+	rows, err := q.db.Query(ctx,
+		`WITH lost AS (
+			UPDATE image_jobs SET
+				status = CASE WHEN attempts >= $1 THEN 'dead' ELSE 'queued' END,
+				last_error = 'worker lost while processing',
+				run_at = NOW(), locked_until = NULL, updated_at = NOW()
+			WHERE status = 'running' AND locked_until < NOW()
+			RETURNING id, image_id, tenant_id, status, attempts, last_error, updated_at
+		 ), failed AS (
+			UPDATE images SET processing_status = 'failed', updated_at = NOW()
+			FROM lost WHERE images.id = lost.image_id AND lost.status = 'dead'
+		 )
+		 SELECT id, image_id, COALESCE(tenant_id::text, ''), status = 'dead', attempts, last_error, updated_at FROM lost`,
+		q.cfg.MaxAttempts,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	recovered := 0
+	var dead []DeadJob
+	for rows.Next() {
+		var d DeadJob
+		var isDead bool
+		if err := rows.Scan(&d.ID, &d.ImageID, &d.TenantID, &isDead, &d.Attempts, &d.LastError, &d.FailedAt); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		if isDead {
+			dead = append(dead, d)
+		} else {
+			recovered++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	rows, err = q.db.Query(ctx,
+		`WITH stale AS (
+			INSERT INTO image_jobs (image_id, tenant_id)
+			SELECT i.id, i.tenant_id FROM images i
+			WHERE i.processing_status IN ('pending', 'processing')
+			  AND i.updated_at < NOW() - make_interval(secs => $1)
+			  AND NOT EXISTS (SELECT 1 FROM image_jobs j WHERE j.image_id = i.id)
+			ON CONFLICT (image_id) WHERE status IN ('queued', 'running') DO NOTHING
+			RETURNING image_id
+		 )
+		 UPDATE images SET processing_status = 'pending', updated_at = NOW()
+		 FROM stale WHERE images.id = stale.image_id
+		 RETURNING images.id`,
+		staleAfter.Seconds(),
+	)
+	if err != nil {
+		return recovered, dead, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		recovered++
+	}
+	return recovered, dead, rows.Err()
+}
+
+// ListDead returns the dead-letter list, most recent first
+func (q *Queue) ListDead(ctx context.Context, limit int) ([]DeadJob, error) {
+	rows, err := q.db.Query(ctx,
+		`SELECT id, image_id, COALESCE(tenant_id::text, ''), attempts, last_error, updated_at
+		 FROM image_jobs WHERE status = 'dead'
+		 ORDER BY updated_at DESC LIMIT $1`, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []DeadJob{}
+	for rows.Next() {
+		var d DeadJob
+		if err := rows.Scan(&d.ID, &d.ImageID, &d.TenantID, &d.Attempts, &d.LastError, &d.FailedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// RetryDead moves a dead job, or every dead job when jobID is empty, back to
+// the queue with a fresh set of attempts. It returns how many were requeued.
+func (q *Queue) RetryDead(ctx context.Context, jobID string) (int, error) {
+	tx, err := q.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE image_jobs SET status = 'queued', attempts = 0, run_at = NOW(), updated_at = NOW()
+		 WHERE status = 'dead' AND ($1 = '' OR id::text = $1)
+		   AND NOT EXISTS (
+			SELECT 1 FROM image_jobs a
+			WHERE a.image_id = image_jobs.image_id AND a.status IN ('queued', 'running')
+		   )
+		 RETURNING image_id`,
+		jobID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var imageIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		imageIDs = append(imageIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if jobID != "" && len(imageIDs) == 0 {
+		return 0, ErrJobNotFound
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE images SET processing_status = 'pending', updated_at = NOW() WHERE id = ANY($1)`, imageIDs,
+	); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, Channel); err != nil {
+		return 0, err
+	}
+	return len(imageIDs), tx.Commit(ctx)
+}
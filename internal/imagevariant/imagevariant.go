@@ -0,0 +1,115 @@
+package imagevariant
+
+import (
+	"errors"
+	"regexp"
+)
+
+// Resize modes
+const (
+	// ModeFit scales the image down to fit within the box, keeping its aspect
+	// ratio; the result can be smaller than the box on one side
+	ModeFit = "fit"
+	// ModeFill scales and center-crops the image to exactly fill the box
+	ModeFill = "fill"
+	// ModeCrop cuts the center of the box out of the image without scaling
+	ModeCrop = "crop"
+)
+
+// DefaultQuality is the WebP/JPEG quality of a preset that does not set one
+const DefaultQuality = 85
+
+// Preset describes one variant generated for every image of a tenant
+type Preset struct {
+	Name    string `json:"name"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Mode    string `json:"mode"`
+	Quality int    `json:"quality"`
+}
+
+// Defaults are used by tenants that have not defined their own presets
+var Defaults = []Preset{
+	{Name: "medium", Width: 800, Height: 800, Mode: ModeFit, Quality: DefaultQuality},
+	{Name: "small", Width: 350, Height: 350, Mode: ModeFit, Quality: DefaultQuality},
+	{Name: "thumb", Width: 100, Height: 100, Mode: ModeFit, Quality: DefaultQuality},
+}
+
+// Variant is a generated variant as stored in images.variants, keyed by
+// preset name
+type Variant struct {
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"`
+}
+
+// Limits are what a tenant's plan allows
+type Limits struct {
+	MaxVariants int `json:"max_variants"`
+	MaxSize     int `json:"max_size"`
+}
+
+// DefaultLimits apply to tenants without an active plan. They match the
+// smallest plans and fit Defaults.
+var DefaultLimits = Limits{MaxVariants: 3, MaxSize: 1600}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]{1,30}$`)
+
+// Validate checks presets against the plan limits and fills in defaults.
+// Errors are i18n keys.
+func Validate(presets []Preset, limits Limits) error {
+	if len(presets) > limits.MaxVariants {
+		return errors.New("image_variant_limit_reached")
+	}
+	seen := make(map[string]bool, len(presets))
+	for i := range presets {
+		p := &presets[i]
+		if !namePattern.MatchString(p.Name) {
+			return errors.New("invalid_image_variant_name")
+		}
+		if seen[p.Name] {
+			return errors.New("duplicate_image_variant_name")
+		}
+		seen[p.Name] = true
+		if p.Width < 1 || p.Height < 1 || p.Width > limits.MaxSize || p.Height > limits.MaxSize {
+			return errors.New("invalid_image_variant_size")
+		}
+		if p.Mode == "" {
+			p.Mode = ModeFit
+		}
+		if p.Mode != ModeFit && p.Mode != ModeFill && p.Mode != ModeCrop {
+			return errors.New("invalid_image_variant_mode")
+		}
+		if p.Quality == 0 {
+			p.Quality = DefaultQuality
+		}
+		if p.Quality < 1 || p.Quality > 100 {
+			return errors.New("invalid_image_variant_quality")
+		}
+	}
+	return nil
+}
+
+// Clamp fits presets saved under a bigger plan into the current limits:
+// extra presets are dropped and sizes are capped. Presets that were never
+// customised (nil) resolve to Defaults.
+func Clamp(presets []Preset, limits Limits) []Preset {
+	if presets == nil {
+		presets = Defaults
+	}
+	if len(presets) > limits.MaxVariants {
+		presets = presets[:limits.MaxVariants]
+	}
+	out := make([]Preset, len(presets))
+	for i, p := range presets {
+		p.Width = min(p.Width, limits.MaxSize)
+		p.Height = min(p.Height, limits.MaxSize)
+		if p.Quality == 0 {
+			p.Quality = DefaultQuality
+		}
+		out[i] = p
+	}
+	return out
+}
@@ -6,14 +6,13 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
-	"github.com/saas-single-db-api/internal/cache"
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/i18n"
 	"github.com/saas-single-db-api/internal/utils"
 )
 
 // AdminAuthMiddleware validates JWT for saas_admin_users
-func AdminAuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.HandlerFunc {
+func AdminAuthMiddleware(keys *utils.KeySet, sessions *auth.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractToken(c)
 		if token == "" {
@@ -22,28 +21,26 @@ func AdminAuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.Handle
 			return
 		}
 
-		// Check blacklist
-		if cache.IsBlacklisted(redisClient, context.Background(), token) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "token_invalidated")})
+		claims, err := utils.ValidateAdminToken(token, keys)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
 			c.Abort()
 			return
 		}
 
-		claims, err := utils.ValidateAdminToken(token, jwtSecret)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
-			c.Abort()
+		if !checkSession(c, sessions, claims.ID) {
 			return
 		}
 
 		c.Set("admin_id", claims.AdminID)
+		c.Set("session_id", claims.ID)
 		c.Set("token", token)
 		c.Next()
 	}
 }
 
 // UserAuthMiddleware validates JWT for backoffice users
-func UserAuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.HandlerFunc {
+func UserAuthMiddleware(keys *utils.KeySet, sessions *auth.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractToken(c)
 		if token == "" {
@@ -52,29 +49,27 @@ func UserAuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.Handler
 			return
 		}
 
-		// Check blacklist
-		if cache.IsBlacklisted(redisClient, context.Background(), token) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "token_invalidated")})
+		claims, err := utils.ValidateUserToken(token, keys)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
 			c.Abort()
 			return
 		}
 
-		claims, err := utils.ValidateUserToken(token, jwtSecret)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
-			c.Abort()
+		if !checkSession(c, sessions, claims.ID) {
 			return
 		}
 
 		c.Set("user_id", claims.UserID)
 		c.Set("token_tenant_id", claims.TenantID)
+		c.Set("session_id", claims.ID)
 		c.Set("token", token)
 		c.Next()
 	}
 }
 
 // AppAuthMiddleware validates JWT for tenant_app_users
-func AppAuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.HandlerFunc {
+func AppAuthMiddleware(keys *utils.KeySet, sessions *auth.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractToken(c)
 		if token == "" {
@@ -83,27 +78,42 @@ func AppAuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.HandlerF
 			return
 		}
 
-		// Check blacklist
-		if cache.IsBlacklisted(redisClient, context.Background(), token) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "token_invalidated")})
+		claims, err := utils.ValidateAppUserToken(token, keys)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
 			c.Abort()
 			return
 		}
 
-		claims, err := utils.ValidateAppUserToken(token, jwtSecret)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
-			c.Abort()
+		if !checkSession(c, sessions, claims.ID) {
 			return
 		}
 
 		c.Set("app_user_id", claims.AppUserID)
 		c.Set("token_tenant_id", claims.TenantID)
+		c.Set("session_id", claims.ID)
 		c.Set("token", token)
 		c.Next()
 	}
 }
 
+// checkSession rejects tokens whose session was revoked (logout, password
+// reset, revocation from another device) and records activity on the session.
+func checkSession(c *gin.Context, sessions *auth.SessionService, sessionID string) bool {
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid_token")})
+		c.Abort()
+		return false
+	}
+	if sessions.IsRevoked(context.Background(), sessionID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "token_invalidated")})
+		c.Abort()
+		return false
+	}
+	sessions.Touch(context.Background(), sessionID)
+	return true
+}
+
 // extractToken extracts the Bearer token from the Authorization header.
 // Falls back to the ?token= query param to support SSE (EventSource cannot set headers).
 func extractToken(c *gin.Context) string {
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saas-single-db-api/internal/i18n"
+)
+
+// PermissionChecker reports whether an admin holds a permission
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, adminID, permission string) bool
+}
+
+// Requirement is the set of permissions a route group demands. An admin
+// needs any one of the listed permissions. Read applies to GET and HEAD,
+// Write to every other method. An empty list requires no permission.
+type Requirement struct {
+	Read  []string
+	Write []string
+}
+
+// NoPermission declares routes that are public or open to any authenticated
+// admin. It exists so that such routes are declared explicitly.
+var NoPermission = Requirement{}
+
+// Require applies the same permissions to every method
+func Require(perms ...string) Requirement {
+	return Requirement{Read: perms, Write: perms}
+}
+
+// ForWrites returns a copy of r whose non-read methods require perms instead
+func (r Requirement) ForWrites(perms ...string) Requirement {
+	return Requirement{Read: r.Read, Write: perms}
+}
+
+func (r Requirement) forMethod(method string) []string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return r.Read
+	}
+	return r.Write
+}
+
+// Access registers routes together with the permission they require and
+// keeps track of them, so Verify can refuse to start a router that has a
+// route registered without a declaration.
+type Access struct {
+	checker  PermissionChecker
+	declared map[string]bool
+}
+
+// NewAccess creates an Access that checks permissions with checker
+func NewAccess(checker PermissionChecker) *Access {
+	return &Access{checker: checker, declared: map[string]bool{}}
+}
+
+// PermissionGroup is a router group whose routes all share a Requirement.
+// It only exposes route registration; nest groups through Access.Group.
+type PermissionGroup struct {
+	access *Access
+	group  *gin.RouterGroup
+	req    Requirement
+}
+
+// Group creates a group under parent whose routes require req. The same
+// relative path may be declared more than once with different requirements.
+func (a *Access) Group(parent *gin.RouterGroup, relativePath string, req Requirement) *PermissionGroup {
+	return &PermissionGroup{access: a, group: parent.Group(relativePath), req: req}
+}
+
+func (g *PermissionGroup) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodGet, relativePath, handlers)
+}
+
+func (g *PermissionGroup) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPost, relativePath, handlers)
+}
+
+func (g *PermissionGroup) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPut, relativePath, handlers)
+}
+
+func (g *PermissionGroup) PATCH(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPatch, relativePath, handlers)
+}
+
+func (g *PermissionGroup) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodDelete, relativePath, handlers)
+}
+
+func (g *PermissionGroup) handle(method, relativePath string, handlers []gin.HandlerFunc) {
+	if perms := g.req.forMethod(method); len(perms) > 0 {
+		handlers = append([]gin.HandlerFunc{g.access.require(perms)}, handlers...)
+	}
+	g.group.Handle(method, relativePath, handlers...)
+	g.access.declared[routeKey(method, joinPaths(g.group.BasePath(), relativePath))] = true
+}
+
+// require aborts with 403 unless the admin set by AdminAuthMiddleware holds
+// one of perms
+func (a *Access) require(perms []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID := c.GetString("admin_id")
+		for _, p := range perms {
+			if a.checker.HasPermission(c.Request.Context(), adminID, p) {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "permission_denied")})
+		c.Abort()
+	}
+}
+
+// Verify returns an error naming every route that was registered without
+// going through Access
+func (a *Access) Verify(routes gin.RoutesInfo) error {
+	var missing []string
+	for _, r := range routes {
+		if !a.declared[routeKey(r.Method, r.Path)] {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes without a declared permission: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func routeKey(method, fullPath string) string {
+	return method + " " + fullPath
+}
+
+// joinPaths mirrors how gin builds a route's full path from its group
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+	return joined
+}
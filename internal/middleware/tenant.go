@@ -11,24 +11,31 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/saas-single-db-api/internal/cache"
+	"github.com/saas-single-db-api/internal/domains"
 	"github.com/saas-single-db-api/internal/i18n"
 )
 
 type tenantCacheData struct {
 	TenantID string   `json:"tenant_id"`
+	URLCode  string   `json:"url_code"`
 	Status   string   `json:"status"`
 	Features []string `json:"features"`
 	Language string   `json:"language"`
 }
 
+// tenantCacheTTL is how long a resolved tenant is cached
+const tenantCacheTTL = 5 * time.Minute
+
 // TenantMiddleware resolves tenant_id from :url_code
 // 1. Extract url_code from route param
 // 2. Check Redis cache: "tenant:urlcode:{url_code}"
 // 3. If cache miss: query PostgreSQL → SET in Redis with 5min TTL
-// 4. Inject tenant_id into context: c.Set("tenant_id", tenantID)
+// 4. Inject tenant_id and url_code into context: c.Set("tenant_id", tenantID)
 // 5. Inject active features: c.Set("features", []string{...})
 // 6. Verify tenant is "active"; if not, return 403
-func TenantMiddleware(db *pgxpool.Pool, cache *redis.Client) gin.HandlerFunc {
+func TenantMiddleware(db *pgxpool.Pool, redisClient *redis.Client) gin.HandlerFunc {
+	tenants := cache.NewTenantContext(redisClient)
 	return func(c *gin.Context) {
 		urlCode := c.Param("url_code")
 		if urlCode == "" {
@@ -40,71 +47,108 @@ func TenantMiddleware(db *pgxpool.Pool, cache *redis.Client) gin.HandlerFunc {
 		// Remove leading slash if present
 		urlCode = strings.TrimPrefix(urlCode, "/")
 
-		ctx := context.Background()
-		cacheKey := fmt.Sprintf("tenant:urlcode:%s", urlCode)
-
-		// Try cache first
-		cached, err := cache.Get(ctx, cacheKey).Result()
-		if err == nil {
-			var data tenantCacheData
-			if json.Unmarshal([]byte(cached), &data) == nil {
-				if data.Status != "active" {
-					c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "tenant_not_active")})
-					c.Abort()
-					return
-				}
-				c.Set("tenant_id", data.TenantID)
-				c.Set("features", data.Features)
-				if data.Language != "" {
-					c.Set("language", data.Language)
-				} else {
-					c.Set("language", "pt-BR")
-				}
-				c.Next()
-				return
-			}
-		}
+		resolveTenant(c, db, tenants, fmt.Sprintf("tenant:urlcode:%s", urlCode),
+			`SELECT id, url_code, status FROM tenants WHERE url_code = $1 AND deleted_at IS NULL`, urlCode)
+	}
+}
 
-		// Cache miss — query DB
-		var tenantID, status string
-		err = db.QueryRow(ctx,
-			`SELECT id, status FROM tenants WHERE url_code = $1 AND deleted_at IS NULL`,
-			urlCode,
-		).Scan(&tenantID, &status)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "tenant_not_found")})
-			c.Abort()
+// HostTenantMiddleware resolves the tenant from the request host instead of
+// the path, for storefronts served on their own host:
+//   - {subdomain}.{baseDomain} resolves by tenants.subdomain ("tenant:subdomain:{subdomain}")
+//   - any other host must be a verified custom domain ("tenant:domain:{host}")
+//
+// Caching and the context it injects are the same as TenantMiddleware. An
+// empty baseDomain disables subdomain resolution.
+func HostTenantMiddleware(db *pgxpool.Pool, redisClient *redis.Client, baseDomain string) gin.HandlerFunc {
+	tenants := cache.NewTenantContext(redisClient)
+	baseDomain = domains.NormalizeHost(baseDomain)
+	return func(c *gin.Context) {
+		host := domains.NormalizeHost(c.Request.Host)
+
+		if subdomain, ok := domains.Subdomain(host, baseDomain); ok {
+			resolveTenant(c, db, tenants, fmt.Sprintf("tenant:subdomain:%s", subdomain),
+				`SELECT id, url_code, status FROM tenants WHERE subdomain = $1 AND deleted_at IS NULL`, subdomain)
 			return
 		}
 
-		if status != "active" {
-			c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "tenant_not_active")})
-			c.Abort()
+		resolveTenant(c, db, tenants, domains.CacheKey(host),
+			`SELECT t.id, t.url_code, t.status
+			 FROM tenant_domains d
+			 JOIN tenants t ON t.id = d.tenant_id AND t.deleted_at IS NULL
+			 WHERE d.domain = $1 AND d.verified_at IS NOT NULL`, host)
+	}
+}
+
+// resolveTenant loads the tenant found by query (returning id, url_code and
+// status for arg) through the cache at cacheKey and injects it into the context
+func resolveTenant(c *gin.Context, db *pgxpool.Pool, tenants *cache.TenantContext, cacheKey, query string, arg interface{}) {
+	ctx := context.Background()
+
+	// Try cache first
+	cached, err := tenants.Get(ctx, cacheKey)
+	if err == nil {
+		var data tenantCacheData
+		if json.Unmarshal(cached, &data) == nil && data.URLCode != "" {
+			if data.Status != "active" {
+				c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "tenant_not_active")})
+				c.Abort()
+				return
+			}
+			c.Set("tenant_id", data.TenantID)
+			c.Set("url_code", data.URLCode)
+			c.Set("features", data.Features)
+			if data.Language != "" {
+				c.Set("language", data.Language)
+			} else {
+				c.Set("language", "pt-BR")
+			}
+			c.Next()
 			return
 		}
+	}
 
-		// Get active features for this tenant's plan
-		features := getActiveTenantFeatures(ctx, db, tenantID)
+	// Cache miss — query DB
+	var tenantID, urlCode, status string
+	err = db.QueryRow(ctx, query, arg).Scan(&tenantID, &urlCode, &status)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "tenant_not_found")})
+		c.Abort()
+		return
+	}
 
-		// Get tenant language
-		language := getTenantLanguage(ctx, db, tenantID)
+	if status != "active" {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, "tenant_not_active")})
+		c.Abort()
+		return
+	}
 
-		// Cache it
-		data := tenantCacheData{
-			TenantID: tenantID,
-			Status:   status,
-			Features: features,
-			Language: language,
-		}
-		if bytes, err := json.Marshal(data); err == nil {
-			cache.Set(ctx, cacheKey, string(bytes), 5*time.Minute)
-		}
+	// Get active features for this tenant's plan
+	planID, featureIDs, features := getTenantPlanFeatures(ctx, db, tenantID)
 
-		c.Set("tenant_id", tenantID)
-		c.Set("features", features)
-		c.Set("language", language)
-		c.Next()
+	// Get tenant language
+	language := getTenantLanguage(ctx, db, tenantID)
+
+	// Cache it, indexed by tenant, plan and features for invalidation
+	data := tenantCacheData{
+		TenantID: tenantID,
+		URLCode:  urlCode,
+		Status:   status,
+		Features: features,
+		Language: language,
 	}
+	if bytes, err := json.Marshal(data); err == nil {
+		tenants.Store(ctx, cacheKey, bytes, tenantCacheTTL, cache.TenantContextRefs{
+			TenantID:   tenantID,
+			PlanID:     planID,
+			FeatureIDs: featureIDs,
+		})
+	}
+
+	c.Set("tenant_id", tenantID)
+	c.Set("url_code", urlCode)
+	c.Set("features", features)
+	c.Set("language", language)
+	c.Next()
 }
 
 // TenantAccessMiddleware ensures the authenticated user's token tenant_id matches
@@ -130,28 +174,36 @@ func TenantAccessMiddleware() gin.HandlerFunc {
 	}
 }
 
-func getActiveTenantFeatures(ctx context.Context, db *pgxpool.Pool, tenantID string) []string {
+// getTenantPlanFeatures returns the tenant's active plan, the ids of all the
+// plan's features and the slugs of the active ones
+func getTenantPlanFeatures(ctx context.Context, db *pgxpool.Pool, tenantID string) (string, []string, []string) {
 	rows, err := db.Query(ctx,
-		`SELECT f.slug
+		`SELECT tp.plan_id, f.id, f.slug, COALESCE(f.is_active, false)
 		 FROM tenant_plans tp
-		 JOIN saas_features_plans pf ON pf.plan_id = tp.plan_id
-		 JOIN saas_features f ON f.id = pf.feature_id
-		 WHERE tp.tenant_id = $1 AND tp.is_active = true AND f.is_active = true`,
+		 LEFT JOIN saas_features_plans pf ON pf.plan_id = tp.plan_id
+		 LEFT JOIN saas_features f ON f.id = pf.feature_id
+		 WHERE tp.tenant_id = $1 AND tp.is_active = true`,
 		tenantID,
 	)
 	if err != nil {
-		return []string{}
+		return "", nil, []string{}
 	}
 	defer rows.Close()
 
-	var features []string
+	var planID string
+	var featureIDs, features []string
 	for rows.Next() {
-		var slug string
-		if rows.Scan(&slug) == nil {
-			features = append(features, slug)
+		var featureID, slug *string
+		var active bool
+		if rows.Scan(&planID, &featureID, &slug, &active) != nil || featureID == nil {
+			continue
+		}
+		featureIDs = append(featureIDs, *featureID)
+		if active {
+			features = append(features, *slug)
 		}
 	}
-	return features
+	return planID, featureIDs, features
 }
 
 func getTenantLanguage(ctx context.Context, db *pgxpool.Pool, tenantID string) string {
@@ -34,6 +34,7 @@ type SystemAdminRole struct {
 	Title       string    `json:"title"`
 	Slug        string    `json:"slug"`
 	Description *string   `json:"description"`
+	Require2FA  bool      `json:"require_2fa"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -56,10 +57,39 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// MFAVerifyRequest completes a login that requires a second factor.
+// Code is a TOTP code or a recovery code.
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFATokenRequest carries the challenge token of a pending login
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFACodeRequest carries a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFADisableRequest is the request body for disabling 2FA
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
 // LoginResponse is the response for admin login
 type LoginResponse struct {
-	Token string       `json:"token"`
-	Admin AdminUserDTO `json:"admin"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	Admin        AdminUserDTO `json:"admin"`
+}
+
+// RefreshTokenRequest is the request body for token refresh and logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // AdminUserDTO is a safe representation of an admin user (no password hash)
@@ -123,12 +153,14 @@ type CreateRoleRequest struct {
 	Title       string  `json:"title" binding:"required"`
 	Slug        string  `json:"slug" binding:"required"`
 	Description *string `json:"description"`
+	Require2FA  bool    `json:"require_2fa"`
 }
 
 // UpdateRoleRequest is the request body for updating a role
 type UpdateRoleRequest struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
+	Require2FA  *bool   `json:"require_2fa"`
 }
 
 // AssignRoleRequest is the request body for assigning a role
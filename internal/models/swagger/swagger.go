@@ -45,8 +45,71 @@ type PaginatedResponse struct {
 
 // AdminLoginResponse is the response from admin login
 type AdminLoginResponse struct {
-	Token string       `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
-	Admin AdminUserDTO `json:"admin"`
+	Token        string       `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string       `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+	Admin        AdminUserDTO `json:"admin"`
+}
+
+// MFARequiredResponse is returned by login when a second factor is needed
+type MFARequiredResponse struct {
+	MFARequired        bool   `json:"mfa_required" example:"true"`
+	MFAToken           string `json:"mfa_token" example:"3b5d5c3712955042212316173ccf37be..."`
+	EnrollmentRequired bool   `json:"enrollment_required" example:"false"`
+}
+
+// MFAVerifyRequest completes a login with a TOTP or recovery code
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"3b5d5c3712955042212316173ccf37be..."`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFATokenRequest carries the challenge token of a pending login
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"3b5d5c3712955042212316173ccf37be..."`
+}
+
+// MFACodeRequest carries a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFADisableRequest is the request for disabling 2FA
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required" example:"secret123"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorSetupResponse is the TOTP enrollment data
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/MySaaS:user@example.com?algorithm=SHA1&digits=6&issuer=MySaaS&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// RecoveryCodesResponse lists one-time recovery codes. They are only shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"3f9a1-0c2b7,a81e4-5d930"`
+}
+
+// RefreshTokenRequest is the request for refreshing an access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+}
+
+// RefreshTokenResponse is the response for a token refresh
+type RefreshTokenResponse struct {
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+}
+
+// SessionDTO is an active login session
+type SessionDTO struct {
+	ID         string  `json:"id" example:"uuid"`
+	TenantID   *string `json:"tenant_id,omitempty" example:"uuid"`
+	UserAgent  *string `json:"user_agent" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)"`
+	IPAddress  *string `json:"ip_address" example:"203.0.113.10"`
+	CreatedAt  string  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	LastSeenAt string  `json:"last_seen_at" example:"2024-01-01T00:00:00Z"`
+	Current    bool    `json:"current" example:"true"`
 }
 
 // AdminUserDTO is a safe representation of an admin user
@@ -74,6 +137,7 @@ type AdminRoleDTO struct {
 	Title       string  `json:"title" example:"Super Admin"`
 	Slug        string  `json:"slug" example:"super_admin"`
 	Description *string `json:"description,omitempty" example:"Full system access"`
+	Require2FA  bool    `json:"require_2fa" example:"false"`
 }
 
 // AdminPermissionDTO is the admin permission
@@ -134,18 +198,22 @@ type OwnerBrief struct {
 
 // PlanResponse represents a plan with features
 type PlanResponse struct {
-	ID           string            `json:"id" example:"uuid"`
-	Name         string            `json:"name" example:"Business Pro"`
-	Description  *string           `json:"description" example:"Business plan with all features"`
-	Translations interface{}       `json:"translations"`
-	PlanType     string            `json:"plan_type" example:"business"`
-	Price        float64           `json:"price" example:"99.90"`
-	MaxUsers     int               `json:"max_users" example:"5"`
-	IsMultilang  bool              `json:"is_multilang" example:"true"`
-	IsActive     bool              `json:"is_active" example:"true"`
-	Features     []FeatureResponse `json:"features,omitempty"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
+	ID                 string            `json:"id" example:"uuid"`
+	Name               string            `json:"name" example:"Business Pro"`
+	Description        *string           `json:"description" example:"Business plan with all features"`
+	Translations       interface{}       `json:"translations"`
+	PlanType           string            `json:"plan_type" example:"business"`
+	Price              float64           `json:"price" example:"99.90"`
+	MaxUsers           int               `json:"max_users" example:"5"`
+	MaxImageVariants   int               `json:"max_image_variants" example:"6"`
+	MaxImageSize       int               `json:"max_image_size" example:"2560"`
+	MaxUploadSize      int64             `json:"max_upload_size" example:"26214400"`
+	MaxUploadDimension int               `json:"max_upload_dimension" example:"10000"`
+	IsMultilang        bool              `json:"is_multilang" example:"true"`
+	IsActive           bool              `json:"is_active" example:"true"`
+	Features           []FeatureResponse `json:"features,omitempty"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
 }
 
 // PlanListResponse wraps a list of plans
@@ -230,11 +298,12 @@ type TenantMemberDTO struct {
 
 // SubscriptionResponse is the response for public subscription
 type SubscriptionResponse struct {
-	TenantID string `json:"tenant_id" example:"uuid"`
-	UserID   string `json:"user_id" example:"uuid"`
-	URLCode  string `json:"url_code" example:"HRP1ZYERFVA"`
-	Token    string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
-	Language string `json:"language" example:"pt-BR"`
+	TenantID     string `json:"tenant_id" example:"uuid"`
+	UserID       string `json:"user_id" example:"uuid"`
+	URLCode      string `json:"url_code" example:"HRP1ZYERFVA"`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+	Language     string `json:"language" example:"pt-BR"`
 }
 
 // TenantBriefDTO is a brief tenant representation
@@ -265,6 +334,7 @@ type UserBriefDTO struct {
 // UserLoginResponse is the response for backoffice user login
 type UserLoginResponse struct {
 	Token             string        `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken      string        `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015..."`
 	Name              string        `json:"name" example:"John"`
 	Email             string        `json:"email" example:"user@example.com"`
 	CurrentTenantCode string        `json:"current_tenant_code" example:"HRP1ZYERFVA"`
@@ -308,9 +378,10 @@ type TenantBrief struct {
 
 // SwitchTenantResponse is the response for switching tenant
 type SwitchTenantResponse struct {
-	Token    string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
-	TenantID string `json:"tenant_id" example:"uuid"`
-	URLCode  string `json:"url_code" example:"HRP1ZYERFVA"`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+	TenantID     string `json:"tenant_id" example:"uuid"`
+	URLCode      string `json:"url_code" example:"HRP1ZYERFVA"`
 }
 
 // BootstrapResponse is the full bootstrap response for initializing the frontend
@@ -371,14 +442,13 @@ type TenantProfileResponse struct {
 	CustomSettings interface{} `json:"custom_settings"`
 }
 
-// CanAddMemberResponse returns whether a member can be added
+// CanAddMemberResponse returns whether a member can be added. Pending
+// invitations count against max_users.
 type CanAddMemberResponse struct {
-	CanAdd         bool   `json:"can_add" example:"true"`
-	CurrentUsers   int    `json:"current_users" example:"2"`
-	MaxUsers       int    `json:"max_users" example:"5"`
-	AvailableSlots int    `json:"available_slots" example:"3"`
-	Reason         string `json:"reason,omitempty"`
-	UpgradeHint    string `json:"upgrade_hint,omitempty"`
+	CanAdd         bool `json:"can_add" example:"true"`
+	MaxUsers       int  `json:"max_users" example:"5"`
+	Current        int  `json:"current" example:"2"`
+	PendingInvites int  `json:"pending_invites" example:"1"`
 }
 
 // MemberDTO represents a member
@@ -400,10 +470,174 @@ type RoleBriefDTO struct {
 	Slug  string `json:"slug" example:"editor"`
 }
 
-// InviteMemberResponse is the response for inviting a member
+// InviteMemberResponse is the response for sending a member invitation
 type InviteMemberResponse struct {
-	UserID  string `json:"user_id" example:"uuid"`
-	Message string `json:"message" example:"member_invited"`
+	InvitationID string `json:"invitation_id" example:"uuid"`
+	ExpiresAt    string `json:"expires_at" example:"2024-01-08T00:00:00Z"`
+	Message      string `json:"message" example:"Convite enviado"`
+}
+
+// InvitationDTO is a pending member invitation
+type InvitationDTO struct {
+	ID        string  `json:"id" example:"uuid"`
+	Email     string  `json:"email" example:"member@example.com"`
+	Name      string  `json:"name" example:"Jane Doe"`
+	RoleID    *string `json:"role_id" example:"uuid"`
+	RoleTitle *string `json:"role_title" example:"Member"`
+	RoleSlug  *string `json:"role_slug" example:"member"`
+	InvitedBy *string `json:"invited_by" example:"uuid"`
+	ExpiresAt string  `json:"expires_at" example:"2024-01-08T00:00:00Z"`
+	Expired   bool    `json:"expired" example:"false"`
+	CreatedAt string  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// InvitationListResponse lists pending invitations
+type InvitationListResponse struct {
+	Data []InvitationDTO `json:"data"`
+}
+
+// InvitationInfoResponse describes an invitation to the invitee
+type InvitationInfoResponse struct {
+	TenantName    string  `json:"tenant_name" example:"Acme"`
+	URLCode       string  `json:"url_code" example:"ABC123XYZ01"`
+	Email         string  `json:"email" example:"member@example.com"`
+	Name          string  `json:"name" example:"Jane Doe"`
+	RoleTitle     *string `json:"role_title" example:"Member"`
+	ExpiresAt     string  `json:"expires_at" example:"2024-01-08T00:00:00Z"`
+	AccountExists bool    `json:"account_exists" example:"false"`
+}
+
+// AcceptInvitationRequest accepts a member invitation. Name and password are
+// only used when no account exists for the invited email.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+	Name     string `json:"name" example:"Jane Doe"`
+	Password string `json:"password" example:"secret123"`
+}
+
+// AcceptInvitationResponse is returned after accepting an invitation
+type AcceptInvitationResponse struct {
+	UserID         string `json:"user_id" example:"uuid"`
+	URLCode        string `json:"url_code" example:"ABC123XYZ01"`
+	AccountCreated bool   `json:"account_created" example:"true"`
+	Message        string `json:"message" example:"Convite aceito"`
+}
+
+// OwnershipTransferResponse is the response for starting an ownership transfer
+type OwnershipTransferResponse struct {
+	TransferID string `json:"transfer_id" example:"uuid"`
+	ExpiresAt  string `json:"expires_at" example:"2024-01-04T00:00:00Z"`
+	Message    string `json:"message" example:"Transferência de propriedade enviada"`
+}
+
+// OwnershipTransferDTO is a tenant's open ownership transfer
+type OwnershipTransferDTO struct {
+	ID         string `json:"id" example:"uuid"`
+	FromUserID string `json:"from_user_id" example:"uuid"`
+	ToUserID   string `json:"to_user_id" example:"uuid"`
+	ToName     string `json:"to_name" example:"Jane Doe"`
+	ToEmail    string `json:"to_email" example:"member@example.com"`
+	ExpiresAt  string `json:"expires_at" example:"2024-01-04T00:00:00Z"`
+	Expired    bool   `json:"expired" example:"false"`
+	CreatedAt  string `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// ConfirmOwnershipTransferRequest confirms an ownership transfer
+type ConfirmOwnershipTransferRequest struct {
+	Token string `json:"token" binding:"required" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+}
+
+// ConfirmOwnershipTransferResponse is returned after confirming an ownership transfer
+type ConfirmOwnershipTransferResponse struct {
+	URLCode string `json:"url_code" example:"ABC123XYZ01"`
+	Message string `json:"message" example:"Você agora é o proprietário"`
+}
+
+// TenantDomainDTO is a custom domain and the TXT record that verifies it
+type TenantDomainDTO struct {
+	ID            string  `json:"id" example:"uuid"`
+	Domain        string  `json:"domain" example:"shop.example.com"`
+	Verified      bool    `json:"verified" example:"false"`
+	VerifiedAt    *string `json:"verified_at" example:"2024-01-01T00:00:00Z"`
+	LastCheckedAt *string `json:"last_checked_at" example:"2024-01-01T00:00:00Z"`
+	RecordType    string  `json:"record_type" example:"TXT"`
+	RecordName    string  `json:"record_name" example:"_saas-verification.shop.example.com"`
+	RecordValue   string  `json:"record_value" example:"saas-verification=9f86d081884c7d659a2feaa0c55ad015..."`
+	CreatedAt     string  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// TenantDomainListResponse lists custom domains
+type TenantDomainListResponse struct {
+	Data []TenantDomainDTO `json:"data"`
+}
+
+// VerifyDomainResponse is the result of a domain verification
+type VerifyDomainResponse struct {
+	Domain  TenantDomainDTO `json:"domain"`
+	Message string          `json:"message" example:"Domínio verificado"`
+}
+
+// WebhookDTO is a webhook endpoint. Secret is only present when the
+// endpoint is created.
+type WebhookDTO struct {
+	ID          string   `json:"id" example:"uuid"`
+	URL         string   `json:"url" example:"https://example.com/hooks/saas"`
+	Description *string  `json:"description" example:"Sincronização do ERP"`
+	Events      []string `json:"events" example:"product.created,product.updated"`
+	IsActive    bool     `json:"is_active" example:"true"`
+	Secret      string   `json:"secret,omitempty" example:"whsec_9f86d081884c7d659a2feaa0c55ad015..."`
+	CreatedAt   string   `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt   string   `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// WebhookListResponse lists webhook endpoints
+type WebhookListResponse struct {
+	Data []WebhookDTO `json:"data"`
+}
+
+// WebhookEventListResponse lists the event types a webhook can subscribe to
+type WebhookEventListResponse struct {
+	Data []string `json:"data" example:"product.created,app_user.registered,image.completed"`
+}
+
+// WebhookSecretResponse carries a newly generated webhook secret
+type WebhookSecretResponse struct {
+	Secret string `json:"secret" example:"whsec_9f86d081884c7d659a2feaa0c55ad015..."`
+}
+
+// WebhookAttemptDTO is a logged request of a webhook delivery
+type WebhookAttemptDTO struct {
+	Attempt        int     `json:"attempt" example:"1"`
+	ResponseStatus *int    `json:"response_status" example:"500"`
+	ResponseBody   *string `json:"response_body" example:"internal error"`
+	Error          *string `json:"error" example:"unexpected status 500"`
+	DurationMS     int     `json:"duration_ms" example:"142"`
+	CreatedAt      string  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// WebhookDeliveryDTO is an event delivery to a webhook endpoint. Payload and
+// log are only returned when fetching a single delivery.
+type WebhookDeliveryDTO struct {
+	ID             string              `json:"id" example:"uuid"`
+	EventID        string              `json:"event_id" example:"uuid"`
+	EventType      string              `json:"event_type" example:"product.created"`
+	Status         string              `json:"status" example:"pending"`
+	Attempts       int                 `json:"attempts" example:"1"`
+	NextAttemptAt  *string             `json:"next_attempt_at,omitempty" example:"2024-01-01T00:00:30Z"`
+	LastError      *string             `json:"last_error" example:"unexpected status 500"`
+	ResponseStatus *int                `json:"response_status" example:"500"`
+	DeliveredAt    *string             `json:"delivered_at" example:"2024-01-01T00:00:00Z"`
+	CreatedAt      string              `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	Payload        interface{}         `json:"payload,omitempty"`
+	Log            []WebhookAttemptDTO `json:"log,omitempty"`
+}
+
+// WebhookDeliveryListResponse is a page of webhook deliveries
+type WebhookDeliveryListResponse struct {
+	Data     []WebhookDeliveryDTO `json:"data"`
+	Total    int                  `json:"total" example:"42"`
+	Page     int                  `json:"page" example:"1"`
+	PageSize int                  `json:"page_size" example:"20"`
 }
 
 // UserRoleResponse represents a role
@@ -476,33 +710,76 @@ type SettingResponse struct {
 
 // ImageResponse represents an uploaded image (single-row with all variants)
 type ImageResponse struct {
-	ID               string      `json:"id" example:"uuid"`
-	Title            *string     `json:"title" example:"Product photo"`
-	AltText          *string     `json:"alt_text" example:"Photo of product"`
-	Translations     interface{} `json:"translations"`
-	OriginalFilename string      `json:"original_filename" example:"photo.jpg"`
-	MimeType         string      `json:"mime_type" example:"image/jpeg"`
-	Extension        string      `json:"extension" example:"jpg"`
-	Width            *int        `json:"width" example:"1920"`
-	Height           *int        `json:"height" example:"1080"`
-	FileSize         *int64      `json:"file_size" example:"204800"`
-	OriginalURL      *string     `json:"original_url" example:"http://localhost:8080/uploads/tenant/image.jpg"`
-	OriginalPath     string      `json:"original_path" example:"tenant/image.jpg"`
-	MediumURL        *string     `json:"medium_url"`
-	MediumPath       *string     `json:"medium_path"`
-	SmallURL         *string     `json:"small_url"`
-	SmallPath        *string     `json:"small_path"`
-	ThumbURL         *string     `json:"thumb_url"`
-	ThumbPath        *string     `json:"thumb_path"`
-	ProcessingStatus string      `json:"processing_status" example:"completed"`
-	DisplayOrder     int         `json:"display_order" example:"0"`
-	CreatedAt        time.Time   `json:"created_at"`
-	UpdatedAt        time.Time   `json:"updated_at"`
+	ID               string                     `json:"id" example:"uuid"`
+	ImageableType    string                     `json:"imageable_type" example:"products"`
+	ImageableID      string                     `json:"imageable_id" example:"uuid"`
+	Title            *string                    `json:"title" example:"Product photo"`
+	AltText          *string                    `json:"alt_text" example:"Photo of product"`
+	Translations     interface{}                `json:"translations"`
+	OriginalFilename string                     `json:"original_filename" example:"photo.jpg"`
+	MimeType         string                     `json:"mime_type" example:"image/jpeg"`
+	Extension        string                     `json:"extension" example:"jpg"`
+	Width            *int                       `json:"width" example:"1920"`
+	Height           *int                       `json:"height" example:"1080"`
+	FileSize         *int64                     `json:"file_size" example:"204800"`
+	OriginalURL      *string                    `json:"original_url" example:"http://localhost:8080/uploads/tenant/image.jpg"`
+	OriginalPath     string                     `json:"original_path" example:"tenant/image.jpg"`
+	Variants         map[string]ImageVariantDTO `json:"variants"`
+	Visibility       string                     `json:"visibility" example:"public" enums:"public,private"`
+	BlurHash         *string                    `json:"blurhash" example:"LEHV6nWB2yk8pyo0adR*.7kCMdnj"`
+	DominantColor    *string                    `json:"dominant_color" example:"#7a8b9c"`
+	ProcessingStatus string                     `json:"processing_status" example:"completed"`
+	DisplayOrder     int                        `json:"display_order" example:"0"`
+	CreatedAt        time.Time                  `json:"created_at"`
+	UpdatedAt        time.Time                  `json:"updated_at"`
+}
+
+// ImageVariantDTO is a generated variant of an image, keyed by preset name
+type ImageVariantDTO struct {
+	Path   string `json:"path" example:"tenant/image_medium.webp"`
+	URL    string `json:"url" example:"http://localhost:8080/uploads/tenant/image_medium.webp"`
+	Width  int    `json:"width" example:"800"`
+	Height int    `json:"height" example:"450"`
+	Size   int64  `json:"size" example:"40960"`
 }
 
 // ImageUploadResponse represents the response from multi-image upload
 type ImageUploadResponse struct {
-	Images []ImageUploadItem `json:"images"`
+	Images   []ImageUploadItem     `json:"images"`
+	Rejected []ImageUploadRejected `json:"rejected"`
+}
+
+// DirectUploadRequest describes a file to upload straight to storage
+type DirectUploadRequest struct {
+	Filename    string `json:"filename" example:"photo.jpg" binding:"required"`
+	ContentType string `json:"content_type" example:"image/jpeg" enums:"image/jpeg,image/png,image/gif,image/webp" binding:"required"`
+	Size        int64  `json:"size" example:"2048000" binding:"required"`
+	Visibility  string `json:"visibility" example:"public" enums:"public,private"`
+}
+
+// DirectUploadResponse is where and how to send the file. Send exactly the
+// listed headers with the PUT.
+type DirectUploadResponse struct {
+	ImageID   string            `json:"image_id" example:"uuid"`
+	UploadURL string            `json:"upload_url" example:"https://bucket.s3.amazonaws.com/tenants/uuid/images/products/uuid/file.jpg?X-Amz-Signature=..."`
+	Method    string            `json:"method" example:"PUT"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// DirectUploadCompleteResponse confirms a direct upload was accepted and
+// queued for processing, or completed right away when the tenant already
+// had the same content
+type DirectUploadCompleteResponse struct {
+	Message          string `json:"message" example:"Upload completed"`
+	ImageID          string `json:"image_id" example:"uuid"`
+	ProcessingStatus string `json:"processing_status" example:"pending" enums:"pending,completed"`
+}
+
+// ImageUploadRejected is a file of a multi-image upload that failed validation
+type ImageUploadRejected struct {
+	Filename string `json:"filename" example:"photo.heic"`
+	Error    string `json:"error" example:"Unsupported file type"`
 }
 
 // ImageUploadItem represents a single uploaded image in the response
@@ -510,6 +787,71 @@ type ImageUploadItem struct {
 	ID          string `json:"id" example:"uuid"`
 	OriginalURL string `json:"original_url" example:"http://localhost:8080/uploads/tenant/image.jpg"`
 	Path        string `json:"path" example:"tenant/image.jpg"`
+	// ProcessingStatus is completed when the tenant already had the same
+	// content and the image shares its files
+	ProcessingStatus string `json:"processing_status" example:"pending" enums:"pending,completed"`
+}
+
+// ImageVariantPresetDTO is a variant generated for every image of the tenant
+type ImageVariantPresetDTO struct {
+	Name    string `json:"name" example:"medium"`
+	Width   int    `json:"width" example:"800"`
+	Height  int    `json:"height" example:"800"`
+	Mode    string `json:"mode" example:"fit" enums:"fit,fill,crop"`
+	Quality int    `json:"quality" example:"85"`
+}
+
+// ImageVariantLimitsDTO is what the tenant's plan allows
+type ImageVariantLimitsDTO struct {
+	MaxVariants int `json:"max_variants" example:"3"`
+	MaxSize     int `json:"max_size" example:"1600"`
+}
+
+// ImageVariantsResponse is the tenant's variant configuration
+type ImageVariantsResponse struct {
+	Variants  []ImageVariantPresetDTO `json:"variants"`
+	IsDefault bool                    `json:"is_default" example:"true"`
+	Limits    ImageVariantLimitsDTO   `json:"limits"`
+}
+
+// UpdateImageVariantsRequest replaces the tenant's variant presets
+type UpdateImageVariantsRequest struct {
+	Variants []ImageVariantPresetDTO `json:"variants"`
+}
+
+// ImageRerenderResponse is returned when images are queued for re-rendering
+type ImageRerenderResponse struct {
+	Message string `json:"message" example:"Images queued for re-rendering"`
+	Queued  int    `json:"queued" example:"42"`
+}
+
+// ReorderImagesRequest sets the display order of the images of a product or service
+type ReorderImagesRequest struct {
+	ImageIDs []string `json:"image_ids" binding:"required" example:"uuid1,uuid2"`
+}
+
+// UpdateImageVisibilityRequest makes an image public or private
+type UpdateImageVisibilityRequest struct {
+	Visibility string `json:"visibility" example:"private" enums:"public,private" binding:"required"`
+}
+
+// ImageEventResponse is the data of an event of the tenant's image event
+// stream. The SSE event name is the type and the SSE id is the id.
+type ImageEventResponse struct {
+	ID            int64          `json:"id" example:"1042"`
+	ImageID       string         `json:"image_id" example:"uuid"`
+	ImageableType string         `json:"imageable_type" example:"products"`
+	ImageableID   string         `json:"imageable_id" example:"uuid"`
+	Type          string         `json:"type" example:"completed" enums:"processing,completed,failed"`
+	Reason        *string        `json:"reason,omitempty" example:"failed to generate variant medium: unexpected EOF"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Image         *ImageResponse `json:"image,omitempty"`
+}
+
+// ImageReprocessResponse is returned when failed images are queued again
+type ImageReprocessResponse struct {
+	Message string `json:"message" example:"Processing queued"`
+	Queued  int    `json:"queued" example:"3"`
 }
 
 // ImageListResponse represents a list of images
@@ -530,6 +872,14 @@ type UploadResponse struct {
 	PublicURL string `json:"public_url" example:"http://localhost:8080/uploads/tenant/file.jpg"`
 }
 
+// ProfileImageResponse is an uploaded avatar or logo. The profile shows
+// the original until the square variants are ready.
+type ProfileImageResponse struct {
+	ImageID   string `json:"image_id" example:"uuid"`
+	Path      string `json:"path" example:"tenants/uuid/images/tenant_logo/uuid/file.png"`
+	PublicURL string `json:"public_url" example:"http://localhost:8080/uploads/tenants/uuid/images/tenant_logo/uuid/file.png"`
+}
+
 // CreateIDResponse is a response containing a created resource ID
 type CreateIDResponse struct {
 	ID string `json:"id" example:"uuid"`
@@ -572,6 +922,17 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"new_password" binding:"required,min=6" example:"new_password"`
 }
 
+// ForgotPasswordRequest is the request for backoffice forgot password
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// ResetPasswordRequest is the request for backoffice reset password
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required" example:"reset-token-string"`
+	NewPassword string `json:"new_password" binding:"required,min=6" example:"new_password"`
+}
+
 // SelectTenantRequest is the request for selecting a tenant
 type SelectTenantRequest struct {
 	TenantID string `json:"tenant_id" binding:"required" example:"uuid"`
@@ -591,9 +952,34 @@ type UpdateTenantProfileRequest struct {
 
 // InviteMemberRequest is the request for inviting a member
 type InviteMemberRequest struct {
-	Email  string  `json:"email" binding:"required,email" example:"member@example.com"`
-	Name   string  `json:"name" binding:"required" example:"Jane Doe"`
-	RoleID *string `json:"role_id" example:"uuid"`
+	Email string `json:"email" binding:"required,email" example:"member@example.com"`
+	Name  string `json:"name" binding:"required" example:"Jane Doe"`
+	Role  string `json:"role" binding:"required" example:"member"`
+}
+
+// StartOwnershipTransferRequest names the member who will receive the tenant
+type StartOwnershipTransferRequest struct {
+	UserID string `json:"user_id" binding:"required" example:"uuid"`
+}
+
+// AddDomainRequest registers a custom domain
+type AddDomainRequest struct {
+	Domain string `json:"domain" binding:"required" example:"shop.example.com"`
+}
+
+// CreateWebhookRequest registers a webhook endpoint
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required" example:"https://example.com/hooks/saas"`
+	Description *string  `json:"description" example:"Sincronização do ERP"`
+	Events      []string `json:"events" binding:"required" example:"product.created,product.updated"`
+}
+
+// UpdateWebhookRequest changes a webhook endpoint; omitted fields are kept
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url" example:"https://example.com/hooks/saas"`
+	Description *string  `json:"description" example:"Sincronização do ERP"`
+	Events      []string `json:"events" example:"product.created,product.updated"`
+	IsActive    *bool    `json:"is_active" example:"true"`
 }
 
 // UpdateMemberRoleRequest is the request for updating member role
@@ -738,8 +1124,9 @@ type AppUpdateProfileRequest struct {
 
 // AppAuthResponse is the response for app user auth operations
 type AppAuthResponse struct {
-	Token string     `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
-	User  AppUserDTO `json:"user"`
+	Token        string     `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string     `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015..."`
+	User         AppUserDTO `json:"user"`
 }
 
 // AppUserDTO is a safe app user representation
@@ -126,6 +126,13 @@ type UpdateTenantStatusRequest struct {
 	Status string `json:"status" binding:"required"`
 }
 
+// TransferOwnershipRequest makes a member the tenant owner without their
+// confirmation, for support cases
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
 // ChangePlanRequest is the request to change a tenant's plan
 type ChangePlanRequest struct {
 	PlanID       string  `json:"plan_id" binding:"required"`
@@ -191,6 +198,11 @@ type CreatePlanRequest struct {
 	IsMultilang  bool        `json:"is_multilang"`
 	FeatureIDs   []string    `json:"feature_ids"`
 	Translations interface{} `json:"translations"`
+	// Image variant and upload limits; zero uses the limits of the smallest plans
+	MaxImageVariants   int   `json:"max_image_variants"`
+	MaxImageSize       int   `json:"max_image_size"`
+	MaxUploadSize      int64 `json:"max_upload_size"`
+	MaxUploadDimension int   `json:"max_upload_dimension"`
 }
 
 // UpdatePlanRequest is the request to update a plan
@@ -202,6 +214,11 @@ type UpdatePlanRequest struct {
 	IsMultilang  *bool       `json:"is_multilang"`
 	IsActive     *bool       `json:"is_active"`
 	Translations interface{} `json:"translations"`
+	// Image variant and upload limits
+	MaxImageVariants   *int   `json:"max_image_variants"`
+	MaxImageSize       *int   `json:"max_image_size"`
+	MaxUploadSize      *int64 `json:"max_upload_size"`
+	MaxUploadDimension *int   `json:"max_upload_dimension"`
 }
 
 // CreateFeatureRequest is the request to create a feature
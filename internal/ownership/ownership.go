@@ -0,0 +1,66 @@
+package ownership
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Roles the previous owner keeps after a swap. A confirmed transfer leaves
+// them as an admin; an admin override, usually because the owner left,
+// leaves them with read access only.
+const (
+	PreviousOwnerAdmin  = "admin"
+	PreviousOwnerMember = "member"
+)
+
+// Swap moves is_owner from the current owner to newOwnerID, who must be an
+// active member, and gives the new owner the tenant's owner role. The
+// previous owner gets the tenant's previousRole, or no role when the tenant
+// has none by that slug, so they never keep the owner's permissions. The
+// owner's membership is locked so concurrent swaps serialize.
+//
+// It returns the previous owner's id, empty when the tenant had no owner.
+// It returns pgx.ErrNoRows when fromUserID is set and no longer owns the
+// tenant, or when newOwnerID is not a member.
+func Swap(ctx context.Context, tx pgx.Tx, tenantID, fromUserID, newOwnerID, previousRole string) (string, error) {
+	var previousOwnerID string
+	err := tx.QueryRow(ctx,
+		`SELECT user_id FROM tenant_members
+		 WHERE tenant_id = $1 AND is_owner AND deleted_at IS NULL
+		 FOR UPDATE`, tenantID,
+	).Scan(&previousOwnerID)
+	if err != nil && !(errors.Is(err, pgx.ErrNoRows) && fromUserID == "") {
+		return "", err
+	}
+	if fromUserID != "" && previousOwnerID != fromUserID {
+		return "", pgx.ErrNoRows
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE tenant_members
+		 SET is_owner = true, updated_at = NOW(),
+		     role_id = COALESCE((SELECT id FROM user_roles WHERE tenant_id = $1 AND slug = 'owner'), role_id)
+		 WHERE tenant_id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		tenantID, newOwnerID,
+	)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", pgx.ErrNoRows
+	}
+
+	if previousOwnerID == "" {
+		return "", nil
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE tenant_members
+		 SET is_owner = false, updated_at = NOW(),
+		     role_id = (SELECT id FROM user_roles WHERE tenant_id = $1 AND slug = $3)
+		 WHERE tenant_id = $1 AND user_id = $2`,
+		tenantID, previousOwnerID, previousRole,
+	)
+	return previousOwnerID, err
+}
@@ -0,0 +1,154 @@
+package placeholder
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// Components of the BlurHash computed for every image: enough detail for a
+// blurred preview, short enough to inline in any response
+const (
+	XComponents = 4
+	YComponents = 3
+)
+
+// SampleSize is the largest side an image should be scaled down to before
+// it is encoded. The hash only keeps the lowest frequencies, so a larger
+// sample costs time without changing the result.
+const SampleSize = 64
+
+var ErrInvalidComponents = errors.New("placeholder: components must be between 1 and 9")
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash encodes img as a BlurHash (https://blurha.sh) with the given
+// number of horizontal and vertical components
+func BlurHash(img image.Image, xComponents, yComponents int) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", ErrInvalidComponents
+	}
+	pixels := linearPixels(img)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return "", errors.New("placeholder: empty image")
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			factors = append(factors, basisFactor(pixels, width, height, i, j))
+		}
+	}
+	dc, ac := factors[0], factors[1:]
+
+	var hash strings.Builder
+	hash.WriteString(encode83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximum := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maximum = float64(quantisedMax+1) / 166
+		hash.WriteString(encode83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encode83(0, 1))
+	}
+
+	hash.WriteString(encode83(toSRGB(dc[0])<<16|toSRGB(dc[1])<<8|toSRGB(dc[2]), 4))
+	for _, f := range ac {
+		value := quantiseAC(f[0], maximum)*19*19 + quantiseAC(f[1], maximum)*19 + quantiseAC(f[2], maximum)
+		hash.WriteString(encode83(value, 2))
+	}
+	return hash.String(), nil
+}
+
+// AverageColor returns the average color of img as #rrggbb. Pixels are
+// averaged in linear light, as the eye blends them.
+func AverageColor(img image.Image) string {
+	pixels := linearPixels(img)
+	if len(pixels) == 0 {
+		return "#000000"
+	}
+	var sum [3]float64
+	for _, p := range pixels {
+		sum[0] += p[0]
+		sum[1] += p[1]
+		sum[2] += p[2]
+	}
+	n := float64(len(pixels))
+	return fmt.Sprintf("#%02x%02x%02x", toSRGB(sum[0]/n), toSRGB(sum[1]/n), toSRGB(sum[2]/n))
+}
+
+// linearPixels returns the pixels of img row by row as linear RGB. Fully
+// transparent pixels count as black, as they are stored.
+func linearPixels(img image.Image) [][3]float64 {
+	bounds := img.Bounds()
+	pixels := make([][3]float64, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels = append(pixels, [3]float64{toLinear(r >> 8), toLinear(g >> 8), toLinear(b >> 8)})
+		}
+	}
+	return pixels
+}
+
+func basisFactor(pixels [][3]float64, width, height, i, j int) [3]float64 {
+	var sum [3]float64
+	for y := 0; y < height; y++ {
+		cy := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+		for x := 0; x < width; x++ {
+			basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) * cy
+			p := pixels[y*width+x]
+			sum[0] += basis * p[0]
+			sum[1] += basis * p[1]
+			sum[2] += basis * p[2]
+		}
+	}
+	normalisation := 2.0
+	if i == 0 && j == 0 {
+		normalisation = 1
+	}
+	scale := normalisation / float64(width*height)
+	return [3]float64{sum[0] * scale, sum[1] * scale, sum[2] * scale}
+}
+
+func quantiseAC(v, maximum float64) int {
+	return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximum, 0.5)*9+9.5))))
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+func toLinear(c uint32) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func toSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func encode83(value, length int) string {
+	out := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		out[i-1] = base83[digit]
+	}
+	return string(out)
+}
@@ -0,0 +1,194 @@
+package profileimage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/saas-single-db-api/internal/imagequeue"
+	"github.com/saas-single-db-api/internal/imagevariant"
+	"github.com/saas-single-db-api/internal/storage"
+	"github.com/saas-single-db-api/internal/upload"
+)
+
+// Imageable types of profile pictures. A picture is an images row like any
+// product image, but an owner has at most one and its URL is mirrored into
+// the owner's profile.
+const (
+	UserAvatar    = "user_avatar"
+	AppUserAvatar = "app_user_avatar"
+	TenantLogo    = "tenant_logo"
+)
+
+// Presets are the square crops rendered for every profile picture, in place
+// of the tenant's presets
+var Presets = []imagevariant.Preset{
+	{Name: "large", Width: 512, Height: 512, Mode: imagevariant.ModeFill, Quality: imagevariant.DefaultQuality},
+	{Name: "medium", Width: 256, Height: 256, Mode: imagevariant.ModeFill, Quality: imagevariant.DefaultQuality},
+	{Name: "thumb", Width: 96, Height: 96, Mode: imagevariant.ModeFill, Quality: imagevariant.DefaultQuality},
+}
+
+// ProfileVariant is the variant whose URL is written to the profile once the
+// picture is processed; until then the profile shows the original
+const ProfileVariant = "large"
+
+// profileColumns updates the profile URL of each type, keyed by owner id
+var profileColumns = map[string]string{
+	UserAvatar:    `UPDATE user_profiles SET avatar_url = $1, updated_at = NOW() WHERE user_id = $2`,
+	AppUserAvatar: `UPDATE tenant_app_user_profiles SET avatar_url = $1, updated_at = NOW() WHERE app_user_id = $2`,
+	TenantLogo:    `UPDATE tenant_profiles SET logo_url = $1, updated_at = NOW() WHERE tenant_id = $2`,
+}
+
+// IsProfileType reports whether imageableType is a profile picture
+func IsProfileType(imageableType string) bool {
+	_, ok := profileColumns[imageableType]
+	return ok
+}
+
+// Service replaces profile pictures
+type Service struct {
+	db      *pgxpool.Pool
+	storage storage.Provider
+	driver  string
+	queue   *imagequeue.Queue
+}
+
+// NewService creates a profile picture service; driver is the name of the
+// storage provider, recorded on every image
+func NewService(db *pgxpool.Pool, st storage.Provider, driver string, queue *imagequeue.Queue) *Service {
+	return &Service{db: db, storage: st, driver: driver, queue: queue}
+}
+
+// Owner identifies whose picture is replaced. TenantID is empty for user
+// avatars, which belong to no tenant.
+type Owner struct {
+	Type     string
+	ID       string
+	TenantID string
+}
+
+// dir is where the owner's pictures are stored
+func (o Owner) dir() string {
+	if o.TenantID == "" {
+		return fmt.Sprintf("avatars/%s", o.ID)
+	}
+	return fmt.Sprintf("tenants/%s/images/%s/%s", o.TenantID, o.Type, o.ID)
+}
+
+// Picture is a newly stored profile picture
+type Picture struct {
+	ImageID string `json:"image_id"`
+	Path    string `json:"path"`
+	URL     string `json:"public_url"`
+}
+
+// Replace stores a validated upload as the owner's picture. The previous
+// picture's row is deleted along with its original and variants, the
+// profile points at the new original right away and the new image is
+// queued for its square variants.
+func (s *Service) Replace(ctx context.Context, owner Owner, file io.Reader, info *upload.Image, filename string) (*Picture, error) {
+	storagePath := info.StoragePath(owner.dir())
+	publicURL, err := s.storage.Put(ctx, storagePath, file, info.MimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Until the new row is committed the stored file belongs to nothing
+	committed := false
+	defer func() {
+		if !committed {
+			s.deleteFiles([]string{storagePath})
+		}
+	}()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize replacements of the same owner so concurrent uploads cannot
+	// both keep their picture
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text || ':' || $2::text))`, owner.Type, owner.ID); err != nil {
+		return nil, err
+	}
+
+	var imageID string
+	err = tx.QueryRow(ctx,
+		`INSERT INTO images (tenant_id, imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url, file_size, processing_status)
+		 VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, $6, $7, $8, $9, $10, 'pending') RETURNING id`,
+		owner.TenantID, owner.Type, owner.ID, filename, info.MimeType, info.Extension, s.driver, storagePath, publicURL, info.Size,
+	).Scan(&imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx,
+		`DELETE FROM images WHERE imageable_type = $1 AND imageable_id = $2 AND id <> $3
+		 RETURNING original_path, variants`,
+		owner.Type, owner.ID, imageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for rows.Next() {
+		var originalPath string
+		var variants map[string]imagevariant.Variant
+		if err := rows.Scan(&originalPath, &variants); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stale = append(stale, originalPath)
+		for _, v := range variants {
+			if v.Path != "" {
+				stale = append(stale, v.Path)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, profileColumns[owner.Type], publicURL, owner.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	s.deleteFiles(stale)
+
+	// If this fails the worker's sweep picks the image up later
+	if err := s.queue.Enqueue(ctx, imageID); err != nil {
+		log.Printf("Warning: failed to queue profile image %s: %v", imageID, err)
+	}
+	return &Picture{ImageID: imageID, Path: storagePath, URL: publicURL}, nil
+}
+
+// SetProfileURL points the owner's profile at url, as long as imageID is
+// still the owner's picture. The worker calls it once the variants exist.
+func SetProfileURL(ctx context.Context, db *pgxpool.Pool, imageableType, ownerID, imageID, url string) error {
+	query, ok := profileColumns[imageableType]
+	if !ok {
+		return nil
+	}
+	_, err := db.Exec(ctx,
+		query+` AND EXISTS (SELECT 1 FROM images WHERE id = $3 AND imageable_type = $4)`,
+		url, ownerID, imageID, imageableType,
+	)
+	return err
+}
+
+func (s *Service) deleteFiles(paths []string) {
+	for _, p := range paths {
+		if err := s.storage.Delete(p); err != nil {
+			log.Printf("Warning: failed to delete %s: %v", p, err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	models "github.com/saas-single-db-api/internal/models/admin"
+	"github.com/saas-single-db-api/internal/ownership"
 )
 
 type Repository struct {
@@ -171,7 +172,7 @@ func (r *Repository) CreateProfile(ctx context.Context, adminID, fullName string
 
 func (r *Repository) ListRoles(ctx context.Context) ([]models.SystemAdminRole, error) {
 	rows, err := r.db.Query(ctx,
-		`SELECT id, title, slug, description, created_at, updated_at FROM saas_admin_roles ORDER BY id`,
+		`SELECT id, title, slug, description, require_2fa, created_at, updated_at FROM saas_admin_roles ORDER BY id`,
 	)
 	if err != nil {
 		return nil, err
@@ -181,7 +182,7 @@ func (r *Repository) ListRoles(ctx context.Context) ([]models.SystemAdminRole, e
 	var roles []models.SystemAdminRole
 	for rows.Next() {
 		var role models.SystemAdminRole
-		if err := rows.Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		if err := rows.Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.Require2FA, &role.CreatedAt, &role.UpdatedAt); err != nil {
 			return nil, err
 		}
 		roles = append(roles, role)
@@ -192,21 +193,21 @@ func (r *Repository) ListRoles(ctx context.Context) ([]models.SystemAdminRole, e
 func (r *Repository) GetRoleByID(ctx context.Context, id int) (*models.SystemAdminRole, error) {
 	var role models.SystemAdminRole
 	err := r.db.QueryRow(ctx,
-		`SELECT id, title, slug, description, created_at, updated_at FROM saas_admin_roles WHERE id = $1`, id,
-	).Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.CreatedAt, &role.UpdatedAt)
+		`SELECT id, title, slug, description, require_2fa, created_at, updated_at FROM saas_admin_roles WHERE id = $1`, id,
+	).Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.Require2FA, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &role, nil
 }
 
-func (r *Repository) CreateRole(ctx context.Context, title, slug string, description *string) (*models.SystemAdminRole, error) {
+func (r *Repository) CreateRole(ctx context.Context, title, slug string, description *string, require2FA bool) (*models.SystemAdminRole, error) {
 	var role models.SystemAdminRole
 	err := r.db.QueryRow(ctx,
-		`INSERT INTO saas_admin_roles (title, slug, description) VALUES ($1, $2, $3)
-		 RETURNING id, title, slug, description, created_at, updated_at`,
-		title, slug, description,
-	).Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.CreatedAt, &role.UpdatedAt)
+		`INSERT INTO saas_admin_roles (title, slug, description, require_2fa) VALUES ($1, $2, $3, $4)
+		 RETURNING id, title, slug, description, require_2fa, created_at, updated_at`,
+		title, slug, description, require2FA,
+	).Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.Require2FA, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +229,11 @@ func (r *Repository) UpdateRole(ctx context.Context, id int, req *models.UpdateR
 		args = append(args, *req.Description)
 		argIdx++
 	}
+	if req.Require2FA != nil {
+		query += fmt.Sprintf(", require_2fa = $%d", argIdx)
+		args = append(args, *req.Require2FA)
+		argIdx++
+	}
 
 	query += fmt.Sprintf(" WHERE id = $%d", argIdx)
 	args = append(args, id)
@@ -256,9 +262,30 @@ func (r *Repository) RemoveRoleFromAdmin(ctx context.Context, adminID string, ro
 	return err
 }
 
+// GetRoleAdminIDs returns the ids of the admins holding a role
+func (r *Repository) GetRoleAdminIDs(ctx context.Context, roleID int) ([]string, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT admin_user_id FROM saas_admin_user_roles WHERE admin_role_id = $1`, roleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 func (r *Repository) GetAdminRoles(ctx context.Context, adminID string) ([]models.SystemAdminRole, error) {
 	rows, err := r.db.Query(ctx,
-		`SELECT r.id, r.title, r.slug, r.description, r.created_at, r.updated_at
+		`SELECT r.id, r.title, r.slug, r.description, r.require_2fa, r.created_at, r.updated_at
 		 FROM saas_admin_roles r
 		 JOIN saas_admin_user_roles ur ON ur.admin_role_id = r.id
 		 WHERE ur.admin_user_id = $1`, adminID,
@@ -271,7 +298,7 @@ func (r *Repository) GetAdminRoles(ctx context.Context, adminID string) ([]model
 	var roles []models.SystemAdminRole
 	for rows.Next() {
 		var role models.SystemAdminRole
-		if err := rows.Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		if err := rows.Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.Require2FA, &role.CreatedAt, &role.UpdatedAt); err != nil {
 			return nil, err
 		}
 		roles = append(roles, role)
@@ -279,6 +306,19 @@ func (r *Repository) GetAdminRoles(ctx context.Context, adminID string) ([]model
 	return roles, nil
 }
 
+// AdminRequires2FA reports whether any role assigned to the admin requires 2FA
+func (r *Repository) AdminRequires2FA(ctx context.Context, adminID string) bool {
+	var required bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(
+		   SELECT 1 FROM saas_admin_user_roles ur
+		   JOIN saas_admin_roles r ON r.id = ur.admin_role_id
+		   WHERE ur.admin_user_id = $1 AND r.require_2fa
+		 )`, adminID,
+	).Scan(&required)
+	return err == nil && required
+}
+
 // --- Permissions ---
 
 func (r *Repository) ListPermissions(ctx context.Context) ([]models.SystemAdminPermission, error) {
@@ -327,8 +367,8 @@ func (r *Repository) GetAdminPermissions(ctx context.Context, adminID string) ([
 func (r *Repository) GetRoleBySlug(ctx context.Context, slug string) (*models.SystemAdminRole, error) {
 	var role models.SystemAdminRole
 	err := r.db.QueryRow(ctx,
-		`SELECT id, title, slug, description, created_at, updated_at FROM saas_admin_roles WHERE slug = $1`, slug,
-	).Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.CreatedAt, &role.UpdatedAt)
+		`SELECT id, title, slug, description, require_2fa, created_at, updated_at FROM saas_admin_roles WHERE slug = $1`, slug,
+	).Scan(&role.ID, &role.Title, &role.Slug, &role.Description, &role.Require2FA, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -486,6 +526,47 @@ func (r *Repository) GetTenantMembers(ctx context.Context, tenantID string) ([]m
 	return members, nil
 }
 
+// TransferTenantOwnership makes newOwnerID, an active member, the tenant
+// owner, demotes the previous owner to member, cancels any open ownership
+// transfer and records the change with the admin who made it. It returns pgx.ErrNoRows when newOwnerID is not a member.
+func (r *Repository) TransferTenantOwnership(ctx context.Context, tenantID, newOwnerID, adminID, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// The owner is replaced because they cannot act, often because they
+	// left, so they keep read access only
+	previous, err := ownership.Swap(ctx, tx, tenantID, "", newOwnerID, ownership.PreviousOwnerMember)
+	if err != nil {
+		return err
+	}
+	var previousOwnerID *string
+	if previous != "" {
+		previousOwnerID = &previous
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE ownership_transfers SET cancelled_at = NOW(), updated_at = NOW()
+		 WHERE tenant_id = $1 AND confirmed_at IS NULL AND cancelled_at IS NULL`, tenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO tenant_ownership_audit (tenant_id, previous_owner_id, new_owner_id, admin_id, reason)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		tenantID, previousOwnerID, newOwnerID, adminID, reason,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 type memberRow struct {
 	UserID    string
 	Name      string
@@ -503,7 +584,8 @@ type memberRow struct {
 
 func (r *Repository) ListPlans(ctx context.Context) ([]planWithFeatures, error) {
 	rows, err := r.db.Query(ctx,
-		`SELECT p.id, p.name, p.description, p.translations, p.plan_type, p.price, p.max_users, p.is_multilang, p.is_active,
+		`SELECT p.id, p.name, p.description, p.translations, p.plan_type, p.price, p.max_users,
+		        p.max_image_variants, p.max_image_size, p.max_upload_size, p.max_upload_dimension, p.is_multilang, p.is_active,
 		        p.created_at, p.updated_at
 		 FROM saas_plans p ORDER BY p.price`,
 	)
@@ -516,7 +598,7 @@ func (r *Repository) ListPlans(ctx context.Context) ([]planWithFeatures, error)
 	for rows.Next() {
 		var p planWithFeatures
 		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Translations, &p.PlanType, &p.Price, &p.MaxUsers,
-			&p.IsMultilang, &p.IsActive, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			&p.MaxImageVariants, &p.MaxImageSize, &p.MaxUploadSize, &p.MaxUploadDimension, &p.IsMultilang, &p.IsActive, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		plans = append(plans, p)
@@ -538,11 +620,16 @@ type planWithFeatures struct {
 	PlanType     string
 	Price        float64
 	MaxUsers     int
-	IsMultilang  bool
-	IsActive     bool
-	CreatedAt    interface{}
-	UpdatedAt    interface{}
-	Features     []featureRow
+	// Image variant and upload limits
+	MaxImageVariants   int
+	MaxImageSize       int
+	MaxUploadSize      int64
+	MaxUploadDimension int
+	IsMultilang        bool
+	IsActive           bool
+	CreatedAt          interface{}
+	UpdatedAt          interface{}
+	Features           []featureRow
 }
 
 type featureRow struct {
@@ -556,9 +643,11 @@ type featureRow struct {
 func (r *Repository) GetPlanByID(ctx context.Context, id string) (*planWithFeatures, error) {
 	var p planWithFeatures
 	err := r.db.QueryRow(ctx,
-		`SELECT id, name, description, translations, plan_type, price, max_users, is_multilang, is_active, created_at, updated_at
+		`SELECT id, name, description, translations, plan_type, price, max_users, max_image_variants, max_image_size,
+		        max_upload_size, max_upload_dimension, is_multilang, is_active, created_at, updated_at
 		 FROM saas_plans WHERE id = $1`, id,
-	).Scan(&p.ID, &p.Name, &p.Description, &p.Translations, &p.PlanType, &p.Price, &p.MaxUsers, &p.IsMultilang, &p.IsActive, &p.CreatedAt, &p.UpdatedAt)
+	).Scan(&p.ID, &p.Name, &p.Description, &p.Translations, &p.PlanType, &p.Price, &p.MaxUsers, &p.MaxImageVariants, &p.MaxImageSize,
+		&p.MaxUploadSize, &p.MaxUploadDimension, &p.IsMultilang, &p.IsActive, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -566,7 +655,7 @@ func (r *Repository) GetPlanByID(ctx context.Context, id string) (*planWithFeatu
 	return &p, nil
 }
 
-func (r *Repository) CreatePlan(ctx context.Context, name string, description *string, planType string, price float64, maxUsers int, isMultilang bool, translations interface{}) (string, error) {
+func (r *Repository) CreatePlan(ctx context.Context, name string, description *string, planType string, price float64, maxUsers, maxImageVariants, maxImageSize int, maxUploadSize int64, maxUploadDimension int, isMultilang bool, translations interface{}) (string, error) {
 	tJSON := "{}"
 	if translations != nil {
 		if b, err := json.Marshal(translations); err == nil {
@@ -575,13 +664,15 @@ func (r *Repository) CreatePlan(ctx context.Context, name string, description *s
 	}
 	var id string
 	err := r.db.QueryRow(ctx,
-		`INSERT INTO saas_plans (name, description, translations, plan_type, price, max_users, is_multilang) VALUES ($1, $2, $3::jsonb, $4::plan_type, $5, $6, $7) RETURNING id`,
-		name, description, tJSON, planType, price, maxUsers, isMultilang,
+		`INSERT INTO saas_plans (name, description, translations, plan_type, price, max_users, max_image_variants, max_image_size,
+		                         max_upload_size, max_upload_dimension, is_multilang)
+		 VALUES ($1, $2, $3::jsonb, $4::plan_type, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		name, description, tJSON, planType, price, maxUsers, maxImageVariants, maxImageSize, maxUploadSize, maxUploadDimension, isMultilang,
 	).Scan(&id)
 	return id, err
 }
 
-func (r *Repository) UpdatePlan(ctx context.Context, id string, name *string, description *string, price *float64, maxUsers *int, isMultilang *bool, isActive *bool, translations interface{}) error {
+func (r *Repository) UpdatePlan(ctx context.Context, id string, name *string, description *string, price *float64, maxUsers, maxImageVariants, maxImageSize *int, maxUploadSize *int64, maxUploadDimension *int, isMultilang *bool, isActive *bool, translations interface{}) error {
 	query := `UPDATE saas_plans SET updated_at = NOW()`
 	args := []interface{}{}
 	argIdx := 1
@@ -606,6 +697,26 @@ func (r *Repository) UpdatePlan(ctx context.Context, id string, name *string, de
 		args = append(args, *maxUsers)
 		argIdx++
 	}
+	if maxImageVariants != nil {
+		query += fmt.Sprintf(", max_image_variants = $%d", argIdx)
+		args = append(args, *maxImageVariants)
+		argIdx++
+	}
+	if maxImageSize != nil {
+		query += fmt.Sprintf(", max_image_size = $%d", argIdx)
+		args = append(args, *maxImageSize)
+		argIdx++
+	}
+	if maxUploadSize != nil {
+		query += fmt.Sprintf(", max_upload_size = $%d", argIdx)
+		args = append(args, *maxUploadSize)
+		argIdx++
+	}
+	if maxUploadDimension != nil {
+		query += fmt.Sprintf(", max_upload_dimension = $%d", argIdx)
+		args = append(args, *maxUploadDimension)
+		argIdx++
+	}
 	if isMultilang != nil {
 		query += fmt.Sprintf(", is_multilang = $%d", argIdx)
 		args = append(args, *isMultilang)
@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -106,14 +108,6 @@ func (r *Repository) UpdateAppUserProfile(ctx context.Context, appUserID string,
 	return err
 }
 
-func (r *Repository) UpdateAppUserProfileAvatar(ctx context.Context, appUserID, avatarURL string) error {
-	_, err := r.db.Exec(ctx,
-		`UPDATE tenant_app_user_profiles SET avatar_url = $1, updated_at = NOW() WHERE app_user_id = $2`,
-		avatarURL, appUserID,
-	)
-	return err
-}
-
 func (r *Repository) UpdateAppUserPassword(ctx context.Context, tenantID, userID, hashPass string) error {
 	_, err := r.db.Exec(ctx,
 		`UPDATE tenant_app_users SET hash_pass = $1, updated_at = NOW()
@@ -123,6 +117,82 @@ func (r *Repository) UpdateAppUserPassword(ctx context.Context, tenantID, userID
 	return err
 }
 
+func (r *Repository) GetTenantName(ctx context.Context, tenantID string) (string, error) {
+	var name string
+	err := r.db.QueryRow(ctx, `SELECT name FROM tenants WHERE id = $1`, tenantID).Scan(&name)
+	return name, err
+}
+
+// --- Password Reset ---
+
+// CreatePasswordResetToken stores a new reset token hash for the app user and
+// invalidates any reset tokens previously issued to them.
+func (r *Repository) CreatePasswordResetToken(ctx context.Context, tenantID, appUserID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE app_password_reset_tokens SET used_at = NOW()
+		 WHERE tenant_id = $1 AND app_user_id = $2 AND used_at IS NULL`,
+		tenantID, appUserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO app_password_reset_tokens (tenant_id, app_user_id, token_hash, expires_at)
+		 VALUES ($1, $2, $3, $4)`,
+		tenantID, appUserID, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ResetPasswordWithToken consumes a valid reset token and sets the new password
+// in a single transaction. Returns pgx.ErrNoRows when the token is unknown,
+// expired, already used or belongs to another tenant.
+func (r *Repository) ResetPasswordWithToken(ctx context.Context, tenantID, tokenHash, hashPass string) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var appUserID string
+	err = tx.QueryRow(ctx,
+		`UPDATE app_password_reset_tokens SET used_at = NOW()
+		 WHERE tenant_id = $1 AND token_hash = $2 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING app_user_id`,
+		tenantID, tokenHash,
+	).Scan(&appUserID)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE tenant_app_users SET hash_pass = $1, updated_at = NOW()
+		 WHERE tenant_id = $2 AND id = $3 AND status = 'active' AND deleted_at IS NULL`,
+		hashPass, tenantID, appUserID,
+	)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", pgx.ErrNoRows
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return appUserID, nil
+}
+
 // --- Catalog (Public) ---
 
 func (r *Repository) ListActiveProducts(ctx context.Context, tenantID string, limit, offset int) ([]interface{}, int64, error) {
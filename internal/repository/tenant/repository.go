@@ -2,11 +2,17 @@ package tenant
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/saas-single-db-api/internal/imagevariant"
+	"github.com/saas-single-db-api/internal/ownership"
+	"github.com/saas-single-db-api/internal/upload"
 )
 
 type Repository struct {
@@ -281,14 +287,6 @@ func (r *Repository) UpdateUserProfile(ctx context.Context, userID string, fullN
 	return err
 }
 
-func (r *Repository) UpdateUserProfileAvatar(ctx context.Context, userID, avatarURL string) error {
-	_, err := r.db.Exec(ctx,
-		`UPDATE user_profiles SET avatar_url = $1, updated_at = NOW() WHERE user_id = $2`,
-		avatarURL, userID,
-	)
-	return err
-}
-
 func (r *Repository) UpdateUserPassword(ctx context.Context, userID, hashPass string) error {
 	_, err := r.db.Exec(ctx,
 		`UPDATE users SET hash_pass = $1, updated_at = NOW() WHERE id = $2`, hashPass, userID,
@@ -497,13 +495,6 @@ func (r *Repository) UpdateTenantProfile(ctx context.Context, tenantID string, a
 	return err
 }
 
-func (r *Repository) UpdateTenantLogo(ctx context.Context, tenantID, logoURL string) error {
-	_, err := r.db.Exec(ctx,
-		`UPDATE tenant_profiles SET logo_url = $1, updated_at = NOW() WHERE tenant_id = $2`, logoURL, tenantID,
-	)
-	return err
-}
-
 // --- Members ---
 
 func (r *Repository) ListTenantMembers(ctx context.Context, tenantID string) ([]memberRow, error) {
@@ -587,6 +578,460 @@ func (r *Repository) RemoveMember(ctx context.Context, tenantID, userID string)
 	return err
 }
 
+// --- Member Invitations ---
+
+// An invitation is open until it is accepted or revoked; an open invitation
+// past expires_at can no longer be accepted but can be resent.
+
+type invitationRow struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	RoleID    *string   `json:"role_id"`
+	RoleTitle *string   `json:"role_title"`
+	RoleSlug  *string   `json:"role_slug"`
+	InvitedBy *string   `json:"invited_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// invitationTokenRow is an open, unexpired invitation looked up by its token
+type invitationTokenRow struct {
+	ID         string
+	TenantID   string
+	TenantName string
+	URLCode    string
+	Email      string
+	Name       string
+	RoleID     *string
+	RoleTitle  *string
+	ExpiresAt  time.Time
+}
+
+// CountPendingInvitations counts open invitations that have not expired
+func (r *Repository) CountPendingInvitations(ctx context.Context, tenantID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM member_invitations
+		 WHERE tenant_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`, tenantID,
+	).Scan(&count)
+	return count, err
+}
+
+// HasPendingInvitation reports whether the email has an open invitation that has not expired
+func (r *Repository) HasPendingInvitation(ctx context.Context, tenantID, email string) bool {
+	var exists bool
+	r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM member_invitations
+		 WHERE tenant_id = $1 AND LOWER(email) = LOWER($2)
+		   AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW())`,
+		tenantID, email,
+	).Scan(&exists)
+	return exists
+}
+
+// CreateInvitation stores a new invitation, revoking an expired open one for
+// the same email first
+func (r *Repository) CreateInvitation(ctx context.Context, tenantID, email, name string, roleID *string, invitedBy, tokenHash string, expiresAt time.Time) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE member_invitations SET revoked_at = NOW(), updated_at = NOW()
+		 WHERE tenant_id = $1 AND LOWER(email) = LOWER($2)
+		   AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= NOW()`,
+		tenantID, email,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = tx.QueryRow(ctx,
+		`INSERT INTO member_invitations (tenant_id, email, name, role_id, invited_by, token_hash, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		tenantID, email, name, roleID, invitedBy, tokenHash, expiresAt,
+	).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+const invitationColumns = `i.id, i.email, i.name, i.role_id, r.title, r.slug, i.invited_by, i.expires_at, i.expires_at <= NOW(), i.created_at`
+
+func scanInvitation(row pgx.Row) (*invitationRow, error) {
+	var inv invitationRow
+	err := row.Scan(&inv.ID, &inv.Email, &inv.Name, &inv.RoleID, &inv.RoleTitle, &inv.RoleSlug,
+		&inv.InvitedBy, &inv.ExpiresAt, &inv.Expired, &inv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ListInvitations returns the open invitations of a tenant, expired ones included
+func (r *Repository) ListInvitations(ctx context.Context, tenantID string) ([]invitationRow, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+invitationColumns+`
+		 FROM member_invitations i
+		 LEFT JOIN user_roles r ON r.id = i.role_id
+		 WHERE i.tenant_id = $1 AND i.accepted_at IS NULL AND i.revoked_at IS NULL
+		 ORDER BY i.created_at DESC`, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []invitationRow{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, nil
+}
+
+// GetInvitation returns an open invitation of the tenant
+func (r *Repository) GetInvitation(ctx context.Context, tenantID, invitationID string) (*invitationRow, error) {
+	return scanInvitation(r.db.QueryRow(ctx,
+		`SELECT `+invitationColumns+`
+		 FROM member_invitations i
+		 LEFT JOIN user_roles r ON r.id = i.role_id
+		 WHERE i.tenant_id = $1 AND i.id = $2 AND i.accepted_at IS NULL AND i.revoked_at IS NULL`,
+		tenantID, invitationID,
+	))
+}
+
+// RenewInvitation replaces the token of an open invitation and extends its expiry
+func (r *Repository) RenewInvitation(ctx context.Context, tenantID, invitationID, tokenHash string, expiresAt time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE member_invitations SET token_hash = $3, expires_at = $4, updated_at = NOW()
+		 WHERE tenant_id = $1 AND id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`,
+		tenantID, invitationID, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeInvitation closes an open invitation
+func (r *Repository) RevokeInvitation(ctx context.Context, tenantID, invitationID string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE member_invitations SET revoked_at = NOW(), updated_at = NOW()
+		 WHERE tenant_id = $1 AND id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`,
+		tenantID, invitationID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetInvitationByToken looks up an open, unexpired invitation by token hash
+func (r *Repository) GetInvitationByToken(ctx context.Context, tokenHash string) (*invitationTokenRow, error) {
+	var inv invitationTokenRow
+	err := r.db.QueryRow(ctx,
+		`SELECT i.id, i.tenant_id, t.name, t.url_code, i.email, i.name, i.role_id, r.title, i.expires_at
+		 FROM member_invitations i
+		 JOIN tenants t ON t.id = i.tenant_id AND t.deleted_at IS NULL
+		 LEFT JOIN user_roles r ON r.id = i.role_id
+		 WHERE i.token_hash = $1 AND i.accepted_at IS NULL AND i.revoked_at IS NULL AND i.expires_at > NOW()`,
+		tokenHash,
+	).Scan(&inv.ID, &inv.TenantID, &inv.TenantName, &inv.URLCode, &inv.Email, &inv.Name, &inv.RoleID, &inv.RoleTitle, &inv.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ClaimInvitation marks an open, unexpired invitation accepted. It reports
+// false when the invitation was accepted, revoked or renewed concurrently.
+func (r *Repository) ClaimInvitation(ctx context.Context, tx pgx.Tx, invitationID, tokenHash string) (bool, error) {
+	tag, err := tx.Exec(ctx,
+		`UPDATE member_invitations SET accepted_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND token_hash = $2 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`,
+		invitationID, tokenHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// AddTenantMember adds a non-owner member, restoring a previously removed membership
+func (r *Repository) AddTenantMember(ctx context.Context, tx pgx.Tx, userID, tenantID string, roleID *string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO tenant_members (user_id, tenant_id, role_id, is_owner)
+		 VALUES ($1, $2, $3, false)
+		 ON CONFLICT (user_id, tenant_id) DO UPDATE
+		 SET role_id = EXCLUDED.role_id, is_owner = false, deleted_at = NULL, created_at = NOW(), updated_at = NOW()
+		 WHERE tenant_members.deleted_at IS NOT NULL`,
+		userID, tenantID, roleID,
+	)
+	return err
+}
+
+// SetEmailVerifiedTx marks the email of a user created from an invitation as
+// verified, since receiving the invitation proved ownership of the address
+func (r *Repository) SetEmailVerifiedTx(ctx context.Context, tx pgx.Tx, userID string) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1`, userID,
+	)
+	return err
+}
+
+// --- Ownership Transfers ---
+
+// ownershipTransferRow is an open transfer. Expired transfers stay open until
+// they are cancelled or replaced by a new one.
+type ownershipTransferRow struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"-"`
+	FromUserID string    `json:"from_user_id"`
+	ToUserID   string    `json:"to_user_id"`
+	ToName     string    `json:"to_name"`
+	ToEmail    string    `json:"to_email"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Expired    bool      `json:"expired"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const ownershipTransferColumns = `o.id, o.tenant_id, o.from_user_id, o.to_user_id, u.name, u.email,
+		        o.expires_at, o.expires_at <= NOW(), o.created_at`
+
+func scanOwnershipTransfer(row pgx.Row) (*ownershipTransferRow, error) {
+	var t ownershipTransferRow
+	err := row.Scan(&t.ID, &t.TenantID, &t.FromUserID, &t.ToUserID, &t.ToName, &t.ToEmail,
+		&t.ExpiresAt, &t.Expired, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetOpenOwnershipTransfer returns the tenant's open transfer, expired or not
+func (r *Repository) GetOpenOwnershipTransfer(ctx context.Context, tenantID string) (*ownershipTransferRow, error) {
+	return scanOwnershipTransfer(r.db.QueryRow(ctx,
+		`SELECT `+ownershipTransferColumns+`
+		 FROM ownership_transfers o
+		 JOIN users u ON u.id = o.to_user_id
+		 WHERE o.tenant_id = $1 AND o.confirmed_at IS NULL AND o.cancelled_at IS NULL`,
+		tenantID,
+	))
+}
+
+// GetOwnershipTransferByToken looks up an open, unexpired transfer by token hash
+func (r *Repository) GetOwnershipTransferByToken(ctx context.Context, tokenHash string) (*ownershipTransferRow, error) {
+	return scanOwnershipTransfer(r.db.QueryRow(ctx,
+		`SELECT `+ownershipTransferColumns+`
+		 FROM ownership_transfers o
+		 JOIN users u ON u.id = o.to_user_id
+		 WHERE o.token_hash = $1 AND o.confirmed_at IS NULL AND o.cancelled_at IS NULL AND o.expires_at > NOW()`,
+		tokenHash,
+	))
+}
+
+// CreateOwnershipTransfer stores a new transfer, cancelling the tenant's
+// previous open transfer if there is one
+func (r *Repository) CreateOwnershipTransfer(ctx context.Context, tenantID, fromUserID, toUserID, tokenHash string, expiresAt time.Time) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE ownership_transfers SET cancelled_at = NOW(), updated_at = NOW()
+		 WHERE tenant_id = $1 AND confirmed_at IS NULL AND cancelled_at IS NULL`, tenantID,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = tx.QueryRow(ctx,
+		`INSERT INTO ownership_transfers (tenant_id, from_user_id, to_user_id, token_hash, expires_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		tenantID, fromUserID, toUserID, tokenHash, expiresAt,
+	).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// CancelOwnershipTransfers closes the tenant's open transfer, if any. It
+// reports whether a transfer was cancelled.
+func (r *Repository) CancelOwnershipTransfers(ctx context.Context, tx pgx.Tx, tenantID string) (bool, error) {
+	tag, err := tx.Exec(ctx,
+		`UPDATE ownership_transfers SET cancelled_at = NOW(), updated_at = NOW()
+		 WHERE tenant_id = $1 AND confirmed_at IS NULL AND cancelled_at IS NULL`, tenantID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClaimOwnershipTransfer marks an open, unexpired transfer confirmed. It
+// reports false when the transfer was cancelled or confirmed concurrently.
+func (r *Repository) ClaimOwnershipTransfer(ctx context.Context, tx pgx.Tx, transferID, tokenHash string) (bool, error) {
+	tag, err := tx.Exec(ctx,
+		`UPDATE ownership_transfers SET confirmed_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND token_hash = $2 AND confirmed_at IS NULL AND cancelled_at IS NULL AND expires_at > NOW()`,
+		transferID, tokenHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// SwapOwner makes newOwnerID the owner of the tenant in place of fromUserID,
+// who stays on as an admin; see ownership.Swap
+func (r *Repository) SwapOwner(ctx context.Context, tx pgx.Tx, tenantID, fromUserID, newOwnerID string) (string, error) {
+	return ownership.Swap(ctx, tx, tenantID, fromUserID, newOwnerID, ownership.PreviousOwnerAdmin)
+}
+
+// CreateOwnershipAudit records a change of owner. transferID is set for a
+// confirmed transfer, adminID for an admin override.
+func (r *Repository) CreateOwnershipAudit(ctx context.Context, tx pgx.Tx, tenantID, previousOwnerID, newOwnerID string, transferID, adminID, reason *string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO tenant_ownership_audit (tenant_id, previous_owner_id, new_owner_id, transfer_id, admin_id, reason)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		tenantID, previousOwnerID, newOwnerID, transferID, adminID, reason,
+	)
+	return err
+}
+
+// --- Domains ---
+
+// DomainRow is a custom domain of a tenant
+type DomainRow struct {
+	ID                string
+	Domain            string
+	VerificationToken string
+	VerifiedAt        *time.Time
+	LastCheckedAt     *time.Time
+	CreatedAt         time.Time
+}
+
+const domainColumns = `id, domain, verification_token, verified_at, last_checked_at, created_at`
+
+func scanDomain(row pgx.Row) (*DomainRow, error) {
+	var d DomainRow
+	if err := row.Scan(&d.ID, &d.Domain, &d.VerificationToken, &d.VerifiedAt, &d.LastCheckedAt, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *Repository) ListTenantDomains(ctx context.Context, tenantID string) ([]DomainRow, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+domainColumns+` FROM tenant_domains WHERE tenant_id = $1 ORDER BY created_at`, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []DomainRow{}
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, *d)
+	}
+	return domains, nil
+}
+
+func (r *Repository) GetTenantDomain(ctx context.Context, tenantID, domainID string) (*DomainRow, error) {
+	return scanDomain(r.db.QueryRow(ctx,
+		`SELECT `+domainColumns+` FROM tenant_domains WHERE tenant_id = $1 AND id = $2`, tenantID, domainID,
+	))
+}
+
+func (r *Repository) CountTenantDomains(ctx context.Context, tenantID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM tenant_domains WHERE tenant_id = $1`, tenantID,
+	).Scan(&count)
+	return count, err
+}
+
+// IsDomainVerifiedByOther reports whether another tenant already serves domain
+func (r *Repository) IsDomainVerifiedByOther(ctx context.Context, tenantID, domain string) bool {
+	var exists bool
+	r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM tenant_domains
+		 WHERE domain = $1 AND tenant_id <> $2 AND verified_at IS NOT NULL)`,
+		domain, tenantID,
+	).Scan(&exists)
+	return exists
+}
+
+// CreateTenantDomain adds an unverified domain. It returns pgx.ErrNoRows when
+// the tenant already has the domain.
+func (r *Repository) CreateTenantDomain(ctx context.Context, tenantID, domain, token string) (*DomainRow, error) {
+	return scanDomain(r.db.QueryRow(ctx,
+		`INSERT INTO tenant_domains (tenant_id, domain, verification_token)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (tenant_id, domain) DO NOTHING
+		 RETURNING `+domainColumns,
+		tenantID, domain, token,
+	))
+}
+
+// RecordDomainCheck stores the outcome of a DNS check. A domain stays
+// verified once verified.
+func (r *Repository) RecordDomainCheck(ctx context.Context, tenantID, domainID string, verified bool) (*DomainRow, error) {
+	return scanDomain(r.db.QueryRow(ctx,
+		`UPDATE tenant_domains
+		 SET last_checked_at = NOW(), updated_at = NOW(),
+		     verified_at = CASE WHEN $3 THEN COALESCE(verified_at, NOW()) ELSE verified_at END
+		 WHERE tenant_id = $1 AND id = $2
+		 RETURNING `+domainColumns,
+		tenantID, domainID, verified,
+	))
+}
+
+func (r *Repository) DeleteTenantDomain(ctx context.Context, tenantID, domainID string) error {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM tenant_domains WHERE tenant_id = $1 AND id = $2`, tenantID, domainID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 // --- Roles ---
 
 func (r *Repository) ListTenantRoles(ctx context.Context, tenantID string) ([]roleRow, error) {
@@ -734,10 +1179,20 @@ func (r *Repository) RemovePermissionFromRole(ctx context.Context, roleID, permi
 
 // imageURLs holds the primary image URLs for a product or service.
 type imageURLs struct {
-	Original  *string `json:"original"`
-	Medium    *string `json:"medium"`
-	Small     *string `json:"small"`
-	Thumbnail *string `json:"thumbnail"`
+	Original *string           `json:"original"`
+	Variants map[string]string `json:"variants"`
+}
+
+// newImageURLs builds the primary image URLs, or nil when there is no image
+func newImageURLs(original *string, variants map[string]imagevariant.Variant) *imageURLs {
+	if original == nil && len(variants) == 0 {
+		return nil
+	}
+	urls := &imageURLs{Original: original, Variants: make(map[string]string, len(variants))}
+	for name, v := range variants {
+		urls.Variants[name] = v.URL
+	}
+	return urls
 }
 
 func (r *Repository) ListProducts(ctx context.Context, tenantID string, limit, offset int) ([]interface{}, int64, error) {
@@ -748,12 +1203,12 @@ func (r *Repository) ListProducts(ctx context.Context, tenantID string, limit, o
 
 	rows, err := r.db.Query(ctx,
 		`SELECT p.id, p.name, p.description, p.price, p.sku, p.stock, p.is_active, p.translations, p.created_at, p.updated_at,
-		        img.original_url, img.medium_url, img.small_url, img.thumb_url
+		        img.original_url, img.variants
 		 FROM products p
 		 LEFT JOIN LATERAL (
-		     SELECT original_url, medium_url, small_url, thumb_url
+		     SELECT original_url, variants
 		     FROM images
-		     WHERE imageable_type = 'products' AND imageable_id = p.id
+		     WHERE imageable_type = 'products' AND imageable_id = p.id AND visibility = 'public'
 		     ORDER BY display_order ASC, created_at ASC
 		     LIMIT 1
 		 ) img ON true
@@ -780,14 +1235,13 @@ func (r *Repository) ListProducts(ctx context.Context, tenantID string, limit, o
 			UpdatedAt    interface{} `json:"updated_at"`
 			Images       *imageURLs  `json:"images"`
 		}
-		var origURL, medURL, smlURL, thmURL *string
+		var origURL *string
+		var variants map[string]imagevariant.Variant
 		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.SKU, &p.Stock, &p.IsActive, &p.Translations, &p.CreatedAt, &p.UpdatedAt,
-			&origURL, &medURL, &smlURL, &thmURL); err != nil {
+			&origURL, &variants); err != nil {
 			return nil, 0, err
 		}
-		if origURL != nil || medURL != nil || smlURL != nil || thmURL != nil {
-			p.Images = &imageURLs{Original: origURL, Medium: medURL, Small: smlURL, Thumbnail: thmURL}
-		}
+		p.Images = newImageURLs(origURL, variants)
 		products = append(products, p)
 	}
 	return products, total, nil
@@ -807,27 +1261,26 @@ func (r *Repository) GetProduct(ctx context.Context, tenantID, productID string)
 		UpdatedAt    interface{} `json:"updated_at"`
 		Images       *imageURLs  `json:"images"`
 	}
-	var origURL, medURL, smlURL, thmURL *string
+	var origURL *string
+	var variants map[string]imagevariant.Variant
 	err := r.db.QueryRow(ctx,
 		`SELECT p.id, p.name, p.description, p.price, p.sku, p.stock, p.is_active, p.translations, p.created_at, p.updated_at,
-		        img.original_url, img.medium_url, img.small_url, img.thumb_url
+		        img.original_url, img.variants
 		 FROM products p
 		 LEFT JOIN LATERAL (
-		     SELECT original_url, medium_url, small_url, thumb_url
+		     SELECT original_url, variants
 		     FROM images
-		     WHERE imageable_type = 'products' AND imageable_id = p.id
+		     WHERE imageable_type = 'products' AND imageable_id = p.id AND visibility = 'public'
 		     ORDER BY display_order ASC, created_at ASC
 		     LIMIT 1
 		 ) img ON true
 		 WHERE p.tenant_id = $1 AND p.id = $2`, tenantID, productID,
 	).Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.SKU, &p.Stock, &p.IsActive, &p.Translations, &p.CreatedAt, &p.UpdatedAt,
-		&origURL, &medURL, &smlURL, &thmURL)
+		&origURL, &variants)
 	if err != nil {
 		return nil, err
 	}
-	if origURL != nil || medURL != nil || smlURL != nil || thmURL != nil {
-		p.Images = &imageURLs{Original: origURL, Medium: medURL, Small: smlURL, Thumbnail: thmURL}
-	}
+	p.Images = newImageURLs(origURL, variants)
 	return p, nil
 }
 
@@ -905,12 +1358,12 @@ func (r *Repository) ListServices(ctx context.Context, tenantID string, limit, o
 
 	rows, err := r.db.Query(ctx,
 		`SELECT s.id, s.name, s.description, s.price, s.duration, s.is_active, s.translations, s.created_at, s.updated_at,
-		        img.original_url, img.medium_url, img.small_url, img.thumb_url
+		        img.original_url, img.variants
 		 FROM services s
 		 LEFT JOIN LATERAL (
-		     SELECT original_url, medium_url, small_url, thumb_url
+		     SELECT original_url, variants
 		     FROM images
-		     WHERE imageable_type = 'services' AND imageable_id = s.id
+		     WHERE imageable_type = 'services' AND imageable_id = s.id AND visibility = 'public'
 		     ORDER BY display_order ASC, created_at ASC
 		     LIMIT 1
 		 ) img ON true
@@ -936,14 +1389,13 @@ func (r *Repository) ListServices(ctx context.Context, tenantID string, limit, o
 			UpdatedAt    interface{} `json:"updated_at"`
 			Images       *imageURLs  `json:"images"`
 		}
-		var origURL, medURL, smlURL, thmURL *string
+		var origURL *string
+		var variants map[string]imagevariant.Variant
 		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.Price, &s.Duration, &s.IsActive, &s.Translations, &s.CreatedAt, &s.UpdatedAt,
-			&origURL, &medURL, &smlURL, &thmURL); err != nil {
+			&origURL, &variants); err != nil {
 			return nil, 0, err
 		}
-		if origURL != nil || medURL != nil || smlURL != nil || thmURL != nil {
-			s.Images = &imageURLs{Original: origURL, Medium: medURL, Small: smlURL, Thumbnail: thmURL}
-		}
+		s.Images = newImageURLs(origURL, variants)
 		services = append(services, s)
 	}
 	return services, total, nil
@@ -962,27 +1414,26 @@ func (r *Repository) GetService(ctx context.Context, tenantID, serviceID string)
 		UpdatedAt    interface{} `json:"updated_at"`
 		Images       *imageURLs  `json:"images"`
 	}
-	var origURL, medURL, smlURL, thmURL *string
+	var origURL *string
+	var variants map[string]imagevariant.Variant
 	err := r.db.QueryRow(ctx,
 		`SELECT s.id, s.name, s.description, s.price, s.duration, s.is_active, s.translations, s.created_at, s.updated_at,
-		        img.original_url, img.medium_url, img.small_url, img.thumb_url
+		        img.original_url, img.variants
 		 FROM services s
 		 LEFT JOIN LATERAL (
-		     SELECT original_url, medium_url, small_url, thumb_url
+		     SELECT original_url, variants
 		     FROM images
-		     WHERE imageable_type = 'services' AND imageable_id = s.id
+		     WHERE imageable_type = 'services' AND imageable_id = s.id AND visibility = 'public'
 		     ORDER BY display_order ASC, created_at ASC
 		     LIMIT 1
 		 ) img ON true
 		 WHERE s.tenant_id = $1 AND s.id = $2`, tenantID, serviceID,
 	).Scan(&s.ID, &s.Name, &s.Description, &s.Price, &s.Duration, &s.IsActive, &s.Translations, &s.CreatedAt, &s.UpdatedAt,
-		&origURL, &medURL, &smlURL, &thmURL)
+		&origURL, &variants)
 	if err != nil {
 		return nil, err
 	}
-	if origURL != nil || medURL != nil || smlURL != nil || thmURL != nil {
-		s.Images = &imageURLs{Original: origURL, Medium: medURL, Small: smlURL, Thumbnail: thmURL}
-	}
+	s.Images = newImageURLs(origURL, variants)
 	return s, nil
 }
 
@@ -1118,24 +1569,294 @@ func (r *Repository) GetConvertWebp(ctx context.Context, tenantID string) (bool,
 	return convertWebp, nil
 }
 
+// GetImageVariantPresets returns the tenant's variant presets, or nil when
+// it uses the defaults
+func (r *Repository) GetImageVariantPresets(ctx context.Context, tenantID string) ([]imagevariant.Preset, error) {
+	var presets []imagevariant.Preset
+	err := r.db.QueryRow(ctx,
+		`SELECT image_variants FROM tenant_settings WHERE tenant_id = $1`, tenantID,
+	).Scan(&presets)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return presets, err
+}
+
+// SaveImageVariantPresets stores the tenant's variant presets; a nil presets
+// value goes back to the defaults
+func (r *Repository) SaveImageVariantPresets(ctx context.Context, tenantID string, presets interface{}) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO tenant_settings (tenant_id, image_variants)
+		 VALUES ($1, $2::jsonb)
+		 ON CONFLICT (tenant_id) DO UPDATE SET
+		   image_variants = $2::jsonb,
+		   updated_at = NOW()`,
+		tenantID, presets,
+	)
+	return err
+}
+
+// GetImageLimits returns the image variant limits of the tenant's active plan
+func (r *Repository) GetImageLimits(ctx context.Context, tenantID string) (imagevariant.Limits, error) {
+	var l imagevariant.Limits
+	err := r.db.QueryRow(ctx,
+		`SELECT pl.max_image_variants, pl.max_image_size
+		 FROM tenant_plans tp
+		 JOIN saas_plans pl ON pl.id = tp.plan_id
+		 WHERE tp.tenant_id = $1 AND tp.is_active = true
+		 LIMIT 1`, tenantID,
+	).Scan(&l.MaxVariants, &l.MaxSize)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return imagevariant.DefaultLimits, nil
+	}
+	return l, err
+}
+
+// GetUploadLimits returns the upload size limits of the tenant's active plan
+func (r *Repository) GetUploadLimits(ctx context.Context, tenantID string) (upload.Limits, error) {
+	var l upload.Limits
+	err := r.db.QueryRow(ctx,
+		`SELECT pl.max_upload_size, pl.max_upload_dimension
+		 FROM tenant_plans tp
+		 JOIN saas_plans pl ON pl.id = tp.plan_id
+		 WHERE tp.tenant_id = $1 AND tp.is_active = true
+		 LIMIT 1`, tenantID,
+	).Scan(&l.MaxBytes, &l.MaxDimension)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return upload.DefaultLimits, nil
+	}
+	return l, err
+}
+
 // --- Images (Polymorphic) ---
 
-func (r *Repository) CreateImageRecord(ctx context.Context, tenantID, imageableType, imageableID, originalFilename, mimeType, extension, storageDriver, originalPath, originalURL string, fileSize int64, title *string) (string, error) {
+func (r *Repository) CreateImageRecord(ctx context.Context, tenantID, imageableType, imageableID, originalFilename, mimeType, extension, storageDriver, originalPath, originalURL string, fileSize int64, title *string, visibility, contentHash string) (string, error) {
 	var id string
 	err := r.db.QueryRow(ctx,
-		`INSERT INTO images (tenant_id, imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url, file_size, title, processing_status)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 'pending') RETURNING id`,
-		tenantID, imageableType, imageableID, originalFilename, mimeType, extension, storageDriver, originalPath, originalURL, fileSize, title,
+		`INSERT INTO images (tenant_id, imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url, file_size, title, visibility, content_hash, processing_status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 'pending') RETURNING id`,
+		tenantID, imageableType, imageableID, originalFilename, mimeType, extension, storageDriver, originalPath, originalURL, fileSize, title, visibility, contentHash,
 	).Scan(&id)
 	return id, err
 }
 
-func (r *Repository) ListImages(ctx context.Context, tenantID, imageableType, imageableID string) ([]interface{}, error) {
+// Images of a tenant with the same content and visibility share their
+// stored files: the first upload is processed, later ones copy its row.
+// Files are deleted with the last image using them, so every change to who
+// uses a file runs under lockImageFiles.
+
+// lockImageFiles serializes changes to the images using the files of
+// originalPath until the transaction ends
+func lockImageFiles(ctx context.Context, tx pgx.Tx, originalPath string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, originalPath)
+	return err
+}
+
+// imageFilesUsed reports whether any image still uses the files of
+// originalPath
+func imageFilesUsed(ctx context.Context, tx pgx.Tx, originalPath string) (bool, error) {
+	var used bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM images WHERE original_path = $1)`, originalPath).Scan(&used)
+	return used, err
+}
+
+// lockDuplicate finds a processed image of the tenant with the given content
+// and visibility and locks its files. It returns pgx.ErrNoRows when there
+// is none.
+func lockDuplicate(ctx context.Context, tx pgx.Tx, tenantID, contentHash, visibility string) (id, originalPath string, err error) {
+	err = tx.QueryRow(ctx,
+		`SELECT id, original_path FROM images
+		 WHERE tenant_id = $1 AND content_hash = $2 AND visibility = $3 AND processing_status = 'completed'
+		 ORDER BY created_at LIMIT 1`,
+		tenantID, contentHash, visibility,
+	).Scan(&id, &originalPath)
+	if err != nil {
+		return "", "", err
+	}
+	return id, originalPath, lockImageFiles(ctx, tx, originalPath)
+}
+
+// CreateDuplicateImage records an upload the tenant already has as a
+// processed image, sharing that image's files and variants; the new image
+// is completed right away. It returns "" when there is no such image.
+func (r *Repository) CreateDuplicateImage(ctx context.Context, tenantID, imageableType, imageableID, originalFilename, contentHash, visibility string, title *string) (id, originalPath, originalURL string, err error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	sourceID, originalPath, err := lockDuplicate(ctx, tx, tenantID, contentHash, visibility)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", "", nil
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// The source is checked again under the lock; it may have been deleted
+	// or reprocessed since it was found
+	err = tx.QueryRow(ctx,
+		`INSERT INTO images (tenant_id, imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url,
+		                     file_size, width, height, variants, blurhash, dominant_color, title, visibility, content_hash, processing_status, processed_at)
+		 SELECT tenant_id, $3, $4, $5, mime_type, extension, storage_driver, original_path, original_url,
+		        file_size, width, height, variants, blurhash, dominant_color, $6, visibility, content_hash, 'completed', processed_at
+		 FROM images WHERE id = $1 AND original_path = $2 AND processing_status = 'completed'
+		 RETURNING id, COALESCE(original_url, '')`,
+		sourceID, originalPath, imageableType, imageableID, originalFilename, title,
+	).Scan(&id, &originalURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", "", nil
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+	return id, originalPath, originalURL, tx.Commit(ctx)
+}
+
+// CreateUploadingImage records an image whose file the client uploads
+// straight to storage. It stays 'uploading', untouched by the worker, until
+// CompleteImageUpload.
+func (r *Repository) CreateUploadingImage(ctx context.Context, tenantID, imageableType, imageableID, originalFilename, mimeType, extension, storageDriver, originalPath, originalURL string, fileSize int64, visibility string) (string, error) {
+	var id string
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO images (tenant_id, imageable_type, imageable_id, original_filename, mime_type, extension, storage_driver, original_path, original_url, file_size, visibility, processing_status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 'uploading') RETURNING id`,
+		tenantID, imageableType, imageableID, originalFilename, mimeType, extension, storageDriver, originalPath, originalURL, fileSize, visibility,
+	).Scan(&id)
+	return id, err
+}
+
+type uploadingImageRow struct {
+	OriginalPath     string
+	ProcessingStatus string
+}
+
+func (r *Repository) GetUploadingImage(ctx context.Context, tenantID, imageID string) (*uploadingImageRow, error) {
+	var img uploadingImageRow
+	err := r.db.QueryRow(ctx,
+		`SELECT original_path, processing_status FROM images WHERE tenant_id = $1 AND id = $2`, tenantID, imageID,
+	).Scan(&img.OriginalPath, &img.ProcessingStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// UploadPending reports whether an image still waits for its file at
+// originalPath, i.e. whether a direct upload to the path may be accepted
+func (r *Repository) UploadPending(ctx context.Context, originalPath string) (bool, error) {
+	var pending bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM images WHERE original_path = $1 AND processing_status = 'uploading')`,
+		originalPath,
+	).Scan(&pending)
+	return pending, err
+}
+
+// CompleteImageUpload moves an uploading image to pending with the type,
+// size and hash found in storage. It reports false when the image was not
+// uploading, e.g. because another call completed it first.
+func (r *Repository) CompleteImageUpload(ctx context.Context, tenantID, imageID, mimeType, extension string, fileSize int64, contentHash string) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE images SET processing_status = 'pending', mime_type = $3, extension = $4, file_size = $5, content_hash = $6, updated_at = NOW()
+		 WHERE tenant_id = $1 AND id = $2 AND processing_status = 'uploading'`,
+		tenantID, imageID, mimeType, extension, fileSize, contentHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CompleteDuplicateUpload completes an uploading image whose content the
+// tenant already has as a processed image, pointing it at that image's
+// files and variants; the uploaded file is no longer used. It reports false
+// when there is no such image or the image was not uploading.
+func (r *Repository) CompleteDuplicateUpload(ctx context.Context, tenantID, imageID, contentHash string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var visibility string
+	err = tx.QueryRow(ctx,
+		`SELECT visibility FROM images WHERE tenant_id = $1 AND id = $2`, tenantID, imageID,
+	).Scan(&visibility)
+	if err != nil {
+		return false, err
+	}
+	sourceID, originalPath, err := lockDuplicate(ctx, tx, tenantID, contentHash, visibility)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE images d SET mime_type = s.mime_type, extension = s.extension, original_path = s.original_path, original_url = s.original_url,
+		        file_size = s.file_size, width = s.width, height = s.height, variants = s.variants, content_hash = s.content_hash,
+		        blurhash = s.blurhash, dominant_color = s.dominant_color,
+		        processing_status = 'completed', processed_at = s.processed_at, updated_at = NOW()
+		 FROM images s
+		 WHERE d.tenant_id = $1 AND d.id = $2 AND d.processing_status = 'uploading'
+		   AND s.id = $3 AND s.original_path = $4 AND s.processing_status = 'completed'`,
+		tenantID, imageID, sourceID, originalPath,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, tx.Commit(ctx)
+}
+
+// ImageRow is an image as listed and returned by the API
+type ImageRow struct {
+	ID               string                          `json:"id"`
+	ImageableType    string                          `json:"imageable_type"`
+	ImageableID      string                          `json:"imageable_id"`
+	Title            *string                         `json:"title"`
+	AltText          *string                         `json:"alt_text"`
+	Translations     interface{}                     `json:"translations"`
+	OriginalFilename *string                         `json:"original_filename"`
+	MimeType         string                          `json:"mime_type"`
+	Extension        string                          `json:"extension"`
+	Width            *int                            `json:"width"`
+	Height           *int                            `json:"height"`
+	FileSize         *int64                          `json:"file_size"`
+	OriginalURL      *string                         `json:"original_url"`
+	OriginalPath     string                          `json:"original_path"`
+	Variants         map[string]imagevariant.Variant `json:"variants"`
+	Visibility       string                          `json:"visibility"`
+	BlurHash         *string                         `json:"blurhash"`
+	DominantColor    *string                         `json:"dominant_color"`
+	ProcessingStatus string                          `json:"processing_status"`
+	DisplayOrder     int                             `json:"display_order"`
+	CreatedAt        interface{}                     `json:"created_at"`
+}
+
+const imageColumns = `id, imageable_type, imageable_id, title, alt_text, translations, original_filename, mime_type, extension,
+		        width, height, file_size, original_url, original_path, variants,
+		        visibility, blurhash, dominant_color, processing_status, display_order, created_at`
+
+func scanImage(row pgx.Row) (*ImageRow, error) {
+	var img ImageRow
+	err := row.Scan(&img.ID, &img.ImageableType, &img.ImageableID, &img.Title, &img.AltText, &img.Translations, &img.OriginalFilename, &img.MimeType, &img.Extension,
+		&img.Width, &img.Height, &img.FileSize, &img.OriginalURL, &img.OriginalPath, &img.Variants,
+		&img.Visibility, &img.BlurHash, &img.DominantColor, &img.ProcessingStatus, &img.DisplayOrder, &img.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (r *Repository) ListImages(ctx context.Context, tenantID, imageableType, imageableID string) ([]*ImageRow, error) {
 	rows, err := r.db.Query(ctx,
-		`SELECT id, title, alt_text, translations, original_filename, mime_type, extension,
-		        width, height, file_size, original_url, original_path,
-		        medium_url, small_url, thumb_url,
-		        processing_status, display_order, created_at
+		`SELECT `+imageColumns+`
 		 FROM images WHERE tenant_id = $1 AND imageable_type = $2 AND imageable_id = $3
 		 ORDER BY display_order, created_at`, tenantID, imageableType, imageableID,
 	)
@@ -1144,73 +1865,22 @@ func (r *Repository) ListImages(ctx context.Context, tenantID, imageableType, im
 	}
 	defer rows.Close()
 
-	var images []interface{}
+	images := []*ImageRow{}
 	for rows.Next() {
-		var img struct {
-			ID               string      `json:"id"`
-			Title            *string     `json:"title"`
-			AltText          *string     `json:"alt_text"`
-			Translations     interface{} `json:"translations"`
-			OriginalFilename *string     `json:"original_filename"`
-			MimeType         string      `json:"mime_type"`
-			Extension        string      `json:"extension"`
-			Width            *int        `json:"width"`
-			Height           *int        `json:"height"`
-			FileSize         *int64      `json:"file_size"`
-			OriginalURL      *string     `json:"original_url"`
-			OriginalPath     string      `json:"original_path"`
-			MediumURL        *string     `json:"medium_url"`
-			SmallURL         *string     `json:"small_url"`
-			ThumbURL         *string     `json:"thumb_url"`
-			ProcessingStatus string      `json:"processing_status"`
-			DisplayOrder     int         `json:"display_order"`
-			CreatedAt        interface{} `json:"created_at"`
-		}
-		if err := rows.Scan(&img.ID, &img.Title, &img.AltText, &img.Translations, &img.OriginalFilename, &img.MimeType, &img.Extension,
-			&img.Width, &img.Height, &img.FileSize, &img.OriginalURL, &img.OriginalPath,
-			&img.MediumURL, &img.SmallURL, &img.ThumbURL,
-			&img.ProcessingStatus, &img.DisplayOrder, &img.CreatedAt); err != nil {
+		img, err := scanImage(rows)
+		if err != nil {
 			return nil, err
 		}
 		images = append(images, img)
 	}
-	return images, nil
-}
-
-func (r *Repository) GetImage(ctx context.Context, tenantID, imageID string) (interface{}, error) {
-	var img struct {
-		ID               string      `json:"id"`
-		ImageableType    string      `json:"imageable_type"`
-		ImageableID      string      `json:"imageable_id"`
-		Title            *string     `json:"title"`
-		AltText          *string     `json:"alt_text"`
-		Translations     interface{} `json:"translations"`
-		OriginalFilename *string     `json:"original_filename"`
-		MimeType         string      `json:"mime_type"`
-		Extension        string      `json:"extension"`
-		Width            *int        `json:"width"`
-		Height           *int        `json:"height"`
-		FileSize         *int64      `json:"file_size"`
-		OriginalURL      *string     `json:"original_url"`
-		MediumURL        *string     `json:"medium_url"`
-		SmallURL         *string     `json:"small_url"`
-		ThumbURL         *string     `json:"thumb_url"`
-		ProcessingStatus string      `json:"processing_status"`
-		DisplayOrder     int         `json:"display_order"`
-		CreatedAt        interface{} `json:"created_at"`
-	}
-	err := r.db.QueryRow(ctx,
-		`SELECT id, imageable_type, imageable_id, title, alt_text, translations, original_filename, mime_type, extension,
-		        width, height, file_size, original_url, medium_url, small_url, thumb_url,
-		        processing_status, display_order, created_at
+	return images, rows.Err()
+}
+
+func (r *Repository) GetImage(ctx context.Context, tenantID, imageID string) (*ImageRow, error) {
+	return scanImage(r.db.QueryRow(ctx,
+		`SELECT `+imageColumns+`
 		 FROM images WHERE tenant_id = $1 AND id = $2`, tenantID, imageID,
-	).Scan(&img.ID, &img.ImageableType, &img.ImageableID, &img.Title, &img.AltText, &img.Translations, &img.OriginalFilename, &img.MimeType, &img.Extension,
-		&img.Width, &img.Height, &img.FileSize, &img.OriginalURL, &img.MediumURL, &img.SmallURL, &img.ThumbURL,
-		&img.ProcessingStatus, &img.DisplayOrder, &img.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return img, nil
+	))
 }
 
 func (r *Repository) UpdateImageTitle(ctx context.Context, tenantID, imageID string, title *string, altText *string, translations interface{}) error {
@@ -1241,40 +1911,105 @@ func (r *Repository) UpdateImageTitle(ctx context.Context, tenantID, imageID str
 	return err
 }
 
-func (r *Repository) DeleteImage(ctx context.Context, tenantID, imageID string) (string, string, string, error) {
+// DeleteImage deletes an image and returns the files to remove with it:
+// its original and variants, unless another image still uses them
+func (r *Repository) DeleteImage(ctx context.Context, tenantID, imageID string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	var originalPath string
-	var mediumPath, smallPath, thumbPath *string
-	err := r.db.QueryRow(ctx,
+	err = tx.QueryRow(ctx,
+		`SELECT original_path FROM images WHERE tenant_id = $1 AND id = $2`, tenantID, imageID,
+	).Scan(&originalPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockImageFiles(ctx, tx, originalPath); err != nil {
+		return nil, err
+	}
+
+	var variants map[string]imagevariant.Variant
+	err = tx.QueryRow(ctx,
 		`DELETE FROM images WHERE tenant_id = $1 AND id = $2
-		 RETURNING original_path, medium_path, small_path, thumb_path`,
+		 RETURNING original_path, variants`,
 		tenantID, imageID,
-	).Scan(&originalPath, &mediumPath, &smallPath, &thumbPath)
+	).Scan(&originalPath, &variants)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
+	}
+	used, err := imageFilesUsed(ctx, tx, originalPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
-	// Return imageable info for potential image_url update
-	return originalPath, strPtrVal(mediumPath), strPtrVal(smallPath), err
+	if used {
+		return nil, nil
+	}
+	return append([]string{originalPath}, variantPaths(variants)...), nil
 }
 
-func strPtrVal(s *string) string {
-	if s != nil {
-		return *s
+// variantPaths lists the storage paths of an image's variants
+func variantPaths(variants map[string]imagevariant.Variant) []string {
+	paths := make([]string, 0, len(variants))
+	for _, v := range variants {
+		if v.Path != "" {
+			paths = append(paths, v.Path)
+		}
 	}
-	return ""
+	return paths
 }
 
-func (r *Repository) GetImagePaths(ctx context.Context, tenantID, imageID string) (originalPath, mediumPath, smallPath, thumbPath, imageableType, imageableID string, err error) {
-	var mp, sp, tp *string
+func (r *Repository) GetImagePaths(ctx context.Context, tenantID, imageID string) (originalPath string, paths []string, imageableType, imageableID string, err error) {
+	var variants map[string]imagevariant.Variant
 	err = r.db.QueryRow(ctx,
-		`SELECT original_path, medium_path, small_path, thumb_path, imageable_type, imageable_id
+		`SELECT original_path, variants, imageable_type, imageable_id
 		 FROM images WHERE tenant_id = $1 AND id = $2`, tenantID, imageID,
-	).Scan(&originalPath, &mp, &sp, &tp, &imageableType, &imageableID)
-	mediumPath = strPtrVal(mp)
-	smallPath = strPtrVal(sp)
-	thumbPath = strPtrVal(tp)
+	).Scan(&originalPath, &variants, &imageableType, &imageableID)
+	paths = variantPaths(variants)
 	return
 }
 
+// MoveImageFiles points a completed or failed image at its files under a
+// new visibility. It reports false when the image was queued or its
+// original moved since it was read, in which case nothing changed, and
+// whether other images still use the files at fromPath.
+func (r *Repository) MoveImageFiles(ctx context.Context, tenantID, imageID, visibility, fromPath, originalPath, originalURL string, variants map[string]imagevariant.Variant) (moved, fromUsed bool, err error) {
+	variantsJSON, err := json.Marshal(variants)
+	if err != nil {
+		return false, false, err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockImageFiles(ctx, tx, fromPath); err != nil {
+		return false, false, err
+	}
+	tag, err := tx.Exec(ctx,
+		`UPDATE images SET visibility = $3, original_path = $5, original_url = $6, variants = $7::jsonb, updated_at = NOW()
+		 WHERE tenant_id = $1 AND id = $2 AND original_path = $4 AND processing_status IN ('completed', 'failed')`,
+		tenantID, imageID, visibility, fromPath, originalPath, originalURL, string(variantsJSON),
+	)
+	if err != nil {
+		return false, false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, false, nil
+	}
+	fromUsed, err = imageFilesUsed(ctx, tx, fromPath)
+	if err != nil {
+		return false, false, err
+	}
+	return true, fromUsed, tx.Commit(ctx)
+}
+
 func (r *Repository) DeleteImageRecord(ctx context.Context, tenantID, imageID string) error {
 	_, err := r.db.Exec(ctx,
 		`DELETE FROM images WHERE tenant_id = $1 AND id = $2`, tenantID, imageID,
@@ -1282,6 +2017,42 @@ func (r *Repository) DeleteImageRecord(ctx context.Context, tenantID, imageID st
 	return err
 }
 
+// LockImageIDs returns the ids of the images attached to an imageable,
+// locking them for the rest of the transaction
+func (r *Repository) LockImageIDs(ctx context.Context, tx pgx.Tx, tenantID, imageableType, imageableID string) ([]string, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT id FROM images
+		 WHERE tenant_id = $1 AND imageable_type = $2 AND imageable_id = $3
+		 FOR UPDATE`, tenantID, imageableType, imageableID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SetImageOrder sets display_order from each image's position in imageIDs,
+// starting at 1
+func (r *Repository) SetImageOrder(ctx context.Context, tx pgx.Tx, tenantID string, imageIDs []string) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE images SET display_order = o.position, updated_at = NOW()
+		 FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+		 WHERE images.tenant_id = $1 AND images.id = o.id`,
+		tenantID, imageIDs,
+	)
+	return err
+}
+
 func (r *Repository) GetNextDisplayOrder(ctx context.Context, tenantID, imageableType, imageableID string) int {
 	var order int
 	r.db.QueryRow(ctx,
@@ -1356,6 +2127,14 @@ func (r *Repository) GetAppUser(ctx context.Context, tenantID, userID string) (i
 	return u, nil
 }
 
+func (r *Repository) GetAppUserEmail(ctx context.Context, tenantID, userID string) (string, error) {
+	var email string
+	err := r.db.QueryRow(ctx,
+		`SELECT email FROM tenant_app_users WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL`, tenantID, userID,
+	).Scan(&email)
+	return email, err
+}
+
 func (r *Repository) UpdateAppUserStatus(ctx context.Context, tenantID, userID, status string) error {
 	_, err := r.db.Exec(ctx,
 		`UPDATE tenant_app_users SET status = $1, updated_at = NOW()
@@ -1426,3 +2205,70 @@ func (r *Repository) SetEmailVerified(ctx context.Context, userID string) error
 	)
 	return err
 }
+
+// --- Password Reset ---
+
+// CreatePasswordResetToken stores a new reset token hash for the user and
+// invalidates any reset tokens previously issued to them.
+func (r *Repository) CreatePasswordResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ResetPasswordWithToken consumes a valid reset token and sets the new password
+// in a single transaction. Returns pgx.ErrNoRows when the token is unknown,
+// expired or already used.
+func (r *Repository) ResetPasswordWithToken(ctx context.Context, tokenHash, hashPass string) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	err = tx.QueryRow(ctx,
+		`UPDATE password_reset_tokens SET used_at = NOW()
+		 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING user_id`,
+		tokenHash,
+	).Scan(&userID)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE users SET hash_pass = $1, updated_at = NOW()
+		 WHERE id = $2 AND status = 'active' AND deleted_at IS NULL`,
+		hashPass, userID,
+	)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", pgx.ErrNoRows
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
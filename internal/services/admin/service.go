@@ -2,38 +2,153 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/saas-single-db-api/internal/auth"
+	"github.com/saas-single-db-api/internal/cache"
+	models "github.com/saas-single-db-api/internal/models/admin"
 	repo "github.com/saas-single-db-api/internal/repository/admin"
 	"github.com/saas-single-db-api/internal/utils"
 )
 
+// permissionsCacheTTL bounds how long a cached permission set can outlive a
+// change that was not invalidated explicitly (e.g. edited in the database)
+const permissionsCacheTTL = 10 * time.Minute
+
 type Service struct {
 	repo      *repo.Repository
-	jwtSecret string
+	redis     *redis.Client
+	tenants   *cache.TenantContext
+	sessions  *auth.SessionService
+	mfa       *auth.MFAService
+	guard     *auth.LoginGuard
+	keys      *utils.KeySet
 	jwtExpiry int
 }
 
-func NewService(repo *repo.Repository, jwtSecret string, jwtExpiry int) *Service {
-	return &Service{repo: repo, jwtSecret: jwtSecret, jwtExpiry: jwtExpiry}
+func NewService(repo *repo.Repository, redisClient *redis.Client, sessions *auth.SessionService, mfa *auth.MFAService, guard *auth.LoginGuard, keys *utils.KeySet, jwtExpiry int) *Service {
+	return &Service{repo: repo, redis: redisClient, tenants: cache.NewTenantContext(redisClient), sessions: sessions, mfa: mfa, guard: guard, keys: keys, jwtExpiry: jwtExpiry}
+}
+
+// LoginResult holds either the issued tokens or, when a second factor is
+// needed, the MFA challenge token to complete the login with.
+type LoginResult struct {
+	Token         string
+	RefreshToken  string
+	Admin         map[string]interface{}
+	MFAToken      string
+	MFAEnrollment bool
+	RecoveryCodes []string
 }
 
-func (s *Service) Login(ctx context.Context, email, password string) (string, interface{}, error) {
+func (s *Service) Login(ctx context.Context, email, password string, client auth.Client) (*LoginResult, error) {
+	if err := s.guard.Check(ctx, auth.SubjectAdmin, email, client.IP); err != nil {
+		return nil, err
+	}
+
 	admin, err := s.repo.GetAdminByEmail(ctx, email)
 	if err != nil {
-		return "", nil, errors.New("invalid_credentials")
+		return nil, s.guard.Fail(ctx, auth.SubjectAdmin, email, client.IP, errors.New("invalid_credentials"))
+	}
+	if !utils.CheckPassword(password, admin.HashPass) {
+		return nil, s.guard.Fail(ctx, auth.SubjectAdmin, email, client.IP, errors.New("invalid_credentials"))
 	}
+	// A suspension is only revealed to someone who knows the password
 	if admin.Status != "active" {
-		return "", nil, errors.New("account_suspended")
+		return nil, s.guard.Fail(ctx, auth.SubjectAdmin, email, client.IP, errors.New("account_suspended"))
 	}
-	if !utils.CheckPassword(password, admin.HashPass) {
-		return "", nil, errors.New("invalid_credentials")
+
+	// 2FA is checked before any token is issued. Admins holding a role that
+	// requires it but without an enrolled factor must enroll to log in. The
+	// failures are only cleared once the login is complete, so wrong codes
+	// keep counting across challenges.
+	enabled := s.mfa.IsEnabled(ctx, auth.SubjectAdmin, admin.ID)
+	if enabled || s.repo.AdminRequires2FA(ctx, admin.ID) {
+		mfaToken, err := s.mfa.NewChallenge(ctx, auth.Challenge{SubjectType: auth.SubjectAdmin, SubjectID: admin.ID, Enroll: !enabled})
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: mfaToken, MFAEnrollment: !enabled}, nil
 	}
 
-	token, err := utils.GenerateAdminToken(admin.ID, s.jwtSecret, s.jwtExpiry)
+	result, err := s.completeLogin(ctx, admin, client)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	s.guard.Succeed(ctx, auth.SubjectAdmin, email)
+	return result, nil
+}
+
+// EnrollMFA returns a new TOTP secret for an admin that must enroll during login
+func (s *Service) EnrollMFA(ctx context.Context, mfaToken string) (*auth.Enrollment, error) {
+	ch, err := s.mfa.Challenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if ch.SubjectType != auth.SubjectAdmin || !ch.Enroll {
+		return nil, auth.ErrInvalidMFAToken
+	}
+	admin, err := s.repo.GetAdminByID(ctx, ch.SubjectID)
+	if err != nil {
+		return nil, auth.ErrInvalidMFAToken
+	}
+	return s.mfa.Setup(ctx, auth.SubjectAdmin, admin.ID, admin.Email)
+}
+
+// VerifyMFA completes a login started with Login. For enrollment challenges the
+// code confirms the new secret and the result carries the recovery codes.
+func (s *Service) VerifyMFA(ctx context.Context, mfaToken, code string, client auth.Client) (*LoginResult, error) {
+	ch, err := s.mfa.Challenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if ch.SubjectType != auth.SubjectAdmin {
+		return nil, auth.ErrInvalidMFAToken
+	}
+
+	admin, err := s.repo.GetAdminByID(ctx, ch.SubjectID)
+	if err != nil || admin.Status != "active" {
+		return nil, auth.ErrInvalidMFAToken
+	}
+	if err := s.guard.Check(ctx, auth.SubjectAdmin, admin.Email, client.IP); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	if ch.Enroll {
+		codes, err = s.mfa.Enable(ctx, auth.SubjectAdmin, admin.ID, code)
+	} else {
+		err = s.mfa.Verify(ctx, auth.SubjectAdmin, admin.ID, code)
+	}
+	if err != nil {
+		return nil, s.mfaFailed(ctx, admin.Email, client.IP, err)
+	}
+	s.mfa.ClearChallenge(ctx, mfaToken)
+
+	result, err := s.completeLogin(ctx, admin, client)
+	if err != nil {
+		return nil, err
+	}
+	s.guard.Succeed(ctx, auth.SubjectAdmin, admin.Email)
+	result.RecoveryCodes = codes
+	return result, nil
+}
+
+func (s *Service) completeLogin(ctx context.Context, admin *models.SystemAdminUser, client auth.Client) (*LoginResult, error) {
+	sessionID, refreshToken, err := s.sessions.Start(ctx, auth.SubjectAdmin, admin.ID, "", client)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateAdminToken(admin.ID, sessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return nil, err
 	}
 
 	profile, _ := s.repo.GetProfile(ctx, admin.ID)
@@ -49,7 +164,127 @@ func (s *Service) Login(ctx context.Context, email, password string) (string, in
 		}
 	}
 
-	return token, result, nil
+	return &LoginResult{Token: token, RefreshToken: refreshToken, Admin: result}, nil
+}
+
+// Refresh rotates the refresh token and issues a new access token
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	grant, newRefresh, err := s.sessions.Rotate(ctx, auth.SubjectAdmin, "", refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	admin, err := s.repo.GetAdminByID(ctx, grant.SubjectID)
+	if err != nil || admin.Status != "active" {
+		_ = s.sessions.RevokeAll(ctx, auth.SubjectAdmin, grant.SubjectID, "")
+		return "", "", auth.ErrInvalidRefreshToken
+	}
+
+	token, err := utils.GenerateAdminToken(admin.ID, grant.SessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return token, newRefresh, nil
+}
+
+// SetupMFA starts 2FA enrollment for a logged-in admin
+func (s *Service) SetupMFA(ctx context.Context, adminID string) (*auth.Enrollment, error) {
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return nil, errors.New("admin_not_found")
+	}
+	return s.mfa.Setup(ctx, auth.SubjectAdmin, admin.ID, admin.Email)
+}
+
+// EnableMFA confirms the pending secret and returns the recovery codes
+func (s *Service) EnableMFA(ctx context.Context, adminID, code, ip string) ([]string, error) {
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return nil, errors.New("admin_not_found")
+	}
+	if err := s.guard.Check(ctx, auth.SubjectAdmin, admin.Email, ip); err != nil {
+		return nil, err
+	}
+	codes, err := s.mfa.Enable(ctx, auth.SubjectAdmin, adminID, code)
+	if err != nil {
+		return nil, s.mfaFailed(ctx, admin.Email, ip, err)
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the admin's second factor. It requires the password and a
+// valid code, and is refused while one of the admin's roles requires 2FA.
+func (s *Service) DisableMFA(ctx context.Context, adminID, password, code, ip string) error {
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return errors.New("admin_not_found")
+	}
+	if err := s.guard.Check(ctx, auth.SubjectAdmin, admin.Email, ip); err != nil {
+		return err
+	}
+	if !utils.CheckPassword(password, admin.HashPass) {
+		return errors.New("invalid_current_password")
+	}
+	if s.repo.AdminRequires2FA(ctx, adminID) {
+		return errors.New("two_factor_required_by_role")
+	}
+	if err := s.mfa.Verify(ctx, auth.SubjectAdmin, adminID, code); err != nil {
+		return s.mfaFailed(ctx, admin.Email, ip, err)
+	}
+	return s.mfa.Disable(ctx, auth.SubjectAdmin, adminID)
+}
+
+// RegenerateRecoveryCodes replaces the admin's recovery codes after checking a code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, adminID, code, ip string) ([]string, error) {
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return nil, errors.New("admin_not_found")
+	}
+	if err := s.guard.Check(ctx, auth.SubjectAdmin, admin.Email, ip); err != nil {
+		return nil, err
+	}
+	if err := s.mfa.Verify(ctx, auth.SubjectAdmin, adminID, code); err != nil {
+		return nil, s.mfaFailed(ctx, admin.Email, ip, err)
+	}
+	return s.mfa.RegenerateRecoveryCodes(ctx, auth.SubjectAdmin, adminID)
+}
+
+// mfaFailed counts a wrong 2FA code as a failed login of the admin, so codes
+// cannot be guessed faster than passwords
+func (s *Service) mfaFailed(ctx context.Context, email, ip string, err error) error {
+	if errors.Is(err, auth.ErrInvalidMFACode) {
+		return s.guard.Fail(ctx, auth.SubjectAdmin, email, ip, err)
+	}
+	return err
+}
+
+// Logout ends the current session
+func (s *Service) Logout(ctx context.Context, adminID, sessionID string) error {
+	return s.sessions.Revoke(ctx, auth.SubjectAdmin, adminID, sessionID)
+}
+
+// ListSessions returns the active sessions of the admin
+func (s *Service) ListSessions(ctx context.Context, adminID, currentID string) ([]auth.Session, error) {
+	return s.sessions.List(ctx, auth.SubjectAdmin, adminID, currentID)
+}
+
+// RevokeSession ends one of the admin's sessions
+func (s *Service) RevokeSession(ctx context.Context, adminID, sessionID string) error {
+	return s.sessions.Revoke(ctx, auth.SubjectAdmin, adminID, sessionID)
+}
+
+// RevokeOtherSessions ends every session of the admin except the current one
+func (s *Service) RevokeOtherSessions(ctx context.Context, adminID, currentID string) error {
+	return s.sessions.RevokeAll(ctx, auth.SubjectAdmin, adminID, currentID)
+}
+
+// UnlockAdmin lifts a login lockout of an admin account
+func (s *Service) UnlockAdmin(ctx context.Context, adminID string) error {
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return errors.New("admin_not_found")
+	}
+	return s.guard.Unlock(ctx, auth.SubjectAdmin, admin.Email)
 }
 
 func (s *Service) GetMe(ctx context.Context, adminID string) (interface{}, error) {
@@ -77,19 +312,184 @@ func (s *Service) GetMe(ctx context.Context, adminID string) (interface{}, error
 }
 
 func (s *Service) HasPermission(ctx context.Context, adminID, permission string) bool {
-	perms, err := s.repo.GetAdminPermissions(ctx, adminID)
+	perms, err := s.permissions(ctx, adminID)
 	if err != nil {
 		return false
 	}
 	return slices.Contains(perms, permission)
 }
 
+// permissions returns the admin's permission slugs, cached in Redis under
+// "admin:permissions:{admin_id}"
+func (s *Service) permissions(ctx context.Context, adminID string) ([]string, error) {
+	key := permissionsCacheKey(adminID)
+	if cached, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+		var perms []string
+		if json.Unmarshal(cached, &perms) == nil {
+			return perms, nil
+		}
+	}
+
+	perms, err := s.repo.GetAdminPermissions(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	if data, err := json.Marshal(perms); err == nil {
+		s.redis.Set(ctx, key, data, permissionsCacheTTL)
+	}
+	return perms, nil
+}
+
+// InvalidatePermissions drops the cached permissions of the given admins
+func (s *Service) InvalidatePermissions(ctx context.Context, adminIDs ...string) {
+	if len(adminIDs) == 0 {
+		return
+	}
+	keys := make([]string, len(adminIDs))
+	for i, id := range adminIDs {
+		keys[i] = permissionsCacheKey(id)
+	}
+	s.redis.Del(ctx, keys...)
+}
+
+func (s *Service) AssignRole(ctx context.Context, adminID string, roleID int) error {
+	if err := s.repo.AssignRoleToAdmin(ctx, adminID, roleID); err != nil {
+		return err
+	}
+	s.InvalidatePermissions(ctx, adminID)
+	return nil
+}
+
+func (s *Service) RemoveRole(ctx context.Context, adminID string, roleID int) error {
+	if err := s.repo.RemoveRoleFromAdmin(ctx, adminID, roleID); err != nil {
+		return err
+	}
+	s.InvalidatePermissions(ctx, adminID)
+	return nil
+}
+
+// DeleteRole removes a role and invalidates the permissions of every admin
+// that held it
+func (s *Service) DeleteRole(ctx context.Context, roleID int) error {
+	adminIDs, err := s.repo.GetRoleAdminIDs(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteRole(ctx, roleID); err != nil {
+		return err
+	}
+	s.InvalidatePermissions(ctx, adminIDs...)
+	return nil
+}
+
+func permissionsCacheKey(adminID string) string {
+	return "admin:permissions:" + adminID
+}
+
+// --- Tenant context ---
+
+// The methods below change data the tenant middlewares cache (status,
+// features, plan) and drop the affected cached contexts so the change takes
+// effect on the next request.
+
+// InvalidateTenantContext drops the cached context of the given tenants
+func (s *Service) InvalidateTenantContext(ctx context.Context, tenantIDs ...string) {
+	s.tenants.Invalidate(ctx, tenantIDs...)
+}
+
+func (s *Service) UpdateTenantStatus(ctx context.Context, tenantID, status string) error {
+	if err := s.repo.UpdateTenantStatus(ctx, tenantID, status); err != nil {
+		return err
+	}
+	s.tenants.Invalidate(ctx, tenantID)
+	return nil
+}
+
+func (s *Service) DeleteTenant(ctx context.Context, tenantID string) error {
+	if err := s.repo.SoftDeleteTenant(ctx, tenantID); err != nil {
+		return err
+	}
+	s.tenants.Invalidate(ctx, tenantID)
+	return nil
+}
+
+func (s *Service) DeletePlan(ctx context.Context, planID string) error {
+	if err := s.repo.DeletePlan(ctx, planID); err != nil {
+		return err
+	}
+	s.tenants.InvalidatePlan(ctx, planID)
+	return nil
+}
+
+func (s *Service) AddFeatureToPlan(ctx context.Context, planID, featureID string) error {
+	if err := s.repo.AddFeatureToPlan(ctx, planID, featureID); err != nil {
+		return err
+	}
+	s.tenants.InvalidatePlan(ctx, planID)
+	return nil
+}
+
+func (s *Service) RemoveFeatureFromPlan(ctx context.Context, planID, featureID string) error {
+	if err := s.repo.RemoveFeatureFromPlan(ctx, planID, featureID); err != nil {
+		return err
+	}
+	s.tenants.InvalidatePlan(ctx, planID)
+	return nil
+}
+
+func (s *Service) UpdateFeature(ctx context.Context, featureID string, title, description *string, isActive *bool, translations interface{}) error {
+	if err := s.repo.UpdateFeature(ctx, featureID, title, description, isActive, translations); err != nil {
+		return err
+	}
+	s.tenants.InvalidateFeature(ctx, featureID)
+	return nil
+}
+
+func (s *Service) DeleteFeature(ctx context.Context, featureID string) error {
+	if err := s.repo.DeleteFeature(ctx, featureID); err != nil {
+		return err
+	}
+	s.tenants.InvalidateFeature(ctx, featureID)
+	return nil
+}
+
+// TransferTenantOwnership makes a member the tenant owner on the admin's
+// authority, for when the owner cannot start a transfer themselves
+func (s *Service) TransferTenantOwnership(ctx context.Context, tenantID, userID, adminID, reason string) error {
+	members, err := s.repo.GetTenantMembers(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, m := range members {
+		if m.UserID == userID {
+			if m.IsOwner {
+				return errors.New("already_tenant_owner")
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.New("member_not_found")
+	}
+
+	err = s.repo.TransferTenantOwnership(ctx, tenantID, userID, adminID, reason)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("member_not_found")
+	}
+	return err
+}
+
 func (s *Service) Repo() *repo.Repository {
 	return s.repo
 }
 
-func (s *Service) JWTSecret() string {
-	return s.jwtSecret
+func (s *Service) Keys() *utils.KeySet {
+	return s.keys
 }
 
 func (s *Service) JWTExpiry() int {
@@ -3,27 +3,48 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
 
+	"github.com/saas-single-db-api/internal/auth"
+	"github.com/saas-single-db-api/internal/cache"
+	"github.com/saas-single-db-api/internal/email"
+	"github.com/saas-single-db-api/internal/profileimage"
 	repo "github.com/saas-single-db-api/internal/repository/app"
+	"github.com/saas-single-db-api/internal/upload"
 	"github.com/saas-single-db-api/internal/utils"
+	"github.com/saas-single-db-api/internal/webhooks"
 )
 
+// passwordResetTTL is how long a password reset link stays valid
+const passwordResetTTL = 60 * time.Minute
+
 type Service struct {
-	repo      *repo.Repository
-	jwtSecret string
-	jwtExpiry int
+	repo         *repo.Repository
+	cache        *cache.RedisClient
+	emailService *email.Service
+	sessions     *auth.SessionService
+	guard        *auth.LoginGuard
+	webhooks     *webhooks.Service
+	profiles     *profileimage.Service
+	keys         *utils.KeySet
+	jwtExpiry    int
 }
 
-func NewService(r *repo.Repository, jwtSecret string, jwtExpiry int) *Service {
-	return &Service{repo: r, jwtSecret: jwtSecret, jwtExpiry: jwtExpiry}
+func NewService(r *repo.Repository, c *cache.RedisClient, emailSvc *email.Service, sessions *auth.SessionService, guard *auth.LoginGuard, hooks *webhooks.Service, profiles *profileimage.Service, keys *utils.KeySet, jwtExpiry int) *Service {
+	return &Service{repo: r, cache: c, emailService: emailSvc, sessions: sessions, guard: guard, webhooks: hooks, profiles: profiles, keys: keys, jwtExpiry: jwtExpiry}
 }
 
 type RegisterResult struct {
-	UserID string
-	Token  string
+	UserID       string
+	Token        string
+	RefreshToken string
 }
 
-func (s *Service) Register(ctx context.Context, tenantID, urlCode, name, email, password string) (*RegisterResult, error) {
+func (s *Service) Register(ctx context.Context, tenantID, urlCode, name, email, password string, client auth.Client) (*RegisterResult, error) {
 	existing, _ := s.repo.GetAppUserByEmail(ctx, tenantID, email)
 	if existing != nil {
 		return nil, errors.New("email_already_registered")
@@ -41,46 +62,112 @@ func (s *Service) Register(ctx context.Context, tenantID, urlCode, name, email,
 
 	_ = s.repo.CreateAppUserProfile(ctx, userID, name)
 
-	token, err := utils.GenerateAppUserToken(userID, tenantID, s.jwtSecret, s.jwtExpiry)
+	_ = s.webhooks.Emit(ctx, tenantID, webhooks.EventAppUserRegistered, map[string]string{
+		"id":    userID,
+		"name":  name,
+		"email": email,
+	})
+
+	token, refreshToken, err := s.startSession(ctx, userID, tenantID, client)
 	if err != nil {
 		return nil, err
 	}
 
-	return &RegisterResult{UserID: userID, Token: token}, nil
+	return &RegisterResult{UserID: userID, Token: token, RefreshToken: refreshToken}, nil
 }
 
 type LoginResult struct {
-	UserID string
-	Token  string
-	Name   string
-	Email  string
+	UserID       string
+	Token        string
+	RefreshToken string
+	Name         string
+	Email        string
 }
 
-func (s *Service) Login(ctx context.Context, tenantID, urlCode, email, password string) (*LoginResult, error) {
+func (s *Service) Login(ctx context.Context, tenantID, urlCode, email, password string, client auth.Client) (*LoginResult, error) {
+	scope := auth.AppScope(tenantID)
+	if err := s.guard.Check(ctx, scope, email, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetAppUserByEmail(ctx, tenantID, email)
 	if err != nil {
-		return nil, errors.New("invalid_credentials")
-	}
-	if user.Status != "active" {
-		return nil, errors.New("account_not_active")
+		return nil, s.guard.Fail(ctx, scope, email, client.IP, errors.New("invalid_credentials"))
 	}
 	if !utils.CheckPassword(password, user.HashPass) {
-		return nil, errors.New("invalid_credentials")
+		return nil, s.guard.Fail(ctx, scope, email, client.IP, errors.New("invalid_credentials"))
 	}
+	if user.Status != "active" {
+		return nil, s.guard.Fail(ctx, scope, email, client.IP, errors.New("account_not_active"))
+	}
+	s.guard.Succeed(ctx, scope, email)
 
-	token, err := utils.GenerateAppUserToken(user.ID, tenantID, s.jwtSecret, s.jwtExpiry)
+	token, refreshToken, err := s.startSession(ctx, user.ID, tenantID, client)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LoginResult{
-		UserID: user.ID,
-		Token:  token,
-		Name:   user.Name,
-		Email:  user.Email,
+		UserID:       user.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
+		Name:         user.Name,
+		Email:        user.Email,
 	}, nil
 }
 
+func (s *Service) startSession(ctx context.Context, userID, tenantID string, client auth.Client) (string, string, error) {
+	sessionID, refreshToken, err := s.sessions.Start(ctx, auth.SubjectApp, userID, tenantID, client)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := utils.GenerateAppUserToken(userID, tenantID, sessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+// Refresh rotates a refresh token issued for this tenant and returns a new access token
+func (s *Service) Refresh(ctx context.Context, tenantID, refreshToken string) (string, string, error) {
+	grant, newRefresh, err := s.sessions.Rotate(ctx, auth.SubjectApp, tenantID, refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetAppUserByID(ctx, tenantID, grant.SubjectID)
+	if err != nil || user.Status != "active" {
+		_ = s.sessions.RevokeAll(ctx, auth.SubjectApp, grant.SubjectID, "")
+		return "", "", auth.ErrInvalidRefreshToken
+	}
+
+	token, err := utils.GenerateAppUserToken(user.ID, tenantID, grant.SessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return token, newRefresh, nil
+}
+
+// Logout ends the current session
+func (s *Service) Logout(ctx context.Context, userID, sessionID string) error {
+	return s.sessions.Revoke(ctx, auth.SubjectApp, userID, sessionID)
+}
+
+// ListSessions returns the active sessions of the app user
+func (s *Service) ListSessions(ctx context.Context, userID, currentID string) ([]auth.Session, error) {
+	return s.sessions.List(ctx, auth.SubjectApp, userID, currentID)
+}
+
+// RevokeSession ends one of the app user's sessions
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.sessions.Revoke(ctx, auth.SubjectApp, userID, sessionID)
+}
+
+// RevokeOtherSessions ends every session of the app user except the current one
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID, currentID string) error {
+	return s.sessions.RevokeAll(ctx, auth.SubjectApp, userID, currentID)
+}
+
 func (s *Service) GetMe(ctx context.Context, tenantID, userID string) (map[string]interface{}, error) {
 	user, err := s.repo.GetAppUserByID(ctx, tenantID, userID)
 	if err != nil {
@@ -108,6 +195,12 @@ func (s *Service) GetMe(ctx context.Context, tenantID, userID string) (map[strin
 	return result, nil
 }
 
+// ReplaceAvatar makes a validated upload the app user's avatar, deleting the
+// previous one
+func (s *Service) ReplaceAvatar(ctx context.Context, tenantID, userID string, file io.Reader, info *upload.Image, filename string) (*profileimage.Picture, error) {
+	return s.profiles.Replace(ctx, profileimage.Owner{Type: profileimage.AppUserAvatar, ID: userID, TenantID: tenantID}, file, info, filename)
+}
+
 func (s *Service) ChangePassword(ctx context.Context, tenantID, userID, currentPass, newPass string) error {
 	user, err := s.repo.GetAppUserByID(ctx, tenantID, userID)
 	if err != nil {
@@ -123,19 +216,54 @@ func (s *Service) ChangePassword(ctx context.Context, tenantID, userID, currentP
 	return s.repo.UpdateAppUserPassword(ctx, tenantID, userID, hashPass)
 }
 
-func (s *Service) ForgotPassword(ctx context.Context, tenantID, email string) error {
-	_, err := s.repo.GetAppUserByEmail(ctx, tenantID, email)
-	if err != nil {
+// ForgotPassword issues a single-use reset token and emails the link to the app user.
+// It never reveals whether the email is registered in the tenant.
+func (s *Service) ForgotPassword(ctx context.Context, tenantID, urlCode, email, language string) error {
+	user, err := s.repo.GetAppUserByEmail(ctx, tenantID, email)
+	if err != nil || user.Status != "active" {
 		// Don't reveal if email exists
 		return nil
 	}
-	// TODO: Send password reset email
-	// For now, just return success
+
+	token := utils.GenerateVerificationToken()
+	if err := s.repo.CreatePasswordResetToken(ctx, tenantID, user.ID, utils.HashToken(token), time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	// Send reset email (async, don't block on failure)
+	if s.emailService != nil {
+		go func() {
+			tenantName, _ := s.repo.GetTenantName(context.Background(), tenantID)
+			resetURL := fmt.Sprintf("%s/%s/reset-password?token=%s", s.emailService.BaseURL(), urlCode, token)
+			vars := map[string]string{
+				"user_name":       user.Name,
+				"tenant_name":     tenantName,
+				"reset_url":       resetURL,
+				"expires_minutes": strconv.Itoa(int(passwordResetTTL.Minutes())),
+			}
+			_ = s.emailService.SendAppPasswordReset(context.Background(), user.Email, language, vars)
+		}()
+	}
+
 	return nil
 }
 
+// ResetPassword consumes a reset token, sets the new password and ends every
+// session of the app user. The reset is committed once the token is used, so
+// failing to end the sessions is logged rather than reported.
 func (s *Service) ResetPassword(ctx context.Context, tenantID, token, newPass string) error {
-	// TODO: Validate token and get user ID
-	// For now, return not implemented
-	return errors.New("password_reset_not_implemented")
+	hashPass, err := utils.HashPassword(newPass)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.repo.ResetPasswordWithToken(ctx, tenantID, utils.HashToken(token), hashPass)
+	if err != nil {
+		return errors.New("invalid_reset_token")
+	}
+
+	if err := s.sessions.RevokeAll(ctx, auth.SubjectApp, userID, ""); err != nil {
+		log.Printf("Error revoking sessions of app user %s after password reset: %v", userID, err)
+	}
+	return nil
 }
@@ -2,28 +2,51 @@ package tenant
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/saas-single-db-api/internal/auth"
 	"github.com/saas-single-db-api/internal/cache"
+	"github.com/saas-single-db-api/internal/domains"
 	"github.com/saas-single-db-api/internal/email"
+	"github.com/saas-single-db-api/internal/imageevents"
+	"github.com/saas-single-db-api/internal/imagequeue"
+	"github.com/saas-single-db-api/internal/imagevariant"
+	"github.com/saas-single-db-api/internal/profileimage"
 	repo "github.com/saas-single-db-api/internal/repository/tenant"
+	"github.com/saas-single-db-api/internal/upload"
 	"github.com/saas-single-db-api/internal/utils"
+	"github.com/saas-single-db-api/internal/webhooks"
 )
 
 type Service struct {
 	repo         *repo.Repository
 	cache        *cache.RedisClient
+	tenants      *cache.TenantContext
 	emailService *email.Service
-	jwtSecret    string
+	sessions     *auth.SessionService
+	mfa          *auth.MFAService
+	guard        *auth.LoginGuard
+	domains      *domains.Verifier
+	webhooks     *webhooks.Service
+	images       *imagequeue.Queue
+	imageEvents  *imageevents.Log
+	profiles     *profileimage.Service
+	keys         *utils.KeySet
 	jwtExpiry    int
 }
 
-func NewService(r *repo.Repository, c *cache.RedisClient, emailSvc *email.Service, jwtSecret string, jwtExpiry int) *Service {
-	return &Service{repo: r, cache: c, emailService: emailSvc, jwtSecret: jwtSecret, jwtExpiry: jwtExpiry}
+func NewService(r *repo.Repository, c *cache.RedisClient, emailSvc *email.Service, sessions *auth.SessionService, mfa *auth.MFAService, guard *auth.LoginGuard, verifier *domains.Verifier, hooks *webhooks.Service, images *imagequeue.Queue, imageEvents *imageevents.Log, profiles *profileimage.Service, keys *utils.KeySet, jwtExpiry int) *Service {
+	return &Service{repo: r, cache: c, tenants: cache.NewTenantContext(c.Inner()), emailService: emailSvc, sessions: sessions, mfa: mfa, guard: guard, domains: verifier, webhooks: hooks, images: images, imageEvents: imageEvents, profiles: profiles, keys: keys, jwtExpiry: jwtExpiry}
 }
 
 // --- Subscription Flow ---
@@ -40,13 +63,15 @@ type SubscribeInput struct {
 	OwnerEmail   string
 	OwnerPass    string
 	Language     string
+	Client       auth.Client
 }
 
 type SubscribeResult struct {
-	TenantID string
-	UserID   string
-	Token    string
-	URLCode  string
+	TenantID     string
+	UserID       string
+	Token        string
+	RefreshToken string
+	URLCode      string
 }
 
 func (s *Service) Subscribe(ctx context.Context, input SubscribeInput) (*SubscribeResult, error) {
@@ -178,17 +203,18 @@ func (s *Service) Subscribe(ctx context.Context, input SubscribeInput) (*Subscri
 		}()
 	}
 
-	// Generate token
-	token, err := utils.GenerateUserToken(userID, tenantID, s.jwtSecret, s.jwtExpiry)
+	// Generate tokens
+	token, refreshToken, err := s.startSession(ctx, userID, tenantID, input.Client)
 	if err != nil {
 		return nil, err
 	}
 
 	return &SubscribeResult{
-		TenantID: tenantID,
-		UserID:   userID,
-		Token:    token,
-		URLCode:  urlCode,
+		TenantID:     tenantID,
+		UserID:       userID,
+		Token:        token,
+		RefreshToken: refreshToken,
+		URLCode:      urlCode,
 	}, nil
 }
 
@@ -251,28 +277,195 @@ func (s *Service) ResendVerification(ctx context.Context, userID string) error {
 	return nil
 }
 
+// --- Password Reset ---
+
+const (
+	// passwordResetTTL is how long a password reset link stays valid
+	passwordResetTTL = 60 * time.Minute
+	// passwordResetMaxRequests limits reset emails per address within passwordResetWindow
+	passwordResetMaxRequests = 3
+	passwordResetWindow      = time.Hour
+)
+
+// ForgotPassword issues a single-use reset token and emails the link to the user.
+// Requests are rate limited per email and never reveal whether the email exists.
+func (s *Service) ForgotPassword(ctx context.Context, email, language string) error {
+	key := "ratelimit:password_reset:" + strings.ToLower(email)
+	count, err := cache.IncrWithTTL(s.cache.Inner(), ctx, key, passwordResetWindow)
+	if err == nil && count > passwordResetMaxRequests {
+		return errors.New("too_many_reset_requests")
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil || user.Status != "active" {
+		// Don't reveal if email exists
+		return nil
+	}
+
+	token := utils.GenerateVerificationToken()
+	if err := s.repo.CreatePasswordResetToken(ctx, user.ID, utils.HashToken(token), time.Now().Add(passwordResetTTL)); err != nil {
+		return fmt.Errorf("create password reset token: %w", err)
+	}
+
+	// Send reset email (async, don't block on failure)
+	if s.emailService != nil {
+		go func() {
+			resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.emailService.BaseURL(), token)
+			vars := map[string]string{
+				"user_name":       user.Name,
+				"reset_url":       resetURL,
+				"expires_minutes": strconv.Itoa(int(passwordResetTTL.Minutes())),
+			}
+			_ = s.emailService.SendPasswordReset(context.Background(), user.Email, language, vars)
+		}()
+	}
+
+	return nil
+}
+
+// ResetPassword consumes a reset token, sets the new password, ends every
+// session of the user and sends a confirmation email.
+func (s *Service) ResetPassword(ctx context.Context, token, newPass, language string) error {
+	hashPass, err := utils.HashPassword(newPass)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.repo.ResetPasswordWithToken(ctx, utils.HashToken(token), hashPass)
+	if err != nil {
+		return errors.New("invalid_reset_token")
+	}
+
+	// The reset is committed; a user told it failed would try a used token
+	if err := s.sessions.RevokeAll(ctx, auth.SubjectUser, userID, ""); err != nil {
+		log.Printf("Error revoking sessions of user %s after password reset: %v", userID, err)
+	}
+
+	// Send confirmation email
+	if s.emailService != nil {
+		go func() {
+			user, err := s.repo.GetUserByID(context.Background(), userID)
+			if err == nil {
+				vars := map[string]string{
+					"user_name":  user.Name,
+					"changed_at": time.Now().UTC().Format("2006-01-02 15:04 UTC"),
+				}
+				_ = s.emailService.SendPasswordChanged(context.Background(), user.Email, language, vars)
+			}
+		}()
+	}
+
+	return nil
+}
+
 // --- Auth ---
 
+// LoginResult holds either the issued tokens or, when the user has 2FA
+// enabled, the MFA challenge token to complete the login with.
 type LoginResult struct {
 	Token             string
+	RefreshToken      string
 	Name              string
 	Email             string
 	CurrentTenantCode string
 	Tenants           []repo.TenantBriefExported
 	Language          string
+	MFAToken          string
 }
 
-func (s *Service) Login(ctx context.Context, email, password string) (*LoginResult, error) {
+func (s *Service) Login(ctx context.Context, email, password string, client auth.Client) (*LoginResult, error) {
+	if err := s.guard.Check(ctx, auth.SubjectUser, email, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return nil, errors.New("invalid_credentials")
+		return nil, s.guard.Fail(ctx, auth.SubjectUser, email, client.IP, errors.New("invalid_credentials"))
 	}
+	if !utils.CheckPassword(password, user.HashPass) {
+		return nil, s.guard.Fail(ctx, auth.SubjectUser, email, client.IP, errors.New("invalid_credentials"))
+	}
+	// The status is only revealed with the right password, and still counts
+	// as a failed attempt
 	if user.Status != "active" {
-		return nil, errors.New("account_not_active")
+		return nil, s.guard.Fail(ctx, auth.SubjectUser, email, client.IP, errors.New("account_not_active"))
 	}
-	if !utils.CheckPassword(password, user.HashPass) {
+
+	// 2FA is checked before any token is issued. The failures are only
+	// cleared once the login is complete, so wrong codes keep counting
+	// across challenges.
+	if s.mfa.IsEnabled(ctx, auth.SubjectUser, user.ID) {
+		mfaToken, err := s.mfa.NewChallenge(ctx, auth.Challenge{SubjectType: auth.SubjectUser, SubjectID: user.ID})
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: mfaToken}, nil
+	}
+
+	result, err := s.completeLogin(ctx, user.ID, client)
+	if err != nil {
+		return nil, err
+	}
+	s.guard.Succeed(ctx, auth.SubjectUser, email)
+	return result, nil
+}
+
+// UnlockMember lifts a login lockout of a backoffice user who is a member of the tenant
+func (s *Service) UnlockMember(ctx context.Context, tenantID, memberID string) error {
+	member, err := s.repo.GetMember(ctx, tenantID, memberID)
+	if err != nil {
+		return errors.New("member_not_found")
+	}
+	return s.guard.Unlock(ctx, auth.SubjectUser, member.Email)
+}
+
+// UnlockAppUser lifts a login lockout of an app user of the tenant
+func (s *Service) UnlockAppUser(ctx context.Context, tenantID, appUserID string) error {
+	email, err := s.repo.GetAppUserEmail(ctx, tenantID, appUserID)
+	if err != nil {
+		return errors.New("app_user_not_found")
+	}
+	return s.guard.Unlock(ctx, auth.AppScope(tenantID), email)
+}
+
+// VerifyMFA completes a login started with Login using a TOTP or recovery code
+func (s *Service) VerifyMFA(ctx context.Context, mfaToken, code string, client auth.Client) (*LoginResult, error) {
+	ch, err := s.mfa.Challenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if ch.SubjectType != auth.SubjectUser {
+		return nil, auth.ErrInvalidMFAToken
+	}
+	user, err := s.repo.GetUserByID(ctx, ch.SubjectID)
+	if err != nil {
+		return nil, auth.ErrInvalidMFAToken
+	}
+	if err := s.guard.Check(ctx, auth.SubjectUser, user.Email, client.IP); err != nil {
+		return nil, err
+	}
+	if err := s.mfa.Verify(ctx, auth.SubjectUser, user.ID, code); err != nil {
+		return nil, s.mfaFailed(ctx, user.Email, client.IP, err)
+	}
+	s.mfa.ClearChallenge(ctx, mfaToken)
+
+	result, err := s.completeLogin(ctx, user.ID, client)
+	if err != nil {
+		return nil, err
+	}
+	s.guard.Succeed(ctx, auth.SubjectUser, user.Email)
+	return result, nil
+}
+
+// completeLogin picks the tenant to scope the session to and issues the tokens
+func (s *Service) completeLogin(ctx context.Context, userID string, client auth.Client) (*LoginResult, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
 		return nil, errors.New("invalid_credentials")
 	}
+	if user.Status != "active" {
+		return nil, errors.New("account_not_active")
+	}
 
 	tenants, _ := s.repo.GetUserTenants(ctx, user.ID)
 	tenantList := make([]repo.TenantBriefExported, len(tenants))
@@ -297,7 +490,7 @@ func (s *Service) Login(ctx context.Context, email, password string) (*LoginResu
 		tenantID = tenants[0].ID
 	}
 
-	token, err := utils.GenerateUserToken(user.ID, tenantID, s.jwtSecret, s.jwtExpiry)
+	token, refreshToken, err := s.startSession(ctx, user.ID, tenantID, client)
 	if err != nil {
 		return nil, err
 	}
@@ -307,6 +500,7 @@ func (s *Service) Login(ctx context.Context, email, password string) (*LoginResu
 
 	return &LoginResult{
 		Token:             token,
+		RefreshToken:      refreshToken,
 		Name:              user.Name,
 		Email:             user.Email,
 		CurrentTenantCode: urlCode,
@@ -315,6 +509,148 @@ func (s *Service) Login(ctx context.Context, email, password string) (*LoginResu
 	}, nil
 }
 
+// startSession registers a new session for the user and returns an access
+// token scoped to tenantID along with the session's first refresh token
+func (s *Service) startSession(ctx context.Context, userID, tenantID string, client auth.Client) (string, string, error) {
+	sessionID, refreshToken, err := s.sessions.Start(ctx, auth.SubjectUser, userID, tenantID, client)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := utils.GenerateUserToken(userID, tenantID, sessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+// SwitchTenant moves the current session to tenantID and returns a new access
+// token and refresh token for it
+func (s *Service) SwitchTenant(ctx context.Context, userID, sessionID, tenantID string) (string, string, error) {
+	refreshToken, err := s.sessions.Rescope(ctx, auth.SubjectUser, userID, sessionID, tenantID)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := utils.GenerateUserToken(userID, tenantID, sessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+// Refresh rotates the refresh token and issues a new access token for the
+// tenant the refresh token was issued for
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	grant, newRefresh, err := s.sessions.Rotate(ctx, auth.SubjectUser, "", refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, grant.SubjectID)
+	if err != nil || user.Status != "active" {
+		_ = s.sessions.RevokeAll(ctx, auth.SubjectUser, grant.SubjectID, "")
+		return "", "", auth.ErrInvalidRefreshToken
+	}
+
+	// Drop the tenant scope if the user was removed from the tenant meanwhile
+	tenantID := grant.TenantID
+	if tenantID != "" && !s.repo.IsMember(ctx, user.ID, tenantID) {
+		tenantID = ""
+	}
+
+	token, err := utils.GenerateUserToken(user.ID, tenantID, grant.SessionID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return token, newRefresh, nil
+}
+
+// SetupMFA starts 2FA enrollment for the user
+func (s *Service) SetupMFA(ctx context.Context, userID string) (*auth.Enrollment, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user_not_found")
+	}
+	return s.mfa.Setup(ctx, auth.SubjectUser, user.ID, user.Email)
+}
+
+// EnableMFA confirms the pending secret and returns the recovery codes
+func (s *Service) EnableMFA(ctx context.Context, userID, code, ip string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user_not_found")
+	}
+	if err := s.guard.Check(ctx, auth.SubjectUser, user.Email, ip); err != nil {
+		return nil, err
+	}
+	codes, err := s.mfa.Enable(ctx, auth.SubjectUser, userID, code)
+	if err != nil {
+		return nil, s.mfaFailed(ctx, user.Email, ip, err)
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the user's second factor after checking the password and a code
+func (s *Service) DisableMFA(ctx context.Context, userID, password, code, ip string) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user_not_found")
+	}
+	if err := s.guard.Check(ctx, auth.SubjectUser, user.Email, ip); err != nil {
+		return err
+	}
+	if !utils.CheckPassword(password, user.HashPass) {
+		return errors.New("invalid_current_password")
+	}
+	if err := s.mfa.Verify(ctx, auth.SubjectUser, userID, code); err != nil {
+		return s.mfaFailed(ctx, user.Email, ip, err)
+	}
+	return s.mfa.Disable(ctx, auth.SubjectUser, userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID, code, ip string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user_not_found")
+	}
+	if err := s.guard.Check(ctx, auth.SubjectUser, user.Email, ip); err != nil {
+		return nil, err
+	}
+	if err := s.mfa.Verify(ctx, auth.SubjectUser, userID, code); err != nil {
+		return nil, s.mfaFailed(ctx, user.Email, ip, err)
+	}
+	return s.mfa.RegenerateRecoveryCodes(ctx, auth.SubjectUser, userID)
+}
+
+// mfaFailed counts a wrong 2FA code as a failed login of the user, so codes
+// cannot be guessed faster than passwords
+func (s *Service) mfaFailed(ctx context.Context, email, ip string, err error) error {
+	if errors.Is(err, auth.ErrInvalidMFACode) {
+		return s.guard.Fail(ctx, auth.SubjectUser, email, ip, err)
+	}
+	return err
+}
+
+// Logout ends the current session
+func (s *Service) Logout(ctx context.Context, userID, sessionID string) error {
+	return s.sessions.Revoke(ctx, auth.SubjectUser, userID, sessionID)
+}
+
+// ListSessions returns the active sessions of the user
+func (s *Service) ListSessions(ctx context.Context, userID, currentID string) ([]auth.Session, error) {
+	return s.sessions.List(ctx, auth.SubjectUser, userID, currentID)
+}
+
+// RevokeSession ends one of the user's sessions
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.sessions.Revoke(ctx, auth.SubjectUser, userID, sessionID)
+}
+
+// RevokeOtherSessions ends every session of the user except the current one
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID, currentID string) error {
+	return s.sessions.RevokeAll(ctx, auth.SubjectUser, userID, currentID)
+}
+
 func (s *Service) GetMe(ctx context.Context, userID string) (map[string]interface{}, error) {
 	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -363,79 +699,724 @@ func (s *Service) IsOwner(ctx context.Context, userID, tenantID string) bool {
 
 // --- Members ---
 
-func (s *Service) CanAddMember(ctx context.Context, tenantID string) (bool, int, int, error) {
+// MemberCapacity reports how many seats of the plan are taken. Pending
+// invitations reserve a seat until they are accepted, revoked or expire.
+type MemberCapacity struct {
+	CanAdd         bool
+	MaxUsers       int
+	Members        int
+	PendingInvites int
+}
+
+func (s *Service) CanAddMember(ctx context.Context, tenantID string) (*MemberCapacity, error) {
 	plan, err := s.repo.GetActiveTenantPlan(ctx, tenantID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return false, 0, 0, errors.New("no_active_plan")
+			return nil, errors.New("no_active_plan")
 		}
-		return false, 0, 0, err
+		return nil, err
 	}
 
 	count, err := s.repo.CountTenantMembers(ctx, tenantID)
 	if err != nil {
-		return false, 0, 0, err
+		return nil, err
+	}
+	pending, err := s.repo.CountPendingInvitations(ctx, tenantID)
+	if err != nil {
+		return nil, err
 	}
 
-	canAdd := count < plan.MaxUsers
-	return canAdd, plan.MaxUsers, count, nil
+	return &MemberCapacity{
+		CanAdd:         count+pending < plan.MaxUsers,
+		MaxUsers:       plan.MaxUsers,
+		Members:        count,
+		PendingInvites: pending,
+	}, nil
 }
 
-func (s *Service) InviteMember(ctx context.Context, tenantID, email, name, password, roleSlug string) (string, error) {
-	canAdd, _, _, err := s.CanAddMember(ctx, tenantID)
+// invitationTTL is how long an invitation link stays valid
+const invitationTTL = 7 * 24 * time.Hour
+
+// InviteMember creates a pending invitation and emails its link. The invitee
+// becomes a member only after accepting it.
+func (s *Service) InviteMember(ctx context.Context, tenantID, inviterID, email, name, roleSlug, language string) (string, time.Time, error) {
+	capacity, err := s.CanAddMember(ctx, tenantID)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
-	if !canAdd {
-		return "", fmt.Errorf("max_users_reached")
+	if !capacity.CanAdd {
+		return "", time.Time{}, errors.New("max_users_reached")
 	}
 
-	// Check if user exists
-	user, _ := s.repo.GetUserByEmail(ctx, email)
-	var userID string
+	if user, err := s.repo.GetUserByEmail(ctx, email); err == nil && s.repo.IsMember(ctx, user.ID, tenantID) {
+		return "", time.Time{}, errors.New("user_already_member")
+	}
+	if s.repo.HasPendingInvitation(ctx, tenantID, email) {
+		return "", time.Time{}, errors.New("invitation_already_pending")
+	}
+
+	roleID, err := s.repo.GetTenantRoleBySlug(ctx, tenantID, roleSlug)
+	if err != nil {
+		return "", time.Time{}, errors.New("role_not_found_tenant")
+	}
+
+	token := utils.GenerateVerificationToken()
+	expiresAt := time.Now().Add(invitationTTL)
+	id, err := s.repo.CreateInvitation(ctx, tenantID, email, name, &roleID, inviterID, utils.HashToken(token), expiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("create invitation: %w", err)
+	}
+
+	s.sendInvitation(tenantID, inviterID, email, token, language)
+	return id, expiresAt, nil
+}
+
+// ListInvitations returns the tenant's open invitations
+func (s *Service) ListInvitations(ctx context.Context, tenantID string) (interface{}, error) {
+	return s.repo.ListInvitations(ctx, tenantID)
+}
+
+// ResendInvitation issues a new link for an open invitation, invalidating the
+// previous one, and emails it again. Expired invitations are renewed if the
+// plan still has a free seat.
+func (s *Service) ResendInvitation(ctx context.Context, tenantID, inviterID, invitationID, language string) (time.Time, error) {
+	inv, err := s.repo.GetInvitation(ctx, tenantID, invitationID)
+	if err != nil {
+		return time.Time{}, errors.New("invitation_not_found")
+	}
+	if inv.Expired {
+		capacity, err := s.CanAddMember(ctx, tenantID)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !capacity.CanAdd {
+			return time.Time{}, errors.New("max_users_reached")
+		}
+	}
+
+	token := utils.GenerateVerificationToken()
+	expiresAt := time.Now().Add(invitationTTL)
+	if err := s.repo.RenewInvitation(ctx, tenantID, invitationID, utils.HashToken(token), expiresAt); err != nil {
+		return time.Time{}, errors.New("invitation_not_found")
+	}
+
+	s.sendInvitation(tenantID, inviterID, inv.Email, token, language)
+	return expiresAt, nil
+}
+
+// RevokeInvitation cancels an open invitation and frees its seat
+func (s *Service) RevokeInvitation(ctx context.Context, tenantID, invitationID string) error {
+	if err := s.repo.RevokeInvitation(ctx, tenantID, invitationID); err != nil {
+		return errors.New("invitation_not_found")
+	}
+	return nil
+}
+
+// sendInvitation emails the invitation link (async, don't block on failure)
+func (s *Service) sendInvitation(tenantID, inviterID, email, token, language string) {
+	if s.emailService == nil {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		tenant, err := s.repo.GetTenantByID(ctx, tenantID)
+		if err != nil {
+			return
+		}
+		inviterName := tenant.Name
+		if inviter, err := s.repo.GetUserByID(ctx, inviterID); err == nil {
+			inviterName = inviter.Name
+		}
+		vars := map[string]string{
+			"tenant_name":  tenant.Name,
+			"inviter_name": inviterName,
+			"invite_url":   fmt.Sprintf("%s/accept-invite?token=%s", s.emailService.BaseURL(), token),
+			"expires_days": strconv.Itoa(int(invitationTTL.Hours() / 24)),
+		}
+		_ = s.emailService.SendMemberInvite(ctx, email, language, vars)
+	}()
+}
+
+// InvitationInfo is what the accept page shows about an invitation
+type InvitationInfo struct {
+	TenantName    string    `json:"tenant_name"`
+	URLCode       string    `json:"url_code"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	RoleTitle     *string   `json:"role_title"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	AccountExists bool      `json:"account_exists"`
+}
+
+// GetInvitationByToken describes an invitation that can still be accepted.
+// AccountExists tells whether accepting links an existing account or needs a password.
+func (s *Service) GetInvitationByToken(ctx context.Context, token string) (*InvitationInfo, error) {
+	inv, err := s.repo.GetInvitationByToken(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, errors.New("invalid_invitation")
+	}
+	_, err = s.repo.GetUserByEmail(ctx, inv.Email)
+	return &InvitationInfo{
+		TenantName:    inv.TenantName,
+		URLCode:       inv.URLCode,
+		Email:         inv.Email,
+		Name:          inv.Name,
+		RoleTitle:     inv.RoleTitle,
+		ExpiresAt:     inv.ExpiresAt,
+		AccountExists: err == nil,
+	}, nil
+}
+
+// AcceptInvitationResult describes the membership created by an accepted invitation
+type AcceptInvitationResult struct {
+	UserID         string
+	URLCode        string
+	AccountCreated bool
+}
+
+// AcceptInvitation adds the invitee to the tenant. When a user with the
+// invited email exists the membership is linked to that account; otherwise
+// an account is created with the given name and password.
+func (s *Service) AcceptInvitation(ctx context.Context, token, name, password string) (*AcceptInvitationResult, error) {
+	tokenHash := utils.HashToken(token)
+	inv, err := s.repo.GetInvitationByToken(ctx, tokenHash)
+	if err != nil {
+		return nil, errors.New("invalid_invitation")
+	}
+
+	user, _ := s.repo.GetUserByEmail(ctx, inv.Email)
+	if user == nil && password == "" {
+		return nil, errors.New("password_required")
+	}
 
 	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
+	claimed, err := s.repo.ClaimInvitation(ctx, tx, inv.ID, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errors.New("invalid_invitation")
+	}
+
+	result := &AcceptInvitationResult{URLCode: inv.URLCode}
 	if user != nil {
-		userID = user.ID
-		// Check if already a member
-		if s.repo.IsMember(ctx, userID, tenantID) {
-			return "", errors.New("user_already_member")
+		if s.repo.IsMember(ctx, user.ID, inv.TenantID) {
+			return nil, errors.New("user_already_member")
 		}
+		result.UserID = user.ID
 	} else {
+		if name == "" {
+			name = inv.Name
+		}
 		hashPass, err := utils.HashPassword(password)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		tenant, err := s.repo.GetTenantByID(ctx, tenantID)
+		result.UserID, err = s.repo.CreateUser(ctx, tx, name, inv.Email, hashPass, inv.URLCode)
 		if err != nil {
-			return "", err
+			return nil, fmt.Errorf("create user: %w", err)
+		}
+		if err := s.repo.CreateUserProfile(ctx, tx, result.UserID, name); err != nil {
+			return nil, fmt.Errorf("create profile: %w", err)
 		}
-		userID, err = s.repo.CreateUser(ctx, tx, name, email, hashPass, tenant.URLCode)
+		if err := s.repo.SetEmailVerifiedTx(ctx, tx, result.UserID); err != nil {
+			return nil, err
+		}
+		result.AccountCreated = true
+	}
+
+	if err := s.repo.AddTenantMember(ctx, tx, result.UserID, inv.TenantID, inv.RoleID); err != nil {
+		return nil, fmt.Errorf("create member: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// --- Ownership Transfer ---
+
+// ownershipTransferTTL is how long the target member has to confirm a transfer
+const ownershipTransferTTL = 3 * 24 * time.Hour
+
+// StartOwnershipTransfer offers the tenant to another member, replacing any
+// open transfer, and emails the confirmation link to the target. Ownership
+// only changes once the target confirms.
+func (s *Service) StartOwnershipTransfer(ctx context.Context, tenantID, ownerID, targetID, language string) (string, time.Time, error) {
+	if targetID == ownerID {
+		return "", time.Time{}, errors.New("cannot_transfer_to_self")
+	}
+	target, err := s.repo.GetMember(ctx, tenantID, targetID)
+	if err != nil {
+		return "", time.Time{}, errors.New("member_not_found")
+	}
+	if target.Status != "active" {
+		return "", time.Time{}, errors.New("transfer_target_inactive")
+	}
+
+	token := utils.GenerateVerificationToken()
+	expiresAt := time.Now().Add(ownershipTransferTTL)
+	id, err := s.repo.CreateOwnershipTransfer(ctx, tenantID, ownerID, targetID, utils.HashToken(token), expiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("create ownership transfer: %w", err)
+	}
+
+	s.sendOwnershipTransfer(tenantID, ownerID, target.Email, token, language)
+	return id, expiresAt, nil
+}
+
+// GetOwnershipTransfer returns the tenant's open transfer
+func (s *Service) GetOwnershipTransfer(ctx context.Context, tenantID string) (interface{}, error) {
+	transfer, err := s.repo.GetOpenOwnershipTransfer(ctx, tenantID)
+	if err != nil {
+		return nil, errors.New("ownership_transfer_not_found")
+	}
+	return transfer, nil
+}
+
+// CancelOwnershipTransfer withdraws the tenant's open transfer
+func (s *Service) CancelOwnershipTransfer(ctx context.Context, tenantID string) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cancelled, err := s.repo.CancelOwnershipTransfers(ctx, tx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("ownership_transfer_not_found")
+	}
+	return tx.Commit(ctx)
+}
+
+// sendOwnershipTransfer emails the confirmation link (async, don't block on failure)
+func (s *Service) sendOwnershipTransfer(tenantID, ownerID, email, token, language string) {
+	if s.emailService == nil {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		tenant, err := s.repo.GetTenantByID(ctx, tenantID)
 		if err != nil {
-			return "", fmt.Errorf("create user: %w", err)
+			return
 		}
-		if err := s.repo.CreateUserProfile(ctx, tx, userID, name); err != nil {
-			return "", fmt.Errorf("create profile: %w", err)
+		ownerName := tenant.Name
+		if owner, err := s.repo.GetUserByID(ctx, ownerID); err == nil {
+			ownerName = owner.Name
 		}
+		vars := map[string]string{
+			"tenant_name":  tenant.Name,
+			"owner_name":   ownerName,
+			"confirm_url":  fmt.Sprintf("%s/confirm-ownership?token=%s", s.emailService.BaseURL(), token),
+			"expires_days": strconv.Itoa(int(ownershipTransferTTL.Hours() / 24)),
+		}
+		_ = s.emailService.SendOwnershipTransfer(ctx, email, language, vars)
+	}()
+}
+
+// ConfirmOwnershipTransfer makes userID the owner of the transfer's tenant.
+// The token alone is not enough: it must be confirmed by the target member
+// while logged in, and the member who started it must still be the owner.
+// It returns the tenant's url_code.
+func (s *Service) ConfirmOwnershipTransfer(ctx context.Context, token, userID string) (string, error) {
+	tokenHash := utils.HashToken(token)
+	transfer, err := s.repo.GetOwnershipTransferByToken(ctx, tokenHash)
+	if err != nil {
+		return "", errors.New("invalid_ownership_transfer")
+	}
+	if transfer.ToUserID != userID {
+		return "", errors.New("ownership_transfer_other_user")
+	}
+	tenant, err := s.repo.GetTenantByID(ctx, transfer.TenantID)
+	if err != nil {
+		return "", errors.New("invalid_ownership_transfer")
 	}
 
-	roleID, err := s.repo.GetTenantRoleBySlugTx(ctx, tx, tenantID, roleSlug)
+	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
-		return "", fmt.Errorf("role_not_found_tenant")
+		return "", err
 	}
+	defer tx.Rollback(ctx)
 
-	if err := s.repo.CreateTenantMember(ctx, tx, userID, tenantID, &roleID, false); err != nil {
-		return "", fmt.Errorf("create member: %w", err)
+	claimed, err := s.repo.ClaimOwnershipTransfer(ctx, tx, transfer.ID, tokenHash)
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return "", errors.New("invalid_ownership_transfer")
+	}
+
+	previousOwnerID, err := s.repo.SwapOwner(ctx, tx, transfer.TenantID, transfer.FromUserID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errors.New("invalid_ownership_transfer")
+		}
+		return "", fmt.Errorf("swap owner: %w", err)
+	}
+	if err := s.repo.CreateOwnershipAudit(ctx, tx, transfer.TenantID, previousOwnerID, userID, &transfer.ID, nil, nil); err != nil {
+		return "", fmt.Errorf("audit ownership transfer: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return "", err
 	}
+	return tenant.URLCode, nil
+}
+
+// --- Images ---
+
+// EnqueueImage queues an uploaded image for processing by worker-images
+func (s *Service) EnqueueImage(ctx context.Context, imageID string) error {
+	return s.images.Enqueue(ctx, imageID)
+}
+
+// ReplaceAvatar makes a validated upload the user's avatar, deleting the
+// previous one. Avatars belong to the user, not to a tenant.
+func (s *Service) ReplaceAvatar(ctx context.Context, userID string, file io.Reader, info *upload.Image, filename string) (*profileimage.Picture, error) {
+	return s.profiles.Replace(ctx, profileimage.Owner{Type: profileimage.UserAvatar, ID: userID}, file, info, filename)
+}
+
+// ReplaceLogo makes a validated upload the tenant's logo, deleting the
+// previous one
+func (s *Service) ReplaceLogo(ctx context.Context, tenantID string, file io.Reader, info *upload.Image, filename string) (*profileimage.Picture, error) {
+	return s.profiles.Replace(ctx, profileimage.Owner{Type: profileimage.TenantLogo, ID: tenantID, TenantID: tenantID}, file, info, filename)
+}
+
+// ImageVariants is the variant configuration of a tenant
+type ImageVariants struct {
+	Variants  []imagevariant.Preset `json:"variants"`
+	IsDefault bool                  `json:"is_default"`
+	Limits    imagevariant.Limits   `json:"limits"`
+}
+
+// GetImageVariants returns the presets the worker renders for the tenant,
+// within the limits of its current plan
+func (s *Service) GetImageVariants(ctx context.Context, tenantID string) (*ImageVariants, error) {
+	presets, err := s.repo.GetImageVariantPresets(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.repo.GetImageLimits(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageVariants{
+		Variants:  imagevariant.Clamp(presets, limits),
+		IsDefault: presets == nil,
+		Limits:    limits,
+	}, nil
+}
+
+// SaveImageVariants replaces the tenant's presets; an empty list goes back
+// to the defaults. Existing images keep their variants until re-rendered.
+func (s *Service) SaveImageVariants(ctx context.Context, tenantID string, presets []imagevariant.Preset) error {
+	if len(presets) == 0 {
+		return s.repo.SaveImageVariantPresets(ctx, tenantID, nil)
+	}
+	limits, err := s.repo.GetImageLimits(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := imagevariant.Validate(presets, limits); err != nil {
+		return err
+	}
+	b, err := json.Marshal(presets)
+	if err != nil {
+		return err
+	}
+	return s.repo.SaveImageVariantPresets(ctx, tenantID, string(b))
+}
+
+// UploadLimits returns the limits uploads of the tenant are validated
+// against. A lookup failure falls back to the defaults rather than blocking
+// uploads.
+func (s *Service) UploadLimits(ctx context.Context, tenantID string) upload.Limits {
+	limits, err := s.repo.GetUploadLimits(ctx, tenantID)
+	if err != nil {
+		log.Printf("upload limits lookup failed for tenant %s: %v", tenantID, err)
+		return upload.DefaultLimits
+	}
+	return limits
+}
+
+// RerenderImages queues every processed image of the tenant so its variants
+// are generated again from the current presets
+func (s *Service) RerenderImages(ctx context.Context, tenantID string) (int, error) {
+	return s.images.RequeueTenant(ctx, tenantID, "completed")
+}
+
+// ReprocessImage queues one of the tenant's images to be processed again
+func (s *Service) ReprocessImage(ctx context.Context, tenantID, imageID string) error {
+	return s.images.Requeue(ctx, tenantID, imageID)
+}
+
+// ReprocessFailedImages queues every image of the tenant that failed
+// processing for another round of attempts
+func (s *Service) ReprocessFailedImages(ctx context.Context, tenantID string) (int, error) {
+	return s.images.RequeueTenant(ctx, tenantID, "failed")
+}
+
+// AppendImageEvent records a processing state change of an image in its
+// tenant's event log
+func (s *Service) AppendImageEvent(ctx context.Context, imageID, eventType, reason string) error {
+	return s.imageEvents.Append(ctx, imageID, eventType, reason)
+}
+
+// ImageEventsSince returns up to limit image events of the tenant after the
+// event afterID, oldest first
+func (s *Service) ImageEventsSince(ctx context.Context, tenantID string, afterID int64, limit int) ([]imageevents.Event, error) {
+	return s.imageEvents.Since(ctx, tenantID, afterID, limit)
+}
+
+// LatestImageEvent returns the id of the tenant's latest image event, 0 when
+// there is none
+func (s *Service) LatestImageEvent(ctx context.Context, tenantID string) (int64, error) {
+	return s.imageEvents.Latest(ctx, tenantID)
+}
+
+// SubscribeImageEvents listens for new image events of the tenant
+func (s *Service) SubscribeImageEvents(ctx context.Context, tenantID string) *redis.PubSub {
+	return s.imageEvents.Subscribe(ctx, tenantID)
+}
+
+// ReorderImages sets the display order of the images of a product or
+// service. imageIDs must list each of its images exactly once.
+func (s *Service) ReorderImages(ctx context.Context, tenantID, imageableType, imageableID string, imageIDs []string) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := s.repo.LockImageIDs(ctx, tx, tenantID, imageableType, imageableID)
+	if err != nil {
+		return err
+	}
+	if len(current) != len(imageIDs) {
+		return errors.New("invalid_image_order")
+	}
+	remaining := make(map[string]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range imageIDs {
+		if !remaining[id] {
+			return errors.New("invalid_image_order")
+		}
+		delete(remaining, id)
+	}
+
+	if err := s.repo.SetImageOrder(ctx, tx, tenantID, imageIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// --- Tenant context ---
+
+// InvalidateTenantContext drops the tenant context cached by the tenant
+// middlewares, under every url_code, subdomain or domain it was resolved by
+func (s *Service) InvalidateTenantContext(ctx context.Context, tenantID string) {
+	s.tenants.Invalidate(ctx, tenantID)
+}
+
+// --- Domains ---
+
+// maxTenantDomains caps the custom domains a tenant can register
+const maxTenantDomains = 5
+
+// domainCheckTimeout bounds the DNS lookup of a verification
+const domainCheckTimeout = 10 * time.Second
+
+// TenantDomain is a custom domain with the TXT record that verifies it
+type TenantDomain struct {
+	ID            string     `json:"id"`
+	Domain        string     `json:"domain"`
+	Verified      bool       `json:"verified"`
+	VerifiedAt    *time.Time `json:"verified_at"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	RecordType    string     `json:"record_type"`
+	RecordName    string     `json:"record_name"`
+	RecordValue   string     `json:"record_value"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newTenantDomain(d *repo.DomainRow) TenantDomain {
+	return TenantDomain{
+		ID:            d.ID,
+		Domain:        d.Domain,
+		Verified:      d.VerifiedAt != nil,
+		VerifiedAt:    d.VerifiedAt,
+		LastCheckedAt: d.LastCheckedAt,
+		RecordType:    "TXT",
+		RecordName:    domains.ChallengeName(d.Domain),
+		RecordValue:   domains.ChallengeValue(d.VerificationToken),
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+// ListDomains returns the tenant's custom domains
+func (s *Service) ListDomains(ctx context.Context, tenantID string) ([]TenantDomain, error) {
+	rows, err := s.repo.ListTenantDomains(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]TenantDomain, len(rows))
+	for i := range rows {
+		list[i] = newTenantDomain(&rows[i])
+	}
+	return list, nil
+}
+
+// AddDomain registers a custom domain. It only routes to the tenant after
+// VerifyDomain finds its TXT record.
+func (s *Service) AddDomain(ctx context.Context, tenantID, domain string) (*TenantDomain, error) {
+	domain, err := s.domains.Normalize(domain)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repo.CountTenantDomains(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxTenantDomains {
+		return nil, errors.New("domain_limit_reached")
+	}
+	if s.repo.IsDomainVerifiedByOther(ctx, tenantID, domain) {
+		return nil, errors.New("domain_in_use")
+	}
+
+	row, err := s.repo.CreateTenantDomain(ctx, tenantID, domain, utils.GenerateVerificationToken())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("domain_already_added")
+		}
+		return nil, fmt.Errorf("create domain: %w", err)
+	}
+	d := newTenantDomain(row)
+	return &d, nil
+}
+
+// VerifyDomain looks up the domain's TXT record and marks it verified when
+// the record is found. A verified domain starts routing to the tenant at once.
+func (s *Service) VerifyDomain(ctx context.Context, tenantID, domainID string) (*TenantDomain, error) {
+	row, err := s.repo.GetTenantDomain(ctx, tenantID, domainID)
+	if err != nil {
+		return nil, errors.New("domain_not_found")
+	}
+	if row.VerifiedAt == nil && s.repo.IsDomainVerifiedByOther(ctx, tenantID, row.Domain) {
+		return nil, errors.New("domain_in_use")
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, domainCheckTimeout)
+	defer cancel()
+	verified, err := s.domains.Verify(lookupCtx, row.Domain, row.VerificationToken)
+	if err != nil {
+		return nil, errors.New("domain_lookup_failed")
+	}
+
+	row, err = s.repo.RecordDomainCheck(ctx, tenantID, domainID, verified)
+	if err != nil {
+		return nil, fmt.Errorf("record domain check: %w", err)
+	}
+	d := newTenantDomain(row)
+	return &d, nil
+}
+
+// RemoveDomain deletes a custom domain and stops routing it
+func (s *Service) RemoveDomain(ctx context.Context, tenantID, domainID string) error {
+	if _, err := s.repo.GetTenantDomain(ctx, tenantID, domainID); err != nil {
+		return errors.New("domain_not_found")
+	}
+	if err := s.repo.DeleteTenantDomain(ctx, tenantID, domainID); err != nil {
+		return errors.New("domain_not_found")
+	}
+	s.tenants.Invalidate(ctx, tenantID)
+	return nil
+}
+
+// --- Webhooks ---
+
+// maxWebhookEndpoints limits how many endpoints a tenant can register
+const maxWebhookEndpoints = 10
+
+// EmitWebhook queues eventType for the tenant's subscribed endpoints. Failing
+// to queue it must not fail the change that caused it, so errors are logged.
+func (s *Service) EmitWebhook(ctx context.Context, tenantID, eventType string, data interface{}) {
+	if err := s.webhooks.Emit(ctx, tenantID, eventType, data); err != nil {
+		log.Printf("Error queueing webhook %s for tenant %s: %v", eventType, tenantID, err)
+	}
+}
+
+func (s *Service) ListWebhooks(ctx context.Context, tenantID string) ([]webhooks.Endpoint, error) {
+	return s.webhooks.ListEndpoints(ctx, tenantID)
+}
+
+func (s *Service) GetWebhook(ctx context.Context, tenantID, endpointID string) (*webhooks.Endpoint, error) {
+	return s.webhooks.GetEndpoint(ctx, tenantID, endpointID)
+}
+
+// CreateWebhook registers an endpoint. The returned endpoint carries its
+// signing secret, which is not shown again.
+func (s *Service) CreateWebhook(ctx context.Context, tenantID, endpointURL string, description *string, events []string) (*webhooks.Endpoint, error) {
+	if err := webhooks.CheckURL(ctx, endpointURL); err != nil {
+		return nil, err
+	}
+	if !webhooks.ValidEvents(events) {
+		return nil, errors.New("invalid_webhook_events")
+	}
+	existing, err := s.webhooks.ListEndpoints(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhookEndpoints {
+		return nil, errors.New("webhook_limit_reached")
+	}
+	return s.webhooks.CreateEndpoint(ctx, tenantID, endpointURL, description, events)
+}
+
+func (s *Service) UpdateWebhook(ctx context.Context, tenantID, endpointID string, endpointURL, description *string, events []string, isActive *bool) error {
+	if endpointURL != nil {
+		if err := webhooks.CheckURL(ctx, *endpointURL); err != nil {
+			return err
+		}
+	}
+	if events != nil && !webhooks.ValidEvents(events) {
+		return errors.New("invalid_webhook_events")
+	}
+	return s.webhooks.UpdateEndpoint(ctx, tenantID, endpointID, endpointURL, description, events, isActive)
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, tenantID, endpointID string) error {
+	return s.webhooks.DeleteEndpoint(ctx, tenantID, endpointID)
+}
+
+func (s *Service) RotateWebhookSecret(ctx context.Context, tenantID, endpointID string) (string, error) {
+	return s.webhooks.RotateSecret(ctx, tenantID, endpointID)
+}
+
+// ListWebhookDeliveries returns the deliveries of one of the tenant's endpoints
+func (s *Service) ListWebhookDeliveries(ctx context.Context, tenantID, endpointID, status string, limit, offset int) ([]webhooks.Delivery, int, error) {
+	if _, err := s.webhooks.GetEndpoint(ctx, tenantID, endpointID); err != nil {
+		return nil, 0, err
+	}
+	return s.webhooks.ListDeliveries(ctx, tenantID, endpointID, status, limit, offset)
+}
+
+func (s *Service) GetWebhookDelivery(ctx context.Context, tenantID, endpointID, deliveryID string) (*webhooks.Delivery, error) {
+	return s.webhooks.GetDelivery(ctx, tenantID, endpointID, deliveryID)
+}
 
-	return userID, nil
+// RedeliverWebhook queues a delivery to be sent again, whatever its status
+func (s *Service) RedeliverWebhook(ctx context.Context, tenantID, endpointID, deliveryID string) error {
+	return s.webhooks.Redeliver(ctx, tenantID, endpointID, deliveryID)
 }
@@ -9,7 +9,7 @@ import (
 func NewProvider(cfg *config.Config) (Provider, error) {
 	switch cfg.StorageProvider {
 	case "local":
-		return NewLocalProvider(cfg.StorageLocalPath, cfg.StorageBaseURL), nil
+		return NewLocalProvider(cfg.StorageLocalPath, cfg.StorageBaseURL, cfg.StorageSigningKey), nil
 	case "s3":
 		return NewS3Provider(cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, cfg.AWSRegion, cfg.AWSBucket)
 	case "r2":
@@ -1,13 +1,76 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"io"
 	"mime/multipart"
+	"strings"
+	"time"
 )
 
 // Provider defines the interface for file storage operations
 type Provider interface {
 	Upload(file multipart.File, header *multipart.FileHeader, path string) (publicURL string, storagePath string, err error)
+	// Put writes body at storagePath, replacing any existing file, and
+	// returns its public URL
+	Put(ctx context.Context, storagePath string, body io.Reader, contentType string) (publicURL string, err error)
 	Delete(storagePath string) error
 	GetReader(storagePath string) (io.ReadCloser, error)
+	// URL returns the public URL of the file at storagePath
+	URL(storagePath string) string
+	// List returns every file whose storage path starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// PresignPut returns a URL the client can PUT a file to for expires,
+	// without going through the API, and the headers it must send along.
+	// Providers that can enforce maxBytes reject larger bodies; the stored
+	// file must be checked with Stat either way.
+	PresignPut(ctx context.Context, storagePath, contentType string, maxBytes int64, expires time.Duration) (url string, headers map[string]string, err error)
+	// SignedURL returns a URL the file at storagePath can be downloaded from
+	// for expires, whether or not it is publicly readable
+	SignedURL(ctx context.Context, storagePath string, expires time.Duration) (string, error)
+}
+
+// PrivatePrefix starts the path of every private file. Public files are
+// served to anyone; private ones only through SignedURL, so a bucket policy
+// granting public reads must exclude this prefix.
+const PrivatePrefix = "private/"
+
+// IsPrivate reports whether storagePath is a private file
+func IsPrivate(storagePath string) bool {
+	return strings.HasPrefix(storagePath, PrivatePrefix)
+}
+
+// Copy writes the file at from to to and returns the new file's public URL
+func Copy(ctx context.Context, p Provider, from, to, contentType string) (string, error) {
+	r, err := p.GetReader(from)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return p.Put(ctx, to, r, contentType)
+}
+
+// ErrNotFound is returned by Stat when there is no file at the path
+var ErrNotFound = errors.New("storage: file not found")
+
+// Object is a stored file as returned by List
+type Object struct {
+	Path     string
+	Size     int64
+	Modified time.Time
+}
+
+// Stat returns the file stored at storagePath, or ErrNotFound
+func Stat(ctx context.Context, p Provider, storagePath string) (*Object, error) {
+	objects, err := p.List(ctx, storagePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		if o.Path == storagePath {
+			return &o, nil
+		}
+	}
+	return nil, ErrNotFound
 }
@@ -1,22 +1,43 @@
 package storage
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type LocalProvider struct {
-	basePath string
-	baseURL  string
+	basePath   string
+	baseURL    string
+	signingKey string
 }
 
-func NewLocalProvider(basePath, baseURL string) *LocalProvider {
-	return &LocalProvider{basePath: basePath, baseURL: baseURL}
+// Errors of the local signed URLs
+var (
+	ErrPresignUnavailable = errors.New("storage: STORAGE_SIGNING_KEY is not set")
+	ErrInvalidSignature   = errors.New("storage: invalid or expired signature")
+)
+
+// NewLocalProvider creates a provider storing files under basePath and
+// served at baseURL. signingKey signs direct upload and download URLs;
+// without it PresignPut and SignedURL fail.
+func NewLocalProvider(basePath, baseURL, signingKey string) *LocalProvider {
+	return &LocalProvider{basePath: basePath, baseURL: baseURL, signingKey: signingKey}
 }
 
 func (l *LocalProvider) Upload(file multipart.File, header *multipart.FileHeader, path string) (string, string, error) {
@@ -39,8 +60,43 @@ func (l *LocalProvider) Upload(file multipart.File, header *multipart.FileHeader
 		return "", "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	publicURL := fmt.Sprintf("%s/%s", l.baseURL, storagePath)
-	return publicURL, storagePath, nil
+	return l.URL(storagePath), storagePath, nil
+}
+
+func (l *LocalProvider) Put(ctx context.Context, storagePath string, body io.Reader, contentType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	fullPath := filepath.Join(l.basePath, storagePath)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it so readers never see a
+	// partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".put-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	return l.URL(storagePath), nil
 }
 
 func (l *LocalProvider) Delete(storagePath string) error {
@@ -52,3 +108,122 @@ func (l *LocalProvider) GetReader(storagePath string) (io.ReadCloser, error) {
 	fullPath := filepath.Join(l.basePath, storagePath)
 	return os.Open(fullPath)
 }
+
+func (l *LocalProvider) List(ctx context.Context, prefix string) ([]Object, error) {
+	// Walk the directory the prefix ends in; the rest of the prefix filters
+	// file names within it
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	root := filepath.Join(l.basePath, filepath.FromSlash(dir))
+
+	var objects []Object
+	err := filepath.WalkDir(root, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.basePath, fullPath)
+		if err != nil {
+			return err
+		}
+		storagePath := filepath.ToSlash(rel)
+		if !strings.HasPrefix(storagePath, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Path: storagePath, Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	return objects, err
+}
+
+// PresignPut returns the file's own URL with a signed query. The API serving
+// baseURL accepts a PUT there once VerifyPut passes.
+func (l *LocalProvider) PresignPut(ctx context.Context, storagePath, contentType string, maxBytes int64, expires time.Duration) (string, map[string]string, error) {
+	if l.signingKey == "" {
+		return "", nil, ErrPresignUnavailable
+	}
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	q.Set("max", strconv.FormatInt(maxBytes, 10))
+	q.Set("type", contentType)
+	q.Set("signature", l.sign(http.MethodPut, storagePath, q))
+	return l.URL(storagePath) + "?" + q.Encode(), map[string]string{"Content-Type": contentType}, nil
+}
+
+// VerifyPut checks the query of a URL made by PresignPut for storagePath and
+// returns the content type and size limit it was signed with
+func (l *LocalProvider) VerifyPut(storagePath string, q url.Values) (contentType string, maxBytes int64, err error) {
+	if err := l.verify(http.MethodPut, storagePath, q); err != nil {
+		return "", 0, err
+	}
+	maxBytes, err = strconv.ParseInt(q.Get("max"), 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidSignature
+	}
+	return q.Get("type"), maxBytes, nil
+}
+
+// SignedURL returns the file's own URL with a signed query. The API serving
+// baseURL only serves private files once VerifyGet passes.
+func (l *LocalProvider) SignedURL(ctx context.Context, storagePath string, expires time.Duration) (string, error) {
+	if l.signingKey == "" {
+		return "", ErrPresignUnavailable
+	}
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	q.Set("signature", l.sign(http.MethodGet, storagePath, q))
+	return l.URL(storagePath) + "?" + q.Encode(), nil
+}
+
+// VerifyGet checks the query of a URL made by SignedURL for storagePath
+func (l *LocalProvider) VerifyGet(storagePath string, q url.Values) error {
+	return l.verify(http.MethodGet, storagePath, q)
+}
+
+// ServeFile writes the file at storagePath to w, or a 404 if there is none.
+// storagePath must be clean; directories are never listed.
+func (l *LocalProvider) ServeFile(w http.ResponseWriter, r *http.Request, storagePath string) {
+	fullPath := filepath.Join(l.basePath, filepath.FromSlash(storagePath))
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, fullPath)
+}
+
+func (l *LocalProvider) verify(method, storagePath string, q url.Values) error {
+	if l.signingKey == "" {
+		return ErrPresignUnavailable
+	}
+	if !hmac.Equal([]byte(l.sign(method, storagePath, q)), []byte(q.Get("signature"))) {
+		return ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// sign is the HMAC-SHA256 of the method, path and signed query values
+func (l *LocalProvider) sign(method, storagePath string, q url.Values) string {
+	mac := hmac.New(sha256.New, []byte(l.signingKey))
+	mac.Write([]byte(strings.Join([]string{method, storagePath, q.Get("expires"), q.Get("max"), q.Get("type")}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *LocalProvider) URL(storagePath string) string {
+	return fmt.Sprintf("%s/%s", l.baseURL, filepath.ToSlash(storagePath))
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) *LocalProvider {
+	t.Helper()
+	return NewLocalProvider(t.TempDir(), "http://localhost:8080/uploads", "test-signing-key")
+}
+
+func readFile(t *testing.T, l *LocalProvider, storagePath string) string {
+	t.Helper()
+	r, err := l.GetReader(storagePath)
+	if err != nil {
+		t.Fatalf("GetReader(%s): %v", storagePath, err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// failingReader returns some data, then an error, like a client that hangs
+// up mid-upload
+type failingReader struct{ sent bool }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.sent {
+		return 0, errors.New("connection reset")
+	}
+	f.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalPut(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	const p = "tenants/t1/images/a.webp"
+
+	u, err := l.Put(ctx, p, strings.NewReader("first"), "image/webp")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if u != "http://localhost:8080/uploads/"+p {
+		t.Errorf("URL = %s", u)
+	}
+	if got := readFile(t, l, p); got != "first" {
+		t.Errorf("content = %q, want %q", got, "first")
+	}
+
+	if _, err := l.Put(ctx, p, strings.NewReader("second"), "image/webp"); err != nil {
+		t.Fatalf("Put over existing file: %v", err)
+	}
+	if got := readFile(t, l, p); got != "second" {
+		t.Errorf("content = %q, want %q", got, "second")
+	}
+
+	// A failed write leaves the previous file whole and no temporary file
+	if _, err := l.Put(ctx, p, &failingReader{}, "image/webp"); err == nil {
+		t.Fatal("Put with a failing body succeeded")
+	}
+	if got := readFile(t, l, p); got != "second" {
+		t.Errorf("content after failed Put = %q, want %q", got, "second")
+	}
+	entries, err := os.ReadDir(filepath.Join(l.basePath, "tenants/t1/images"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only a.webp", names)
+	}
+
+	info, err := os.Stat(filepath.Join(l.basePath, p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := l.Put(cancelled, "tenants/t1/images/b.webp", strings.NewReader("x"), "image/webp"); err == nil {
+		t.Error("Put with a cancelled context succeeded")
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	for _, p := range []string{
+		"tenants/a/x.jpg",
+		"tenants/a/sub/z.jpg",
+		"tenants/ab/y.jpg",
+		"tenants/b/w.jpg",
+		"avatars/v.jpg",
+	} {
+		if _, err := l.Put(ctx, p, strings.NewReader(p), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"tenants/a/", []string{"tenants/a/sub/z.jpg", "tenants/a/x.jpg"}},
+		{"tenants/a", []string{"tenants/a/sub/z.jpg", "tenants/a/x.jpg", "tenants/ab/y.jpg"}},
+		{"tenants/a/x", []string{"tenants/a/x.jpg"}},
+		{"tenants/a/x.jpg", []string{"tenants/a/x.jpg"}},
+		{"tenants/", []string{"tenants/a/sub/z.jpg", "tenants/a/x.jpg", "tenants/ab/y.jpg", "tenants/b/w.jpg"}},
+		{"missing/", nil},
+		{"tenants/c", nil},
+	}
+	for _, tt := range tests {
+		objects, err := l.List(ctx, tt.prefix)
+		if err != nil {
+			t.Errorf("List(%q): %v", tt.prefix, err)
+			continue
+		}
+		var got []string
+		for _, o := range objects {
+			got = append(got, o.Path)
+			if o.Size != int64(len(o.Path)) {
+				t.Errorf("List(%q): %s has size %d, want %d", tt.prefix, o.Path, o.Size, len(o.Path))
+			}
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+
+	if _, err := Stat(ctx, l, "tenants/a/x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of a prefix = %v, want ErrNotFound", err)
+	}
+}
+
+func signedQuery(t *testing.T, signed string) url.Values {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestLocalPresignPut(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	const p = "tenants/t1/images/a.jpg"
+
+	signed, headers, err := l.PresignPut(ctx, p, "image/jpeg", 1024, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	if !strings.HasPrefix(signed, l.URL(p)+"?") || headers["Content-Type"] != "image/jpeg" {
+		t.Errorf("PresignPut = %s, %v", signed, headers)
+	}
+	q := signedQuery(t, signed)
+	contentType, maxBytes, err := l.VerifyPut(p, q)
+	if err != nil || contentType != "image/jpeg" || maxBytes != 1024 {
+		t.Errorf("VerifyPut = %q, %d, %v", contentType, maxBytes, err)
+	}
+
+	tampered := func(key, value string) url.Values {
+		c := url.Values{}
+		for k, v := range q {
+			c[k] = v
+		}
+		c.Set(key, value)
+		return c
+	}
+	tests := []struct {
+		name string
+		path string
+		q    url.Values
+	}{
+		{"other path", "tenants/t2/images/a.jpg", q},
+		{"raised limit", p, tampered("max", "1073741824")},
+		{"other type", p, tampered("type", "text/html")},
+		{"extended expiry", p, tampered("expires", "9999999999")},
+		{"bad signature", p, tampered("signature", strings.Repeat("0", 64))},
+		{"no signature", p, tampered("signature", "")},
+	}
+	for _, tt := range tests {
+		if _, _, err := l.VerifyPut(tt.path, tt.q); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: VerifyPut = %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+
+	// An upload URL does not grant downloads
+	if err := l.VerifyGet(p, q); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyGet with a PUT signature = %v, want ErrInvalidSignature", err)
+	}
+
+	other := NewLocalProvider(l.basePath, l.baseURL, "other-key")
+	if _, _, err := other.VerifyPut(p, q); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyPut with another key = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	const p = "private/tenants/t1/images/a.jpg"
+
+	signed, err := l.SignedURL(ctx, p, time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	q := signedQuery(t, signed)
+	if err := l.VerifyGet(p, q); err != nil {
+		t.Errorf("VerifyGet: %v", err)
+	}
+	if err := l.VerifyGet("private/tenants/t1/images/b.jpg", q); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyGet of another file = %v, want ErrInvalidSignature", err)
+	}
+	if _, _, err := l.VerifyPut(p, q); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyPut with a GET signature = %v, want ErrInvalidSignature", err)
+	}
+
+	expired, err := l.SignedURL(ctx, p, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.VerifyGet(p, signedQuery(t, expired)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyGet of an expired URL = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestLocalWithoutSigningKey(t *testing.T) {
+	l := NewLocalProvider(t.TempDir(), "http://localhost:8080/uploads", "")
+	ctx := context.Background()
+	if _, _, err := l.PresignPut(ctx, "a.jpg", "image/jpeg", 1, time.Minute); !errors.Is(err, ErrPresignUnavailable) {
+		t.Errorf("PresignPut = %v, want ErrPresignUnavailable", err)
+	}
+	if _, err := l.SignedURL(ctx, "a.jpg", time.Minute); !errors.Is(err, ErrPresignUnavailable) {
+		t.Errorf("SignedURL = %v, want ErrPresignUnavailable", err)
+	}
+	// An empty key must not verify an empty signature
+	if err := l.VerifyGet("a.jpg", url.Values{"expires": {"9999999999"}}); !errors.Is(err, ErrPresignUnavailable) {
+		t.Errorf("VerifyGet = %v, want ErrPresignUnavailable", err)
+	}
+}
@@ -1,15 +1,18 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"path/filepath"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/google/uuid"
 )
 
@@ -53,8 +56,22 @@ func (r *R2Provider) Upload(file multipart.File, header *multipart.FileHeader, p
 		return "", "", fmt.Errorf("failed to upload to R2: %w", err)
 	}
 
-	publicURL := fmt.Sprintf("%s/%s", r.publicURL, key)
-	return publicURL, key, nil
+	return r.URL(key), key, nil
+}
+
+func (r *R2Provider) Put(ctx context.Context, storagePath string, body io.Reader, contentType string) (string, error) {
+	// The uploader streams body in parts, so it need not be seekable
+	uploader := s3manager.NewUploaderWithClient(r.client)
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(r.bucket),
+		Key:         aws.String(storagePath),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to R2: %w", err)
+	}
+	return r.URL(storagePath), nil
 }
 
 func (r *R2Provider) Delete(storagePath string) error {
@@ -75,3 +92,19 @@ func (r *R2Provider) GetReader(storagePath string) (io.ReadCloser, error) {
 	}
 	return result.Body, nil
 }
+
+func (r *R2Provider) List(ctx context.Context, prefix string) ([]Object, error) {
+	return listObjects(ctx, r.client, r.bucket, prefix)
+}
+
+func (r *R2Provider) PresignPut(ctx context.Context, storagePath, contentType string, maxBytes int64, expires time.Duration) (string, map[string]string, error) {
+	return presignPut(ctx, r.client, r.bucket, storagePath, contentType, expires)
+}
+
+func (r *R2Provider) SignedURL(ctx context.Context, storagePath string, expires time.Duration) (string, error) {
+	return presignGet(ctx, r.client, r.bucket, storagePath, expires)
+}
+
+func (r *R2Provider) URL(storagePath string) string {
+	return fmt.Sprintf("%s/%s", r.publicURL, storagePath)
+}
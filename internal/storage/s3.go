@@ -1,15 +1,18 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"path/filepath"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/google/uuid"
 )
 
@@ -48,8 +51,22 @@ func (s *S3Provider) Upload(file multipart.File, header *multipart.FileHeader, p
 		return "", "", fmt.Errorf("failed to upload to S3: %w", err)
 	}
 
-	publicURL := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, key)
-	return publicURL, key, nil
+	return s.URL(key), key, nil
+}
+
+func (s *S3Provider) Put(ctx context.Context, storagePath string, body io.Reader, contentType string) (string, error) {
+	// The uploader streams body in parts, so it need not be seekable
+	uploader := s3manager.NewUploaderWithClient(s.client)
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(storagePath),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %w", err)
+	}
+	return s.URL(storagePath), nil
 }
 
 func (s *S3Provider) Delete(storagePath string) error {
@@ -70,3 +87,71 @@ func (s *S3Provider) GetReader(storagePath string) (io.ReadCloser, error) {
 	}
 	return result.Body, nil
 }
+
+func (s *S3Provider) List(ctx context.Context, prefix string) ([]Object, error) {
+	return listObjects(ctx, s.client, s.bucket, prefix)
+}
+
+// listObjects pages through the keys under prefix; shared by the
+// S3-compatible providers
+func listObjects(ctx context.Context, client *s3.S3, bucket, prefix string) ([]Object, error) {
+	var objects []Object
+	err := client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, o := range page.Contents {
+			objects = append(objects, Object{
+				Path:     aws.StringValue(o.Key),
+				Size:     aws.Int64Value(o.Size),
+				Modified: aws.TimeValue(o.LastModified),
+			})
+		}
+		return true
+	})
+	return objects, err
+}
+
+func (s *S3Provider) PresignPut(ctx context.Context, storagePath, contentType string, maxBytes int64, expires time.Duration) (string, map[string]string, error) {
+	return presignPut(ctx, s.client, s.bucket, storagePath, contentType, expires)
+}
+
+// presignPut signs a PutObject request; shared by the S3-compatible
+// providers. The content type is signed, so the upload must send it. S3
+// does not sign the length of a presigned PUT, so maxBytes is not enforced.
+func presignPut(ctx context.Context, client *s3.S3, bucket, key, contentType string, expires time.Duration) (string, map[string]string, error) {
+	req, _ := client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	req.SetContext(ctx)
+	url, err := req.Presign(expires)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+	return url, map[string]string{"Content-Type": contentType}, nil
+}
+
+func (s *S3Provider) SignedURL(ctx context.Context, storagePath string, expires time.Duration) (string, error) {
+	return presignGet(ctx, s.client, s.bucket, storagePath, expires)
+}
+
+// presignGet signs a GetObject request; shared by the S3-compatible
+// providers
+func presignGet(ctx context.Context, client *s3.S3, bucket, key string, expires time.Duration) (string, error) {
+	req, _ := client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	req.SetContext(ctx)
+	url, err := req.Presign(expires)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+	return url, nil
+}
+
+func (s *S3Provider) URL(storagePath string) string {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, storagePath)
+}
@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// fakeS3 is an in-memory bucket speaking the parts of the S3 REST API the
+// providers use: object PUT, GET and DELETE, multipart uploads and
+// ListObjectsV2. Listings are paged by pageSize keys to exercise paging.
+type fakeS3 struct {
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	uploads map[string]map[int][]byte
+	nextID  int
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{
+		bucket:   bucket,
+		pageSize: 2,
+		objects:  map[string][]byte{},
+		types:    map[string]string{},
+		uploads:  map[string]map[int][]byte{},
+	}
+}
+
+type listResult struct {
+	XMLName               xml.Name     `xml:"ListBucketResult"`
+	Name                  string       `xml:"Name"`
+	Prefix                string       `xml:"Prefix"`
+	KeyCount              int          `xml:"KeyCount"`
+	IsTruncated           bool         `xml:"IsTruncated"`
+	NextContinuationToken string       `xml:"NextContinuationToken,omitempty"`
+	Contents              []listObject `xml:"Contents"`
+}
+
+type listObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	Size         int    `xml:"Size"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, q.Get("prefix"), q.Get("continuation-token"))
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = map[int][]byte{}
+		f.types[key] = r.Header.Get("Content-Type")
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, bucket, key, id)
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		part, _ := strconv.Atoi(q.Get("partNumber"))
+		f.uploads[q.Get("uploadId")][part] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, part))
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		parts := f.uploads[q.Get("uploadId")]
+		numbers := make([]int, 0, len(parts))
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var object []byte
+		for _, n := range numbers {
+			object = append(object, parts[n]...)
+		}
+		f.objects[key] = object
+		delete(f.uploads, q.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"done"</ETag></CompleteMultipartUploadResult>`, bucket, key)
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(f.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Write(object)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		delete(f.types, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported", http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	start, _ := strconv.Atoi(token)
+	end := min(start+f.pageSize, len(keys))
+
+	result := listResult{Name: f.bucket, Prefix: prefix}
+	for _, k := range keys[start:end] {
+		result.Contents = append(result.Contents, listObject{
+			Key:          k,
+			LastModified: "2024-01-02T03:04:05.000Z",
+			Size:         len(f.objects[k]),
+		})
+	}
+	result.KeyCount = len(result.Contents)
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[key]
+	return object, ok
+}
+
+func (f *fakeS3) contentType(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.types[key]
+}
+
+func newTestS3(t *testing.T) (*S3Provider, *fakeS3) {
+	t.Helper()
+	fake := newFakeS3("test-bucket")
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String("us-east-1"),
+		Endpoint:         aws.String(srv.URL),
+		S3ForcePathStyle: aws.Bool(true),
+		DisableSSL:       aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("key", "secret", ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &S3Provider{client: s3.New(sess), bucket: fake.bucket}, fake
+}
+
+// onlyReader hides any Seek method, like a request body
+type onlyReader struct{ io.Reader }
+
+func TestS3Put(t *testing.T) {
+	s, fake := newTestS3(t)
+	ctx := context.Background()
+
+	u, err := s.Put(ctx, "tenants/t1/a.webp", onlyReader{strings.NewReader("small")}, "image/webp")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if u != "https://test-bucket.s3.amazonaws.com/tenants/t1/a.webp" {
+		t.Errorf("URL = %s", u)
+	}
+	if got, _ := fake.object("tenants/t1/a.webp"); string(got) != "small" {
+		t.Errorf("stored %q, want %q", got, "small")
+	}
+	if ct := fake.contentType("tenants/t1/a.webp"); ct != "image/webp" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// Bodies over one part are sent as a multipart upload
+	large := bytes.Repeat([]byte("0123456789"), 600*1024)
+	if _, err := s.Put(ctx, "tenants/t1/large.bin", onlyReader{bytes.NewReader(large)}, "application/octet-stream"); err != nil {
+		t.Fatalf("Put of a large body: %v", err)
+	}
+	if got, _ := fake.object("tenants/t1/large.bin"); !bytes.Equal(got, large) {
+		t.Errorf("stored %d bytes, want %d", len(got), len(large))
+	}
+
+	r, err := s.GetReader("tenants/t1/a.webp")
+	if err != nil {
+		t.Fatalf("GetReader: %v", err)
+	}
+	defer r.Close()
+	if got, _ := io.ReadAll(r); string(got) != "small" {
+		t.Errorf("GetReader read %q", got)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	s, _ := newTestS3(t)
+	ctx := context.Background()
+	for _, p := range []string{
+		"tenants/a/x.jpg",
+		"tenants/a/sub/z.jpg",
+		"tenants/a/w.jpg",
+		"tenants/ab/y.jpg",
+		"avatars/v.jpg",
+	} {
+		if _, err := s.Put(ctx, p, strings.NewReader(p), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"tenants/a/", []string{"tenants/a/sub/z.jpg", "tenants/a/w.jpg", "tenants/a/x.jpg"}},
+		{"tenants/a", []string{"tenants/a/sub/z.jpg", "tenants/a/w.jpg", "tenants/a/x.jpg", "tenants/ab/y.jpg"}},
+		{"avatars/", []string{"avatars/v.jpg"}},
+		{"missing/", nil},
+	}
+	for _, tt := range tests {
+		objects, err := s.List(ctx, tt.prefix)
+		if err != nil {
+			t.Errorf("List(%q): %v", tt.prefix, err)
+			continue
+		}
+		var got []string
+		for _, o := range objects {
+			got = append(got, o.Path)
+			if o.Size != int64(len(o.Path)) {
+				t.Errorf("List(%q): %s has size %d, want %d", tt.prefix, o.Path, o.Size, len(o.Path))
+			}
+			if !o.Modified.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+				t.Errorf("List(%q): %s modified %v", tt.prefix, o.Path, o.Modified)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestS3Delete(t *testing.T) {
+	s, fake := newTestS3(t)
+	ctx := context.Background()
+	if _, err := s.Put(ctx, "tenants/t1/a.webp", strings.NewReader("a"), "image/webp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(ctx, "tenants/t1/b.webp", strings.NewReader("b"), "image/webp"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete("tenants/t1/a.webp"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := fake.object("tenants/t1/a.webp"); ok {
+		t.Error("object still stored after Delete")
+	}
+	if _, ok := fake.object("tenants/t1/b.webp"); !ok {
+		t.Error("Delete removed another object")
+	}
+	// Deleting a missing key succeeds, as on S3
+	if err := s.Delete("tenants/t1/a.webp"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+
+	objects, err := s.List(ctx, "tenants/t1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Path != "tenants/t1/b.webp" {
+		t.Errorf("List after Delete = %v", objects)
+	}
+	if _, err := s.GetReader("tenants/t1/a.webp"); err == nil {
+		t.Error("GetReader of a deleted object succeeded")
+	}
+}
@@ -0,0 +1,224 @@
+package storagegc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/saas-single-db-api/internal/imagevariant"
+	"github.com/saas-single-db-api/internal/storage"
+)
+
+// prefixes are the parts of storage written by the apps. Anything else in
+// the bucket is never listed, let alone deleted.
+var prefixes = []string{"tenants/", storage.PrivatePrefix, "avatars/", "logos/", "app-avatars/"}
+
+// Options scope a collection
+type Options struct {
+	// TenantID limits the collection to one tenant's images and files;
+	// empty collects everywhere
+	TenantID string
+	// Grace protects recent work: images created and files written within
+	// it are never collected, so uploads and processing in flight are safe
+	Grace time.Duration
+	// DryRun reports what would be collected without deleting anything
+	DryRun bool
+}
+
+// OrphanImage is an images row whose product, service or owner is gone, or
+// a direct upload that was never completed
+type OrphanImage struct {
+	ID            string
+	TenantID      string
+	ImageableType string
+	ImageableID   string
+	Paths         []string
+}
+
+// Report lists what was collected, or would be on a dry run
+type Report struct {
+	Images  []OrphanImage
+	Objects []storage.Object
+	// Bytes is the total size of Objects
+	Bytes int64
+	// Failed counts files that could not be deleted
+	Failed int
+}
+
+// Collector removes images rows whose imageable no longer exists and
+// stored files no row or profile refers to
+type Collector struct {
+	db      *pgxpool.Pool
+	storage storage.Provider
+}
+
+// NewCollector creates a collector
+func NewCollector(db *pgxpool.Pool, st storage.Provider) *Collector {
+	return &Collector{db: db, storage: st}
+}
+
+// Run collects orphaned images first, then orphaned files, so the files of
+// the removed rows are accounted for in the same run
+func (c *Collector) Run(ctx context.Context, opts Options) (*Report, error) {
+	report := &Report{}
+
+	images, err := c.orphanImages(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphaned images: %w", err)
+	}
+	report.Images = images
+	if !opts.DryRun && len(images) > 0 {
+		ids := make([]string, len(images))
+		for i, img := range images {
+			ids[i] = img.ID
+		}
+		if _, err := c.db.Exec(ctx, `DELETE FROM images WHERE id = ANY($1)`, ids); err != nil {
+			return nil, fmt.Errorf("failed to delete orphaned images: %w", err)
+		}
+	}
+
+	known, err := c.knownPaths(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stored paths: %w", err)
+	}
+	// Files of the orphaned images can still be used by images uploaded
+	// with the same content. On a dry run the orphaned images are still in
+	// the table; their files are already reported with them.
+	for _, img := range images {
+		for _, p := range img.Paths {
+			if !opts.DryRun && !known[p] {
+				if err := c.storage.Delete(p); err != nil {
+					log.Printf("Warning: failed to delete %s: %v", p, err)
+					report.Failed++
+				}
+			}
+			known[p] = true
+		}
+	}
+
+	scan := prefixes
+	if opts.TenantID != "" {
+		scan = []string{"tenants/" + opts.TenantID + "/", storage.PrivatePrefix + "tenants/" + opts.TenantID + "/"}
+	}
+	cutoff := time.Now().Add(-opts.Grace)
+	for _, prefix := range scan {
+		objects, err := c.storage.List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		for _, o := range objects {
+			if known[o.Path] || o.Modified.After(cutoff) {
+				continue
+			}
+			report.Objects = append(report.Objects, o)
+			report.Bytes += o.Size
+			if opts.DryRun {
+				continue
+			}
+			if err := c.storage.Delete(o.Path); err != nil {
+				log.Printf("Warning: failed to delete %s: %v", o.Path, err)
+				report.Failed++
+			}
+		}
+	}
+	return report, nil
+}
+
+// orphanImages finds images older than the grace period whose product,
+// service, user, app user or tenant was deleted, or that are still waiting
+// for their direct upload. Images have no foreign key to what they belong
+// to, so deleting it leaves them behind.
+func (c *Collector) orphanImages(ctx context.Context, opts Options) ([]OrphanImage, error) {
+	rows, err := c.db.Query(ctx,
+		`SELECT i.id, COALESCE(i.tenant_id::text, ''), i.imageable_type, i.imageable_id, i.original_path, i.variants
+		 FROM images i
+		 WHERE i.created_at < NOW() - make_interval(secs => $1)
+		   AND ($2 = '' OR i.tenant_id::text = $2)
+		   AND (i.processing_status = 'uploading' OR CASE i.imageable_type
+			WHEN 'products' THEN NOT EXISTS (SELECT 1 FROM products p WHERE p.id = i.imageable_id AND p.tenant_id = i.tenant_id)
+			WHEN 'services' THEN NOT EXISTS (SELECT 1 FROM services s WHERE s.id = i.imageable_id AND s.tenant_id = i.tenant_id)
+			WHEN 'user_avatar' THEN NOT EXISTS (SELECT 1 FROM users u WHERE u.id = i.imageable_id)
+			WHEN 'app_user_avatar' THEN NOT EXISTS (SELECT 1 FROM tenant_app_users a WHERE a.id = i.imageable_id)
+			WHEN 'tenant_logo' THEN NOT EXISTS (SELECT 1 FROM tenants t WHERE t.id = i.imageable_id)
+			ELSE false
+		   END)
+		 ORDER BY i.created_at`,
+		opts.Grace.Seconds(), opts.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []OrphanImage
+	for rows.Next() {
+		var img OrphanImage
+		var originalPath string
+		var variants map[string]imagevariant.Variant
+		if err := rows.Scan(&img.ID, &img.TenantID, &img.ImageableType, &img.ImageableID, &originalPath, &variants); err != nil {
+			return nil, err
+		}
+		img.Paths = append(img.Paths, originalPath)
+		for _, v := range variants {
+			if v.Path != "" {
+				img.Paths = append(img.Paths, v.Path)
+			}
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// knownPaths returns every path still referenced: the originals and variants
+// of all images, and profile pictures uploaded before they were images,
+// which are only known by their URL
+func (c *Collector) knownPaths(ctx context.Context) (map[string]bool, error) {
+	known := make(map[string]bool)
+
+	rows, err := c.db.Query(ctx, `SELECT original_path, variants FROM images`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var originalPath string
+		var variants map[string]imagevariant.Variant
+		if err := rows.Scan(&originalPath, &variants); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		known[originalPath] = true
+		for _, v := range variants {
+			known[v.Path] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	baseURL := c.storage.URL("")
+	rows, err = c.db.Query(ctx,
+		`SELECT avatar_url FROM user_profiles WHERE avatar_url IS NOT NULL
+		 UNION ALL SELECT avatar_url FROM tenant_app_user_profiles WHERE avatar_url IS NOT NULL
+		 UNION ALL SELECT avatar_url FROM saas_admin_profiles WHERE avatar_url IS NOT NULL
+		 UNION ALL SELECT logo_url FROM tenant_profiles WHERE logo_url IS NOT NULL`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		if p, ok := strings.CutPrefix(url, baseURL); ok {
+			known[p] = true
+		}
+	}
+	return known, rows.Err()
+}
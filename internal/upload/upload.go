@@ -0,0 +1,161 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+
+	"github.com/google/uuid"
+	_ "golang.org/x/image/webp"
+)
+
+// Allowed maps the accepted content types, as detected from the file
+// content, to the extension files of that type are stored with
+var Allowed = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+const (
+	// MaxPixels caps width x height of any image, whatever the plan allows.
+	// Decoding takes about 4 bytes per pixel, so this keeps a small,
+	// highly compressed file from expanding into gigabytes of memory.
+	MaxPixels = 100_000_000
+	// MaxFiles is how many files a multi-file upload may carry
+	MaxFiles = 10
+	// multipartOverhead is allowed on top of the files for headers and
+	// other form fields
+	multipartOverhead = 1 << 20
+	// sniffLen is how much of a file is inspected to detect its type
+	sniffLen = 512
+)
+
+var (
+	ErrFileTooLarge    = errors.New("file_too_large")
+	ErrUnsupportedType = errors.New("unsupported_file_type")
+	ErrInvalidImage    = errors.New("invalid_image")
+	ErrImageTooLarge   = errors.New("image_dimensions_too_large")
+)
+
+// Limits bound a single uploaded file
+type Limits struct {
+	MaxBytes     int64 `json:"max_bytes"`
+	MaxDimension int   `json:"max_dimension"`
+}
+
+// DefaultLimits apply to uploads not covered by a plan, such as avatars, and
+// to tenants without an active plan
+var DefaultLimits = Limits{MaxBytes: 10 << 20, MaxDimension: 6000}
+
+// Image describes a validated upload
+type Image struct {
+	MimeType  string
+	Extension string
+	Width     int
+	Height    int
+	Size      int64
+	// ContentHash is the hex SHA-256 of the file, set when the whole file
+	// was read
+	ContentHash string
+}
+
+// StoragePath returns a new unique path for the image under dir
+func (img *Image) StoragePath(dir string) string {
+	return path.Join(dir, uuid.New().String()+"."+img.Extension)
+}
+
+// LimitBody caps the request body at what the given number of files within
+// limits can take, so an oversized request is cut off while it is read
+// instead of being spooled to disk first
+func LimitBody(w http.ResponseWriter, r *http.Request, limits Limits, files int) {
+	r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBytes*int64(files)+multipartOverhead)
+}
+
+// IsBodyTooLarge reports whether err comes from reading past LimitBody
+func IsBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// Validate checks an uploaded image against limits. The type is detected
+// from the content, never from the client's Content-Type or file name, and
+// must be one of Allowed. Only the image header is decoded, so oversized
+// images are rejected before any pixel is allocated. The file is read from
+// the start, hashed and rewound for the caller. Errors are i18n keys.
+func Validate(file multipart.File, header *multipart.FileHeader, limits Limits) (*Image, error) {
+	if header.Size > limits.MaxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, err := InspectAll(file, limits)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img.Size = header.Size
+	return img, nil
+}
+
+// Inspect detects the type and dimensions of the image r starts with and
+// checks them against limits, the way Validate does. It reads no further
+// than the image header and leaves Size for the caller to fill in.
+func Inspect(r io.Reader, limits Limits) (*Image, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrInvalidImage
+	}
+	head = head[:n]
+	mimeType := http.DetectContentType(head)
+	ext, ok := Allowed[mimeType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, format, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(head), r))
+	if err != nil || "image/"+format != mimeType {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > limits.MaxDimension || cfg.Height > limits.MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	return &Image{
+		MimeType:  mimeType,
+		Extension: ext,
+		Width:     cfg.Width,
+		Height:    cfg.Height,
+	}, nil
+}
+
+// InspectAll is Inspect for a whole file: after the header checks it reads
+// the rest of r to fill in ContentHash
+func InspectAll(r io.Reader, limits Limits) (*Image, error) {
+	hash := sha256.New()
+	r = io.TeeReader(r, hash)
+	img, err := Inspect(r, limits)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+	img.ContentHash = hex.EncodeToString(hash.Sum(nil))
+	return img, nil
+}
@@ -32,108 +32,111 @@ type AppUserClaims struct {
 	jwt.RegisteredClaims
 }
 
+// Audiences of each token type. Validation requires the matching audience, so
+// a token issued for one API is never accepted as another type.
+const (
+	AudienceAdmin = "saas-admin"
+	AudienceUser  = "saas-backoffice"
+	AudienceApp   = "saas-app"
+)
+
 // --- JWT Token Generation ---
+//
+// The session id is stored as the `jti` claim; it stays the same across
+// refreshes and is what gets revoked on logout.
 
-// GenerateAdminToken generates a JWT token for admin
-func GenerateAdminToken(adminID, secret string, expiryHours int) (string, error) {
+// GenerateAdminToken generates a short-lived JWT access token for admin
+func GenerateAdminToken(adminID, sessionID string, keys *KeySet, expiryMinutes int) (string, error) {
 	claims := AdminClaims{
-		AdminID: adminID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   "admin:" + adminID,
-		},
+		AdminID:          adminID,
+		RegisteredClaims: registeredClaims("admin:"+adminID, AudienceAdmin, sessionID, expiryMinutes),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return signToken(keys, claims)
 }
 
-// GenerateUserToken generates a JWT token for backoffice user
-func GenerateUserToken(userID, tenantID, secret string, expiryHours int) (string, error) {
+// GenerateUserToken generates a short-lived JWT access token for backoffice user
+func GenerateUserToken(userID, tenantID, sessionID string, keys *KeySet, expiryMinutes int) (string, error) {
 	claims := UserClaims{
-		UserID:   userID,
-		TenantID: tenantID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   "user:" + userID,
-		},
+		UserID:           userID,
+		TenantID:         tenantID,
+		RegisteredClaims: registeredClaims("user:"+userID, AudienceUser, sessionID, expiryMinutes),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return signToken(keys, claims)
 }
 
-// GenerateAppUserToken generates a JWT token for app user
-func GenerateAppUserToken(appUserID, tenantID, secret string, expiryHours int) (string, error) {
+// GenerateAppUserToken generates a short-lived JWT access token for app user
+func GenerateAppUserToken(appUserID, tenantID, sessionID string, keys *KeySet, expiryMinutes int) (string, error) {
 	claims := AppUserClaims{
-		AppUserID: appUserID,
-		TenantID:  tenantID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   "app:" + appUserID,
-		},
+		AppUserID:        appUserID,
+		TenantID:         tenantID,
+		RegisteredClaims: registeredClaims("app:"+appUserID, AudienceApp, sessionID, expiryMinutes),
+	}
+	return signToken(keys, claims)
+}
+
+func registeredClaims(subject, audience, sessionID string, expiryMinutes int) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(expiryMinutes) * time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{audience},
+		ID:        sessionID,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+}
+
+// signToken signs the claims with the current key and sets its kid header
+func signToken(keys *KeySet, claims jwt.Claims) (string, error) {
+	k := keys.signingKey()
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	return token.SignedString(k.private)
 }
 
 // --- JWT Token Validation ---
 
 // ValidateAdminToken validates and parses an admin JWT token
-func ValidateAdminToken(tokenString, secret string) (*AdminClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &AdminClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
-	if err != nil {
+func ValidateAdminToken(tokenString string, keys *KeySet) (*AdminClaims, error) {
+	claims := &AdminClaims{}
+	if err := parseToken(tokenString, keys, AudienceAdmin, claims); err != nil {
 		return nil, err
 	}
-	claims, ok := token.Claims.(*AdminClaims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
-	}
 	return claims, nil
 }
 
 // ValidateUserToken validates and parses a user JWT token
-func ValidateUserToken(tokenString, secret string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
-	if err != nil {
+func ValidateUserToken(tokenString string, keys *KeySet) (*UserClaims, error) {
+	claims := &UserClaims{}
+	if err := parseToken(tokenString, keys, AudienceUser, claims); err != nil {
 		return nil, err
 	}
-	claims, ok := token.Claims.(*UserClaims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
-	}
 	return claims, nil
 }
 
 // ValidateAppUserToken validates and parses an app user JWT token
-func ValidateAppUserToken(tokenString, secret string) (*AppUserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &AppUserClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
-	if err != nil {
+func ValidateAppUserToken(tokenString string, keys *KeySet) (*AppUserClaims, error) {
+	claims := &AppUserClaims{}
+	if err := parseToken(tokenString, keys, AudienceApp, claims); err != nil {
 		return nil, err
 	}
-	claims, ok := token.Claims.(*AppUserClaims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
-	}
 	return claims, nil
 }
 
+func parseToken(tokenString string, keys *KeySet, audience string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.keyFunc,
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
 // --- Password Hashing ---
 
 // HashPassword hashes a password using bcrypt
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"math/big"
 )
 
@@ -32,3 +34,10 @@ func GenerateVerificationToken() string {
 	}
 	return string(token)
 }
+
+// HashToken returns the hex-encoded SHA-256 digest of a token, so that
+// single-use tokens can be stored without keeping the raw value.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
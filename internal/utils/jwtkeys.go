@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// KeyIDLayout is the timestamp format used for key ids. Because ids sort in
+// creation order, the newest key in the directory is the one used for signing.
+const KeyIDLayout = "20060102T150405Z"
+
+const (
+	// keyReloadInterval is how often the key directory is re-read to pick up a
+	// rotated signing key
+	keyReloadInterval = time.Minute
+	// keyMissReloadInterval throttles re-reads triggered by tokens signed with
+	// an unknown kid (e.g. rotated by another instance a moment ago)
+	keyMissReloadInterval = 5 * time.Second
+)
+
+// KeySet holds the asymmetric keys used to sign and verify JWTs. Keys are PEM
+// encoded private keys stored one per file as <kid>.pem in a directory shared
+// by all APIs. The newest key signs; older keys remain valid for verification
+// until they are pruned, which lets keys rotate without invalidating tokens.
+type KeySet struct {
+	dir string
+
+	mu       sync.RWMutex
+	keys     map[string]*jwtKey
+	current  *jwtKey
+	loadedAt time.Time
+}
+
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// LoadKeySet reads every key in dir. It fails if the directory holds no keys.
+func LoadKeySet(dir string) (*KeySet, error) {
+	ks := &KeySet{dir: dir}
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) reload() error {
+	files, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	keys := make(map[string]*jwtKey, len(files))
+	var current *jwtKey
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		signer, method, err := ParsePrivateKeyPEM(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		k := &jwtKey{
+			kid:     strings.TrimSuffix(filepath.Base(f), ".pem"),
+			method:  method,
+			private: signer,
+			public:  signer.Public(),
+		}
+		keys[k.kid] = k
+		current = k
+	}
+	if current == nil {
+		return fmt.Errorf("no JWT signing keys found in %s", ks.dir)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.current = current
+	ks.loadedAt = time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+// reloadIfOlder re-reads the directory when the last load is older than d.
+// A failed reload keeps the keys already loaded.
+func (ks *KeySet) reloadIfOlder(d time.Duration) {
+	ks.mu.RLock()
+	stale := time.Since(ks.loadedAt) > d
+	ks.mu.RUnlock()
+	if stale {
+		_ = ks.reload()
+	}
+}
+
+func (ks *KeySet) signingKey() *jwtKey {
+	ks.reloadIfOlder(keyReloadInterval)
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.current
+}
+
+func (ks *KeySet) lookup(kid string) (*jwtKey, bool) {
+	ks.mu.RLock()
+	k, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+	if ok {
+		return k, true
+	}
+
+	ks.reloadIfOlder(keyMissReloadInterval)
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	k, ok = ks.keys[kid]
+	return k, ok
+}
+
+// keyFunc resolves the verification key from the token's kid header
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing kid")
+	}
+	k, ok := ks.lookup(kid)
+	if !ok {
+		return nil, errors.New("unknown kid")
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return k.public, nil
+}
+
+// --- JWKS ---
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of every key in the set
+func (ks *KeySet) JWKS() JWKSet {
+	ks.reloadIfOlder(keyReloadInterval)
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range ks.keys {
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid > set.Keys[j].Kid })
+	return set
+}
+
+// JWKSHandler serves the key set at /.well-known/jwks.json
+func JWKSHandler(ks *KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, ks.JWKS())
+	}
+}
+
+// --- PEM helpers ---
+
+// ParsePrivateKeyPEM parses an RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8)
+// private key and returns it with the signing method it is used with.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, jwt.SigningMethod, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, jwt.SigningMethodRS256, nil
+	case ed25519.PrivateKey:
+		return k, jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// GenerateKeyPEM creates a new private key for alg, PKCS#8 PEM encoded
+func GenerateKeyPEM(alg string) ([]byte, error) {
+	var key interface{}
+	var err error
+	switch alg {
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,95 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL    = errors.New("invalid_webhook_url")
+	ErrURLNotAllowed = errors.New("webhook_url_not_allowed")
+)
+
+// sharedAddressSpace is carrier-grade NAT space (RFC 6598). Some clouds serve
+// instance metadata from it.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether deliveries may be sent to ip. Loopback,
+// private, link-local and unspecified addresses reach the apps' own network
+// or the cloud metadata endpoint, so they are refused.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// CheckURL validates an endpoint URL when it is registered: it must be an
+// absolute http(s) URL whose host resolves to public addresses only. The
+// dispatcher checks the address again on every connection, since the host
+// can be re-pointed afterwards.
+func CheckURL(ctx context.Context, raw string) error {
+	if !ValidURL(raw) {
+		return ErrInvalidURL
+	}
+	u, _ := url.Parse(raw)
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrInvalidURL
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return ErrURLNotAllowed
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to addresses that are not public. It runs
+// after DNS resolution, for every connection, so a host that resolved to a
+// public address when the endpoint was registered cannot be rebound to an
+// internal one.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("webhook: address %s is not allowed", addrPort.Addr())
+	}
+	return nil
+}
+
+// NewClient returns the client deliveries are sent with by default. It only
+// connects to public addresses, ignores proxy settings, which would hide the
+// address actually reached, and does not follow redirects: a 3xx response is
+// recorded as a failed attempt.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: dialControl}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: requestTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
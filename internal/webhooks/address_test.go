@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"172.18.0.3", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.100.100.200", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://93.184.216.34/hook", nil},
+		{"ftp://93.184.216.34/hook", ErrInvalidURL},
+		{"/hook", ErrInvalidURL},
+		{"http://localhost:8080/hook", ErrURLNotAllowed},
+		{"http://127.0.0.1:6379", ErrURLNotAllowed},
+		{"http://[::1]/hook", ErrURLNotAllowed},
+		{"http://169.254.169.254/latest/meta-data/", ErrURLNotAllowed},
+		{"http://10.1.2.3:5432", ErrURLNotAllowed},
+	}
+	for _, tt := range tests {
+		if err := CheckURL(context.Background(), tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("CheckURL(%q) = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+}
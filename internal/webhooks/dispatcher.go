@@ -0,0 +1,223 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxAttempts is how many times a delivery is tried before it is marked
+	// failed. Retries wait retryBase, doubled after every attempt, up to
+	// retryMax: a little over 4 hours from the first try to the last.
+	maxAttempts = 10
+	retryBase   = 30 * time.Second
+	retryMax    = 6 * time.Hour
+	// requestTimeout bounds a single request to an endpoint
+	requestTimeout = 10 * time.Second
+	// batchSize is how many due deliveries are claimed at a time
+	batchSize = 20
+	// claimTimeout is how long claimed deliveries stay locked. A batch is
+	// sent one delivery after another, so the lock must outlast every
+	// request of the batch timing out, or another dispatcher would claim
+	// and send the rest again. A dispatcher that dies mid-batch only delays
+	// its deliveries.
+	claimTimeout = batchSize*requestTimeout + time.Minute
+	// maxLoggedBody is how much of a response body is kept in the log
+	maxLoggedBody = 2048
+)
+
+// Dispatcher sends queued deliveries. Deliveries are claimed with SKIP
+// LOCKED, so any number of dispatchers can share the queue.
+type Dispatcher struct {
+	db     *pgxpool.Pool
+	client *http.Client
+}
+
+// NewDispatcher creates a dispatcher. A nil client uses NewClient. Clients
+// must time out within requestTimeout.
+func NewDispatcher(db *pgxpool.Pool, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = NewClient()
+	}
+	return &Dispatcher{db: db, client: client}
+}
+
+// queuedDelivery is a claimed delivery with what is needed to send it
+type queuedDelivery struct {
+	ID        string
+	EventType string
+	Payload   []byte
+	Attempts  int
+	URL       string
+	Secret    string
+}
+
+// Run sends due deliveries every interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.DeliverDue(ctx)
+			if err != nil {
+				log.Printf("Error delivering webhooks: %v", err)
+			}
+			// A full batch likely means more are waiting
+			if err != nil || n < batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue claims a batch of due deliveries, sends them and records the
+// outcome. It returns how many were sent.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	batch, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range batch {
+		d.deliver(ctx, &batch[i])
+	}
+	return len(batch), nil
+}
+
+// claim locks a batch of due deliveries. Deliveries of disabled endpoints
+// stay pending and are sent once the endpoint is enabled again.
+func (d *Dispatcher) claim(ctx context.Context) ([]queuedDelivery, error) {
+	rows, err := d.db.Query(ctx,
+		`UPDATE webhook_deliveries wd SET locked_until = NOW() + make_interval(secs => $1), updated_at = NOW()
+		 FROM webhook_endpoints e
+		 WHERE e.id = wd.endpoint_id AND e.is_active AND wd.id IN (
+			SELECT q.id FROM webhook_deliveries q
+			JOIN webhook_endpoints qe ON qe.id = q.endpoint_id AND qe.is_active
+			WHERE q.status = 'pending' AND q.next_attempt_at <= NOW()
+			  AND (q.locked_until IS NULL OR q.locked_until < NOW())
+			ORDER BY q.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF q SKIP LOCKED
+		 )
+		 RETURNING wd.id, wd.event_type, wd.payload, wd.attempts, e.url, e.secret`,
+		claimTimeout.Seconds(), batchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []queuedDelivery
+	for rows.Next() {
+		var q queuedDelivery
+		if err := rows.Scan(&q.ID, &q.EventType, &q.Payload, &q.Attempts, &q.URL, &q.Secret); err != nil {
+			return nil, err
+		}
+		batch = append(batch, q)
+	}
+	return batch, rows.Err()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, q *queuedDelivery) {
+	attempt := q.Attempts + 1
+	start := time.Now()
+	status, body, sendErr := d.send(ctx, q)
+	duration := time.Since(start)
+
+	var errMsg *string
+	if sendErr != nil {
+		msg := sendErr.Error()
+		errMsg = &msg
+	} else if status < 200 || status > 299 {
+		msg := "unexpected status " + strconv.Itoa(status)
+		errMsg = &msg
+	}
+	var respStatus *int
+	if sendErr == nil {
+		respStatus = &status
+	}
+
+	_, err := d.db.Exec(ctx,
+		`INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_status, response_body, error, duration_ms)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		q.ID, attempt, respStatus, body, errMsg, duration.Milliseconds(),
+	)
+	if err != nil {
+		log.Printf("Error logging attempt %d of webhook delivery %s: %v", attempt, q.ID, err)
+	}
+
+	switch {
+	case errMsg == nil:
+		_, err = d.db.Exec(ctx,
+			`UPDATE webhook_deliveries SET status = 'delivered', attempts = $2, response_status = $3, last_error = NULL,
+				delivered_at = NOW(), locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`,
+			q.ID, attempt, respStatus,
+		)
+	case attempt >= maxAttempts:
+		_, err = d.db.Exec(ctx,
+			`UPDATE webhook_deliveries SET status = 'failed', attempts = $2, response_status = $3, last_error = $4,
+				locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`,
+			q.ID, attempt, respStatus, errMsg,
+		)
+	default:
+		_, err = d.db.Exec(ctx,
+			`UPDATE webhook_deliveries SET attempts = $2, response_status = $3, last_error = $4,
+				next_attempt_at = $5, locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`,
+			q.ID, attempt, respStatus, errMsg, time.Now().Add(RetryDelay(attempt)),
+		)
+	}
+	if err != nil {
+		log.Printf("Error recording webhook delivery %s: %v", q.ID, err)
+	}
+}
+
+// send POSTs the payload and returns the response status and the start of
+// its body
+func (d *Dispatcher) send(ctx context.Context, q *queuedDelivery) (int, *string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.URL, bytes.NewReader(q.Payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "saas-webhooks/1.0")
+	req.Header.Set(HeaderID, q.ID)
+	req.Header.Set(HeaderEvent, q.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(q.Secret, timestamp, q.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	// Postgres text takes neither NUL bytes nor invalid UTF-8
+	body := strings.ToValidUTF8(strings.ReplaceAll(string(b), "\x00", ""), "")
+	return resp.StatusCode, &body, nil
+}
+
+// RetryDelay is how long to wait after the given (1-based) failed attempt
+func RetryDelay(attempt int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempt && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
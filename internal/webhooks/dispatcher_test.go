@@ -0,0 +1,149 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{9, 128 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, retryMax},
+		{50, retryMax},
+	}
+	for _, tt := range tests {
+		if got := RetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+
+	var total time.Duration
+	for attempt := 1; attempt < maxAttempts; attempt++ {
+		total += RetryDelay(attempt)
+	}
+	if total < 4*time.Hour || total > 5*time.Hour {
+		t.Errorf("retries span %s, want a little over 4h", total)
+	}
+}
+
+func TestClaimOutlastsBatch(t *testing.T) {
+	if claimTimeout <= batchSize*requestTimeout {
+		t.Errorf("claimTimeout %s does not cover a batch of %d requests of %s", claimTimeout, batchSize, requestTimeout)
+	}
+}
+
+func TestSend(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt","type":"product.created","data":{}}`)
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, strings.Repeat("x", maxLoggedBody+100))
+	}))
+	defer srv.Close()
+
+	d := &Dispatcher{client: srv.Client()}
+	status, body, err := d.send(context.Background(), &queuedDelivery{
+		ID:        "delivery-1",
+		EventType: EventProductCreated,
+		Payload:   payload,
+		URL:       srv.URL + "/hook",
+		Secret:    secret,
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if status != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", status, http.StatusAccepted)
+	}
+	if body == nil || len(*body) != maxLoggedBody {
+		t.Errorf("logged body is not cut at %d bytes", maxLoggedBody)
+	}
+
+	if got.Method != http.MethodPost || got.URL.Path != "/hook" {
+		t.Errorf("request = %s %s, want POST /hook", got.Method, got.URL.Path)
+	}
+	if string(gotBody) != string(payload) {
+		t.Errorf("body = %s, want %s", gotBody, payload)
+	}
+	if got.Header.Get(HeaderID) != "delivery-1" || got.Header.Get(HeaderEvent) != EventProductCreated {
+		t.Errorf("headers = %v", got.Header)
+	}
+	if !Verify(secret, got.Header.Get(HeaderTimestamp), got.Header.Get(HeaderSignature), gotBody, time.Minute) {
+		t.Error("receiver could not verify the signature")
+	}
+}
+
+func TestSendSanitizesBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("bad\x00\xffbody"))
+	}))
+	defer srv.Close()
+
+	d := &Dispatcher{client: srv.Client()}
+	status, body, err := d.send(context.Background(), &queuedDelivery{ID: "d", URL: srv.URL, Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if status != http.StatusInternalServerError || *body != "badbody" {
+		t.Errorf("got %d %q, want 500 \"badbody\"", status, *body)
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, nil)
+	_, _, err := d.send(context.Background(), &queuedDelivery{ID: "d", URL: srv.URL, Payload: []byte(`{}`)})
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("send to %s: err = %v, want address not allowed", srv.URL, err)
+	}
+	if hits != 0 {
+		t.Error("request reached a loopback server")
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	// Loopback is refused by the dialer; only the redirect policy is tested
+	client := NewClient()
+	client.Transport = srv.Client().Transport
+	d := &Dispatcher{client: client}
+	status, _, err := d.send(context.Background(), &queuedDelivery{ID: "d", URL: srv.URL, Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if status != http.StatusTemporaryRedirect || followed {
+		t.Errorf("status = %d, followed = %v; want the redirect recorded, not followed", status, followed)
+	}
+}
@@ -0,0 +1,46 @@
+package webhooks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"id":"1","type":"product.created"}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+	sig := Sign(secret, now, body)
+
+	if sig != Sign(secret, now, body) {
+		t.Fatal("Sign is not deterministic")
+	}
+	if !Verify(secret, ts, sig, body, 5*time.Minute) {
+		t.Fatal("Verify rejected a valid signature")
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+	}{
+		{"wrong secret", "whsec_other", ts, sig, body},
+		{"tampered body", secret, ts, sig, []byte(`{"id":"2","type":"product.created"}`)},
+		{"other timestamp", secret, strconv.FormatInt(now+1, 10), sig, body},
+		{"stale timestamp", secret, strconv.FormatInt(now-600, 10), Sign(secret, now-600, body), body},
+		{"future timestamp", secret, strconv.FormatInt(now+600, 10), Sign(secret, now+600, body), body},
+		{"invalid timestamp", secret, "yesterday", sig, body},
+		{"missing prefix", secret, ts, sig[len(signaturePrefix):], body},
+		{"empty signature", secret, ts, "", body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute) {
+				t.Error("Verify accepted an invalid signature")
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/saas-single-db-api/internal/utils"
+)
+
+// Event types a tenant can subscribe an endpoint to
+const (
+	EventProductCreated      = "product.created"
+	EventProductUpdated      = "product.updated"
+	EventProductDeleted      = "product.deleted"
+	EventServiceCreated      = "service.created"
+	EventServiceUpdated      = "service.updated"
+	EventServiceDeleted      = "service.deleted"
+	EventAppUserRegistered   = "app_user.registered"
+	EventAppUserStatusChange = "app_user.status_changed"
+	EventImageCompleted      = "image.completed"
+)
+
+// Events lists every event type, in the order they are documented
+var Events = []string{
+	EventProductCreated,
+	EventProductUpdated,
+	EventProductDeleted,
+	EventServiceCreated,
+	EventServiceUpdated,
+	EventServiceDeleted,
+	EventAppUserRegistered,
+	EventAppUserStatusChange,
+	EventImageCompleted,
+}
+
+// secretPrefix marks endpoint secrets so they are recognizable when leaked
+const secretPrefix = "whsec_"
+
+var (
+	ErrEndpointNotFound = errors.New("webhook_not_found")
+	ErrDeliveryNotFound = errors.New("webhook_delivery_not_found")
+)
+
+// Service stores webhook endpoints and queues deliveries for them. Emit is
+// safe to call from any app; the Dispatcher sends what it queues.
+type Service struct {
+	db *pgxpool.Pool
+}
+
+// NewService creates a new webhook service
+func NewService(db *pgxpool.Pool) *Service {
+	return &Service{db: db}
+}
+
+// Endpoint is a URL a tenant receives events on. Secret is only filled in
+// when the endpoint is created or its secret rotated.
+type Endpoint struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description *string   `json:"description"`
+	Events      []string  `json:"events"`
+	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Delivery is one event queued for one endpoint
+type Delivery struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastError      *string         `json:"last_error"`
+	ResponseStatus *int            `json:"response_status"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Log            []Attempt       `json:"log,omitempty"`
+}
+
+// Attempt is a logged request for a delivery
+type Attempt struct {
+	Attempt        int       `json:"attempt"`
+	ResponseStatus *int      `json:"response_status"`
+	ResponseBody   *string   `json:"response_body"`
+	Error          *string   `json:"error"`
+	DurationMS     int       `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// envelope is the body POSTed to endpoints
+type envelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	TenantID  string      `json:"tenant_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// ValidEvents reports whether every entry of events is a known event type
+func ValidEvents(events []string) bool {
+	for _, e := range events {
+		if !slices.Contains(Events, e) {
+			return false
+		}
+	}
+	return len(events) > 0
+}
+
+// ValidURL reports whether raw is an absolute http(s) URL. Use CheckURL for
+// endpoints, which also checks where the URL points.
+func ValidURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && len(raw) <= 2048
+}
+
+func newSecret() string {
+	return secretPrefix + utils.GenerateVerificationToken()
+}
+
+// --- Events ---
+
+// Emit queues an event for every active endpoint of the tenant subscribed to
+// eventType. data is marshalled as the envelope's "data" field.
+func (s *Service) Emit(ctx context.Context, tenantID, eventType string, data interface{}) error {
+	eventID := uuid.NewString()
+	payload, err := json.Marshal(envelope{
+		ID:        eventID,
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx,
+		`INSERT INTO webhook_deliveries (endpoint_id, tenant_id, event_id, event_type, payload)
+		 SELECT id, tenant_id, $2::uuid, $3, $4::jsonb
+		 FROM webhook_endpoints
+		 WHERE tenant_id = $1 AND is_active = true AND $3 = ANY(events)`,
+		tenantID, eventID, eventType, payload,
+	)
+	return err
+}
+
+// --- Endpoints ---
+
+const endpointColumns = `id, url, description, events, is_active, created_at, updated_at`
+
+func scanEndpoint(row pgx.Row) (*Endpoint, error) {
+	var e Endpoint
+	if err := row.Scan(&e.ID, &e.URL, &e.Description, &e.Events, &e.IsActive, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListEndpoints returns the tenant's endpoints, oldest first
+func (s *Service) ListEndpoints(ctx context.Context, tenantID string) ([]Endpoint, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE tenant_id = $1 ORDER BY created_at`, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Endpoint{}
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+// GetEndpoint returns one of the tenant's endpoints
+func (s *Service) GetEndpoint(ctx context.Context, tenantID, endpointID string) (*Endpoint, error) {
+	e, err := scanEndpoint(s.db.QueryRow(ctx,
+		`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE tenant_id = $1 AND id = $2`, tenantID, endpointID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEndpointNotFound
+	}
+	return e, err
+}
+
+// CreateEndpoint registers an endpoint with a new secret, returned once here
+func (s *Service) CreateEndpoint(ctx context.Context, tenantID, endpointURL string, description *string, events []string) (*Endpoint, error) {
+	secret := newSecret()
+	e, err := scanEndpoint(s.db.QueryRow(ctx,
+		`INSERT INTO webhook_endpoints (tenant_id, url, description, events, secret)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING `+endpointColumns,
+		tenantID, endpointURL, description, events, secret,
+	))
+	if err != nil {
+		return nil, err
+	}
+	e.Secret = secret
+	return e, nil
+}
+
+// UpdateEndpoint changes the given fields of an endpoint; nil leaves a field
+// as it is
+func (s *Service) UpdateEndpoint(ctx context.Context, tenantID, endpointID string, endpointURL, description *string, events []string, isActive *bool) error {
+	tag, err := s.db.Exec(ctx,
+		`UPDATE webhook_endpoints SET
+			url = COALESCE($3, url),
+			description = COALESCE($4, description),
+			events = COALESCE($5, events),
+			is_active = COALESCE($6, is_active),
+			updated_at = NOW()
+		 WHERE tenant_id = $1 AND id = $2`,
+		tenantID, endpointID, endpointURL, description, events, isActive,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+// DeleteEndpoint removes an endpoint along with its deliveries
+func (s *Service) DeleteEndpoint(ctx context.Context, tenantID, endpointID string) error {
+	tag, err := s.db.Exec(ctx,
+		`DELETE FROM webhook_endpoints WHERE tenant_id = $1 AND id = $2`, tenantID, endpointID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+// RotateSecret replaces the endpoint's secret and returns the new one.
+// Deliveries sent from now on, including retries, are signed with it.
+func (s *Service) RotateSecret(ctx context.Context, tenantID, endpointID string) (string, error) {
+	secret := newSecret()
+	tag, err := s.db.Exec(ctx,
+		`UPDATE webhook_endpoints SET secret = $3, updated_at = NOW() WHERE tenant_id = $1 AND id = $2`,
+		tenantID, endpointID, secret,
+	)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", ErrEndpointNotFound
+	}
+	return secret, nil
+}
+
+// --- Deliveries ---
+
+// ListDeliveries returns an endpoint's deliveries, newest first, optionally
+// filtered by status, with the total count
+func (s *Service) ListDeliveries(ctx context.Context, tenantID, endpointID, status string, limit, offset int) ([]Delivery, int, error) {
+	var total int
+	err := s.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM webhook_deliveries
+		 WHERE tenant_id = $1 AND endpoint_id = $2 AND ($3 = '' OR status = $3)`,
+		tenantID, endpointID, status,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT id, event_id, event_type, status, attempts, next_attempt_at, last_error, response_status, delivered_at, created_at
+		 FROM webhook_deliveries
+		 WHERE tenant_id = $1 AND endpoint_id = $2 AND ($3 = '' OR status = $3)
+		 ORDER BY created_at DESC LIMIT $4 OFFSET $5`,
+		tenantID, endpointID, status, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		var nextAttemptAt time.Time
+		if err := rows.Scan(&d.ID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &nextAttemptAt, &d.LastError, &d.ResponseStatus, &d.DeliveredAt, &d.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		if d.Status == "pending" {
+			d.NextAttemptAt = &nextAttemptAt
+		}
+		list = append(list, d)
+	}
+	return list, total, rows.Err()
+}
+
+// GetDelivery returns a delivery with its payload and the log of its attempts
+func (s *Service) GetDelivery(ctx context.Context, tenantID, endpointID, deliveryID string) (*Delivery, error) {
+	var d Delivery
+	var nextAttemptAt time.Time
+	err := s.db.QueryRow(ctx,
+		`SELECT id, event_id, event_type, status, attempts, next_attempt_at, last_error, response_status, delivered_at, created_at, payload
+		 FROM webhook_deliveries
+		 WHERE tenant_id = $1 AND endpoint_id = $2 AND id = $3`,
+		tenantID, endpointID, deliveryID,
+	).Scan(&d.ID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &nextAttemptAt, &d.LastError, &d.ResponseStatus, &d.DeliveredAt, &d.CreatedAt, &d.Payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == "pending" {
+		d.NextAttemptAt = &nextAttemptAt
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT attempt, response_status, response_body, error, duration_ms, created_at
+		 FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY created_at`, deliveryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	d.Log = []Attempt{}
+	for rows.Next() {
+		var a Attempt
+		if err := rows.Scan(&a.Attempt, &a.ResponseStatus, &a.ResponseBody, &a.Error, &a.DurationMS, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Log = append(d.Log, a)
+	}
+	return &d, rows.Err()
+}
+
+// Redeliver queues a delivery to be sent again right away, with a fresh
+// retry budget. Its attempt log is kept.
+func (s *Service) Redeliver(ctx context.Context, tenantID, endpointID, deliveryID string) error {
+	tag, err := s.db.Exec(ctx,
+		`UPDATE webhook_deliveries SET
+			status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL, updated_at = NOW()
+		 WHERE tenant_id = $1 AND endpoint_id = $2 AND id = $3
+		   AND (locked_until IS NULL OR locked_until < NOW())`,
+		tenantID, endpointID, deliveryID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
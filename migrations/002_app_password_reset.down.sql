@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS app_password_reset_tokens CASCADE;
+
+DELETE FROM email_templates WHERE slug = 'app_password_reset';
+ALTER TABLE email_templates DROP CONSTRAINT IF EXISTS email_templates_slug_language_key;
+DELETE FROM email_templates WHERE language <> 'pt-BR';
+ALTER TABLE email_templates ADD CONSTRAINT email_templates_slug_key UNIQUE (slug);
+ALTER TABLE email_templates DROP COLUMN IF EXISTS language;
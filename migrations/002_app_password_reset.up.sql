@@ -0,0 +1,202 @@
+-- ============================================================
+-- Localized Email Templates
+-- ============================================================
+
+ALTER TABLE email_templates ADD COLUMN language VARCHAR(10) NOT NULL DEFAULT 'pt-BR';
+ALTER TABLE email_templates DROP CONSTRAINT email_templates_slug_key;
+ALTER TABLE email_templates ADD CONSTRAINT email_templates_slug_language_key UNIQUE (slug, language);
+
+-- ============================================================
+-- App User Password Reset Tokens
+-- ============================================================
+
+-- Only the SHA-256 hash of the token is stored; the raw token travels by email.
+CREATE TABLE app_password_reset_tokens (
+    id          UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id   UUID         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    app_user_id UUID         NOT NULL REFERENCES tenant_app_users(id) ON DELETE CASCADE,
+    token_hash  VARCHAR(64)  UNIQUE NOT NULL,
+    expires_at  TIMESTAMP    NOT NULL,
+    used_at     TIMESTAMP,
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_app_password_reset_tokens_user ON app_password_reset_tokens(tenant_id, app_user_id);
+
+-- ============================================================
+-- Seed Data
+-- ============================================================
+
+INSERT INTO email_templates (slug, language, subject, body_html, variables) VALUES
+(
+    'app_password_reset',
+    'pt-BR',
+    '{{tenant_name}} — Redefinição de senha',
+    '<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{tenant_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Olá, {{user_name}}!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Recebemos uma solicitação para redefinir a senha da sua conta em <strong>{{tenant_name}}</strong>. Clique no botão abaixo para escolher uma nova senha:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{reset_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Redefinir minha senha
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Se o botão não funcionar, copie e cole este link no seu navegador:<br>
+      <a href="{{reset_url}}" style="color:#4F46E5;">{{reset_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Este link expira em {{expires_minutes}} minutos e só pode ser usado uma vez. Se você não solicitou a redefinição, ignore este e-mail.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "user_name", "reset_url", "expires_minutes"]'::jsonb
+),
+(
+    'app_password_reset',
+    'pt',
+    '{{tenant_name}} — Redefinição de palavra-passe',
+    '<!DOCTYPE html>
+<html lang="pt">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{tenant_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Olá, {{user_name}}!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Recebemos um pedido para redefinir a palavra-passe da sua conta em <strong>{{tenant_name}}</strong>. Clique no botão abaixo para escolher uma nova palavra-passe:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{reset_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Redefinir a minha palavra-passe
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Se o botão não funcionar, copie e cole esta ligação no seu navegador:<br>
+      <a href="{{reset_url}}" style="color:#4F46E5;">{{reset_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Esta ligação expira em {{expires_minutes}} minutos e só pode ser usada uma vez. Se não pediu a redefinição, ignore este e-mail.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "user_name", "reset_url", "expires_minutes"]'::jsonb
+),
+(
+    'app_password_reset',
+    'en',
+    '{{tenant_name}} — Password reset',
+    '<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{tenant_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Hello, {{user_name}}!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      We received a request to reset the password of your <strong>{{tenant_name}}</strong> account. Click the button below to choose a new password:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{reset_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Reset my password
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      If the button does not work, copy and paste this link into your browser:<br>
+      <a href="{{reset_url}}" style="color:#4F46E5;">{{reset_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      This link expires in {{expires_minutes}} minutes and can only be used once. If you did not request a reset, ignore this email.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "user_name", "reset_url", "expires_minutes"]'::jsonb
+),
+(
+    'app_password_reset',
+    'es',
+    '{{tenant_name}} — Restablecimiento de contraseña',
+    '<!DOCTYPE html>
+<html lang="es">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{tenant_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">¡Hola, {{user_name}}!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Recibimos una solicitud para restablecer la contraseña de tu cuenta en <strong>{{tenant_name}}</strong>. Haz clic en el botón de abajo para elegir una nueva contraseña:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{reset_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Restablecer mi contraseña
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Si el botón no funciona, copia y pega este enlace en tu navegador:<br>
+      <a href="{{reset_url}}" style="color:#4F46E5;">{{reset_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Este enlace caduca en {{expires_minutes}} minutos y solo puede usarse una vez. Si no solicitaste el restablecimiento, ignora este correo.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "user_name", "reset_url", "expires_minutes"]'::jsonb
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS password_reset_tokens CASCADE;
+
+DELETE FROM email_templates WHERE slug IN ('password_reset', 'password_changed');
@@ -0,0 +1,22 @@
+-- ============================================================
+-- Refresh Tokens
+-- ============================================================
+
+-- Opaque refresh tokens for admins, backoffice users and app users.
+-- Every use rotates the token inside the same family; replaying a used
+-- token revokes the whole family. Only the SHA-256 hash is stored.
+CREATE TABLE refresh_tokens (
+    id           UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    family_id    UUID         NOT NULL,
+    subject_type VARCHAR(10)  NOT NULL CHECK (subject_type IN ('admin', 'user', 'app')),
+    subject_id   UUID         NOT NULL,
+    tenant_id    UUID         REFERENCES tenants(id) ON DELETE CASCADE,
+    token_hash   VARCHAR(64)  UNIQUE NOT NULL,
+    expires_at   TIMESTAMP    NOT NULL,
+    used_at      TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_family  ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_subject ON refresh_tokens(subject_type, subject_id) WHERE revoked_at IS NULL;
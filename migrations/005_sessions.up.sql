@@ -0,0 +1,25 @@
+-- ============================================================
+-- Sessions
+-- ============================================================
+
+-- One row per login. The session id is the `jti` of every access token issued
+-- for it and the family_id of its refresh tokens, so it survives refreshes.
+CREATE TABLE sessions (
+    id           UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject_type VARCHAR(10)  NOT NULL CHECK (subject_type IN ('admin', 'user', 'app')),
+    subject_id   UUID         NOT NULL,
+    tenant_id    UUID         REFERENCES tenants(id) ON DELETE CASCADE,
+    user_agent   TEXT,
+    ip_address   VARCHAR(45),
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    revoked_at   TIMESTAMP
+);
+
+CREATE INDEX idx_sessions_subject ON sessions(subject_type, subject_id) WHERE revoked_at IS NULL;
+
+-- Refresh token families issued before sessions existed cannot be mapped to a
+-- session; drop them so those clients log in again.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_id_fkey FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE saas_admin_roles DROP COLUMN IF EXISTS require_2fa;
+DROP TABLE IF EXISTS two_factor_recovery_codes CASCADE;
+DROP TABLE IF EXISTS two_factor_secrets CASCADE;
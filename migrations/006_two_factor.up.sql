@@ -0,0 +1,32 @@
+-- ============================================================
+-- Two-factor authentication (TOTP)
+-- ============================================================
+
+-- One secret per admin or backoffice user. enabled_at stays NULL until the
+-- subject confirms the secret with a valid code.
+CREATE TABLE two_factor_secrets (
+    subject_type VARCHAR(10)  NOT NULL CHECK (subject_type IN ('admin', 'user')),
+    subject_id   UUID         NOT NULL,
+    secret       VARCHAR(64)  NOT NULL,
+    enabled_at   TIMESTAMP,
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subject_type, subject_id)
+);
+
+CREATE TABLE two_factor_recovery_codes (
+    id           UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject_type VARCHAR(10)  NOT NULL CHECK (subject_type IN ('admin', 'user')),
+    subject_id   UUID         NOT NULL,
+    code_hash    VARCHAR(64)  NOT NULL,
+    used_at      TIMESTAMP,
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_two_factor_recovery_codes_subject ON two_factor_recovery_codes(subject_type, subject_id);
+
+-- ============================================================
+-- Admin roles that require 2FA
+-- ============================================================
+
+ALTER TABLE saas_admin_roles ADD COLUMN require_2fa BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS member_invitations CASCADE;
+
+DELETE FROM email_templates WHERE slug = 'member_invite';
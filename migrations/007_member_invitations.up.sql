@@ -0,0 +1,204 @@
+-- ============================================================
+-- Member Invitations
+-- ============================================================
+
+-- Pending invitations to join a tenant. Only the SHA-256 hash of the token is
+-- stored; the raw token travels by email. A seat is reserved while the
+-- invitation is pending and not expired.
+CREATE TABLE member_invitations (
+    id          UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id   UUID         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    email       VARCHAR(255) NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    role_id     UUID         REFERENCES user_roles(id) ON DELETE SET NULL,
+    invited_by  UUID         REFERENCES users(id) ON DELETE SET NULL,
+    token_hash  VARCHAR(64)  UNIQUE NOT NULL,
+    expires_at  TIMESTAMP    NOT NULL,
+    accepted_at TIMESTAMP,
+    revoked_at  TIMESTAMP,
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+-- At most one open invitation per email and tenant
+CREATE UNIQUE INDEX idx_member_invitations_open ON member_invitations(tenant_id, LOWER(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX idx_member_invitations_tenant_id ON member_invitations(tenant_id);
+
+-- ============================================================
+-- Seed Data
+-- ============================================================
+
+INSERT INTO email_templates (slug, language, subject, body_html, variables) VALUES
+(
+    'member_invite',
+    'pt-BR',
+    '{{app_name}} — Convite para {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Você foi convidado!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{inviter_name}}</strong> convidou você para fazer parte de <strong>{{tenant_name}}</strong> no <strong>{{app_name}}</strong>. Clique no botão abaixo para aceitar o convite:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{invite_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Aceitar convite
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Se o botão não funcionar, copie e cole este link no seu navegador:<br>
+      <a href="{{invite_url}}" style="color:#4F46E5;">{{invite_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Este convite expira em {{expires_days}} dias. Se você não esperava este convite, ignore este e-mail.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "inviter_name", "invite_url", "expires_days"]'::jsonb
+),
+(
+    'member_invite',
+    'pt',
+    '{{app_name}} — Convite para {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="pt">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Foi convidado!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{inviter_name}}</strong> convidou-o para fazer parte de <strong>{{tenant_name}}</strong> no <strong>{{app_name}}</strong>. Clique no botão abaixo para aceitar o convite:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{invite_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Aceitar convite
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Se o botão não funcionar, copie e cole esta ligação no seu navegador:<br>
+      <a href="{{invite_url}}" style="color:#4F46E5;">{{invite_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Este convite expira em {{expires_days}} dias. Se não esperava este convite, ignore este e-mail.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "inviter_name", "invite_url", "expires_days"]'::jsonb
+),
+(
+    'member_invite',
+    'en',
+    '{{app_name}} — Invitation to {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">You have been invited!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{inviter_name}}</strong> invited you to join <strong>{{tenant_name}}</strong> on <strong>{{app_name}}</strong>. Click the button below to accept the invitation:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{invite_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Accept invitation
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      If the button does not work, copy and paste this link into your browser:<br>
+      <a href="{{invite_url}}" style="color:#4F46E5;">{{invite_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      This invitation expires in {{expires_days}} days. If you were not expecting it, ignore this email.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "inviter_name", "invite_url", "expires_days"]'::jsonb
+),
+(
+    'member_invite',
+    'es',
+    '{{app_name}} — Invitación a {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="es">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">¡Has sido invitado!</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{inviter_name}}</strong> te invitó a unirte a <strong>{{tenant_name}}</strong> en <strong>{{app_name}}</strong>. Haz clic en el botón de abajo para aceptar la invitación:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{invite_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Aceptar invitación
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Si el botón no funciona, copia y pega este enlace en tu navegador:<br>
+      <a href="{{invite_url}}" style="color:#4F46E5;">{{invite_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Esta invitación caduca en {{expires_days}} días. Si no esperabas esta invitación, ignora este correo.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "inviter_name", "invite_url", "expires_days"]'::jsonb
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS tenant_ownership_audit CASCADE;
+DROP TABLE IF EXISTS ownership_transfers CASCADE;
+
+DELETE FROM email_templates WHERE slug = 'ownership_transfer';
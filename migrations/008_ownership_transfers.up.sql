@@ -0,0 +1,227 @@
+-- ============================================================
+-- Ownership Transfers
+-- ============================================================
+
+-- Transfers started by a tenant owner. The target member confirms with the
+-- emailed token; only its SHA-256 hash is stored.
+CREATE TABLE ownership_transfers (
+    id           UUID        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id    UUID        NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    from_user_id UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id   UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash   VARCHAR(64) UNIQUE NOT NULL,
+    expires_at   TIMESTAMP   NOT NULL,
+    confirmed_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    created_at   TIMESTAMP   NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+-- At most one open transfer per tenant
+CREATE UNIQUE INDEX idx_ownership_transfers_open ON ownership_transfers(tenant_id)
+    WHERE confirmed_at IS NULL AND cancelled_at IS NULL;
+
+-- Every change of owner. A transfer confirmed by the new owner has
+-- transfer_id set; an override by a system admin has admin_id and a reason.
+CREATE TABLE tenant_ownership_audit (
+    id                UUID      PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id         UUID      NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    previous_owner_id UUID      REFERENCES users(id) ON DELETE SET NULL,
+    new_owner_id      UUID      REFERENCES users(id) ON DELETE SET NULL,
+    transfer_id       UUID      REFERENCES ownership_transfers(id) ON DELETE SET NULL,
+    admin_id          UUID      REFERENCES saas_admin_users(id) ON DELETE SET NULL,
+    reason            TEXT,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_tenant_ownership_audit_tenant_id ON tenant_ownership_audit(tenant_id);
+
+-- ============================================================
+-- Seed Data
+-- ============================================================
+
+INSERT INTO email_templates (slug, language, subject, body_html, variables) VALUES
+(
+    'ownership_transfer',
+    'pt-BR',
+    '{{app_name}} — Transferência de {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Transferência de propriedade</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{owner_name}}</strong> quer transferir a propriedade de <strong>{{tenant_name}}</strong> no <strong>{{app_name}}</strong> para você. Como novo proprietário, você terá controle total sobre a conta, incluindo assinatura e membros.
+    </p>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Clique no botão abaixo para aceitar a transferência:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{confirm_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Aceitar transferência
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Se o botão não funcionar, copie e cole este link no seu navegador:<br>
+      <a href="{{confirm_url}}" style="color:#4F46E5;">{{confirm_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Este link expira em {{expires_days}} dias. Se você não esperava esta transferência, ignore este e-mail.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "owner_name", "confirm_url", "expires_days"]'::jsonb
+),
+(
+    'ownership_transfer',
+    'pt',
+    '{{app_name}} — Transferência de {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="pt">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Transferência de propriedade</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{owner_name}}</strong> pretende transferir a propriedade de <strong>{{tenant_name}}</strong> no <strong>{{app_name}}</strong> para si. Como novo proprietário, terá controlo total sobre a conta, incluindo subscrição e membros.
+    </p>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Clique no botão abaixo para aceitar a transferência:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{confirm_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Aceitar transferência
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Se o botão não funcionar, copie e cole esta ligação no seu navegador:<br>
+      <a href="{{confirm_url}}" style="color:#4F46E5;">{{confirm_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Esta ligação expira em {{expires_days}} dias. Se não esperava esta transferência, ignore este e-mail.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "owner_name", "confirm_url", "expires_days"]'::jsonb
+),
+(
+    'ownership_transfer',
+    'en',
+    '{{app_name}} — Transfer of {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Ownership transfer</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{owner_name}}</strong> wants to transfer ownership of <strong>{{tenant_name}}</strong> on <strong>{{app_name}}</strong> to you. As the new owner you will have full control of the account, including its subscription and members.
+    </p>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Click the button below to accept the transfer:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{confirm_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Accept transfer
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      If the button does not work, copy and paste this link into your browser:<br>
+      <a href="{{confirm_url}}" style="color:#4F46E5;">{{confirm_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      This link expires in {{expires_days}} days. If you were not expecting this transfer, ignore this email.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "owner_name", "confirm_url", "expires_days"]'::jsonb
+),
+(
+    'ownership_transfer',
+    'es',
+    '{{app_name}} — Transferencia de {{tenant_name}}',
+    '<!DOCTYPE html>
+<html lang="es">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;">
+<table width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f7;padding:40px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 8px rgba(0,0,0,0.08);">
+  <tr><td style="background:#4F46E5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;">{{app_name}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#333;margin:0 0 16px;">Transferencia de propiedad</h2>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      <strong>{{owner_name}}</strong> quiere transferirte la propiedad de <strong>{{tenant_name}}</strong> en <strong>{{app_name}}</strong>. Como nuevo propietario tendrás control total sobre la cuenta, incluida la suscripción y los miembros.
+    </p>
+    <p style="color:#555;font-size:16px;line-height:1.6;">
+      Haz clic en el botón de abajo para aceptar la transferencia:
+    </p>
+    <table width="100%" cellpadding="0" cellspacing="0" style="margin:32px 0;">
+    <tr><td align="center">
+      <a href="{{confirm_url}}" style="display:inline-block;background:#4F46E5;color:#ffffff;text-decoration:none;padding:14px 32px;border-radius:6px;font-size:16px;font-weight:bold;">
+        Aceptar transferencia
+      </a>
+    </td></tr>
+    </table>
+    <p style="color:#888;font-size:14px;">
+      Si el botón no funciona, copia y pega este enlace en tu navegador:<br>
+      <a href="{{confirm_url}}" style="color:#4F46E5;">{{confirm_url}}</a>
+    </p>
+  </td></tr>
+  <tr><td style="background:#f8f8fa;padding:24px 40px;text-align:center;">
+    <p style="color:#999;font-size:12px;margin:0;">
+      Este enlace caduca en {{expires_days}} días. Si no esperabas esta transferencia, ignora este correo.
+    </p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    '["app_name", "tenant_name", "owner_name", "confirm_url", "expires_days"]'::jsonb
+);
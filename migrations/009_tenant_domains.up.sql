@@ -0,0 +1,28 @@
+-- ============================================================
+-- Tenant Domains
+-- ============================================================
+
+-- Custom domains a tenant serves its storefront on. A domain routes to the
+-- tenant once the TXT record "_saas-verification.{domain}" containing
+-- "saas-verification={verification_token}" has been found in its DNS.
+CREATE TABLE tenant_domains (
+    id                 UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id          UUID         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    domain             VARCHAR(255) NOT NULL,
+    verification_token VARCHAR(64)  NOT NULL,
+    verified_at        TIMESTAMP,
+    last_checked_at    TIMESTAMP,
+    created_at         TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_tenant_domains_tenant_domain ON tenant_domains(tenant_id, domain);
+-- A verified domain belongs to exactly one tenant
+CREATE UNIQUE INDEX idx_tenant_domains_verified ON tenant_domains(domain) WHERE verified_at IS NOT NULL;
+CREATE INDEX idx_tenant_domains_tenant_id ON tenant_domains(tenant_id);
+
+-- Carry over domains recorded in tenants.custom_domain; they still have to be verified
+INSERT INTO tenant_domains (tenant_id, domain, verification_token)
+SELECT id, LOWER(TRIM(TRAILING '.' FROM TRIM(custom_domain))), REPLACE(uuid_generate_v4()::text, '-', '')
+FROM tenants
+WHERE custom_domain IS NOT NULL AND TRIM(custom_domain) <> '' AND deleted_at IS NULL;
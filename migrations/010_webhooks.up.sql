@@ -0,0 +1,58 @@
+-- ============================================================
+-- Webhooks
+-- ============================================================
+
+-- Endpoints a tenant wants events POSTed to. events lists the subscribed
+-- event types; every request is signed with the endpoint's secret.
+CREATE TABLE webhook_endpoints (
+    id          UUID          PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id   UUID          NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    url         VARCHAR(2048) NOT NULL,
+    description VARCHAR(255),
+    events      TEXT[]        NOT NULL DEFAULT '{}',
+    secret      VARCHAR(100)  NOT NULL,
+    is_active   BOOLEAN       NOT NULL DEFAULT true,
+    created_at  TIMESTAMP     NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_endpoints_tenant_id ON webhook_endpoints(tenant_id);
+
+-- One row per event and endpoint. The table is the delivery queue: pending
+-- rows are claimed once next_attempt_at has passed, and locked_until keeps
+-- a claimed row from being picked up again until its attempt is recorded
+-- (or the worker that claimed it is gone).
+CREATE TABLE webhook_deliveries (
+    id              UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    endpoint_id     UUID         NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    tenant_id       UUID         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    event_id        UUID         NOT NULL,
+    event_type      VARCHAR(100) NOT NULL,
+    payload         JSONB        NOT NULL,
+    status          VARCHAR(20)  NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts        INTEGER      NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMP,
+    last_error      TEXT,
+    response_status INTEGER,
+    delivered_at    TIMESTAMP,
+    created_at      TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Log of every request made for a delivery
+CREATE TABLE webhook_delivery_attempts (
+    id              UUID        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    delivery_id     UUID        NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt         INTEGER     NOT NULL,
+    response_status INTEGER,
+    response_body   TEXT,
+    error           TEXT,
+    duration_ms     INTEGER     NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempt);
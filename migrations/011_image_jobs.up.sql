@@ -0,0 +1,31 @@
+-- ============================================================
+-- Image Jobs
+-- ============================================================
+
+-- Queue of image processing work. A job is claimed by one worker with
+-- SKIP LOCKED and leased until locked_until; a job whose lease runs out is
+-- picked up again, so every job runs at least once. Finished jobs are
+-- deleted; jobs that used up their attempts stay as 'dead' (the dead-letter
+-- list) until they are retried or the image is deleted.
+CREATE TABLE image_jobs (
+    id           UUID        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    image_id     UUID        NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+    tenant_id    UUID        NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    status       VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'dead')),
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    run_at       TIMESTAMP   NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    last_error   TEXT,
+    created_at   TIMESTAMP   NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+-- An image has at most one job waiting or running
+CREATE UNIQUE INDEX idx_image_jobs_active ON image_jobs(image_id) WHERE status IN ('queued', 'running');
+CREATE INDEX idx_image_jobs_due ON image_jobs(run_at) WHERE status = 'queued';
+CREATE INDEX idx_image_jobs_dead ON image_jobs(updated_at) WHERE status = 'dead';
+
+-- Queue the images left unprocessed by the pub/sub worker
+INSERT INTO image_jobs (image_id, tenant_id)
+SELECT id, tenant_id FROM images WHERE processing_status IN ('pending', 'processing');
+UPDATE images SET processing_status = 'pending' WHERE processing_status = 'processing';
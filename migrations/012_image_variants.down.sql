@@ -0,0 +1,18 @@
+ALTER TABLE images
+    ADD COLUMN medium_path TEXT,
+    ADD COLUMN medium_url  TEXT,
+    ADD COLUMN small_path  TEXT,
+    ADD COLUMN small_url   TEXT,
+    ADD COLUMN thumb_path  TEXT,
+    ADD COLUMN thumb_url   TEXT;
+
+UPDATE images SET
+    medium_path = variants->'medium'->>'path', medium_url = variants->'medium'->>'url',
+    small_path  = variants->'small'->>'path',  small_url  = variants->'small'->>'url',
+    thumb_path  = variants->'thumb'->>'path',  thumb_url  = variants->'thumb'->>'url';
+
+ALTER TABLE images DROP COLUMN IF EXISTS variants;
+ALTER TABLE tenant_settings DROP COLUMN IF EXISTS image_variants;
+ALTER TABLE saas_plans
+    DROP COLUMN IF EXISTS max_image_variants,
+    DROP COLUMN IF EXISTS max_image_size;
@@ -0,0 +1,34 @@
+-- ============================================================
+-- Image Variants
+-- ============================================================
+
+-- How far a plan lets tenants customise their image variants: how many
+-- presets they may define and the largest width/height of any of them
+ALTER TABLE saas_plans
+    ADD COLUMN max_image_variants INTEGER NOT NULL DEFAULT 3,
+    ADD COLUMN max_image_size     INTEGER NOT NULL DEFAULT 1600;
+
+UPDATE saas_plans SET max_image_variants = 6, max_image_size = 2560 WHERE plan_type = 'business';
+
+-- Variant presets of the tenant, a JSON array of
+-- {"name","width","height","mode","quality"}; NULL uses the built-in
+-- medium/small/thumb presets
+ALTER TABLE tenant_settings ADD COLUMN image_variants JSONB;
+
+-- Generated variants keyed by preset name:
+-- {"medium": {"path","url","width","height","size"}, ...}
+ALTER TABLE images ADD COLUMN variants JSONB NOT NULL DEFAULT '{}';
+
+UPDATE images SET variants = jsonb_strip_nulls(jsonb_build_object(
+    'medium', CASE WHEN medium_path IS NOT NULL THEN jsonb_build_object('path', medium_path, 'url', medium_url) END,
+    'small',  CASE WHEN small_path  IS NOT NULL THEN jsonb_build_object('path', small_path,  'url', small_url)  END,
+    'thumb',  CASE WHEN thumb_path  IS NOT NULL THEN jsonb_build_object('path', thumb_path,  'url', thumb_url)  END
+));
+
+ALTER TABLE images
+    DROP COLUMN medium_path,
+    DROP COLUMN medium_url,
+    DROP COLUMN small_path,
+    DROP COLUMN small_url,
+    DROP COLUMN thumb_path,
+    DROP COLUMN thumb_url;
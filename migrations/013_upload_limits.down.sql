@@ -0,0 +1,3 @@
+ALTER TABLE saas_plans
+    DROP COLUMN IF EXISTS max_upload_size,
+    DROP COLUMN IF EXISTS max_upload_dimension;
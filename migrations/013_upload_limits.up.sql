@@ -0,0 +1,10 @@
+-- ============================================================
+-- Upload Limits
+-- ============================================================
+
+-- Largest file and largest width/height a plan accepts for uploaded images
+ALTER TABLE saas_plans
+    ADD COLUMN max_upload_size      BIGINT  NOT NULL DEFAULT 10485760,
+    ADD COLUMN max_upload_dimension INTEGER NOT NULL DEFAULT 6000;
+
+UPDATE saas_plans SET max_upload_size = 26214400, max_upload_dimension = 10000 WHERE plan_type = 'business';
@@ -0,0 +1,6 @@
+DELETE FROM images WHERE tenant_id IS NULL;
+
+ALTER TABLE image_jobs ALTER COLUMN tenant_id SET NOT NULL;
+
+ALTER TABLE images DROP CONSTRAINT IF EXISTS images_tenant_required;
+ALTER TABLE images ALTER COLUMN tenant_id SET NOT NULL;
@@ -0,0 +1,12 @@
+-- ============================================================
+-- Profile Images
+-- ============================================================
+
+-- Avatars and logos are stored in images like any other picture. Backoffice
+-- users belong to no tenant, so their avatars are the one kind of image
+-- without one.
+ALTER TABLE images ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE images ADD CONSTRAINT images_tenant_required
+    CHECK (tenant_id IS NOT NULL OR imageable_type = 'user_avatar');
+
+ALTER TABLE image_jobs ALTER COLUMN tenant_id DROP NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE images DROP CONSTRAINT IF EXISTS images_visibility_check;
+ALTER TABLE images DROP COLUMN IF EXISTS visibility;
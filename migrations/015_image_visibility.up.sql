@@ -0,0 +1,9 @@
+-- ============================================================
+-- Image Visibility
+-- ============================================================
+
+-- Private images are stored under the private/ prefix and only served
+-- through signed, expiring URLs
+ALTER TABLE images ADD COLUMN IF NOT EXISTS visibility VARCHAR(10) NOT NULL DEFAULT 'public';
+ALTER TABLE images ADD CONSTRAINT images_visibility_check
+    CHECK (visibility IN ('public', 'private'));
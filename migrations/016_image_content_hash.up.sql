@@ -0,0 +1,11 @@
+-- ============================================================
+-- Image Content Hash
+-- ============================================================
+
+-- SHA-256 of the uploaded file. A tenant uploading content it already has
+-- gets a new image sharing the stored files and variants of the first one;
+-- the files are deleted with the last image using them.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_images_content_hash ON images(tenant_id, content_hash) WHERE content_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_original_path ON images(original_path);
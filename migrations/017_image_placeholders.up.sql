@@ -0,0 +1,8 @@
+-- ============================================================
+-- Image Placeholders
+-- ============================================================
+
+-- Shown by clients while an image loads: a BlurHash of the image and its
+-- average color as #rrggbb. Both are set by the worker.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS blurhash VARCHAR(64);
+ALTER TABLE images ADD COLUMN IF NOT EXISTS dominant_color VARCHAR(7);
@@ -0,0 +1,21 @@
+-- ============================================================
+-- Image Events
+-- ============================================================
+
+-- Log of image processing state changes, streamed to the tenant over SSE.
+-- Appends of a tenant are serialized, so its ids commit in increasing order
+-- and a client resumes after the last id it saw. Events are kept for a day;
+-- the image worker prunes older ones.
+CREATE TABLE image_events (
+    id             BIGSERIAL    PRIMARY KEY,
+    tenant_id      UUID         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    image_id       UUID         NOT NULL,
+    imageable_type VARCHAR(50)  NOT NULL,
+    imageable_id   UUID         NOT NULL,
+    type           VARCHAR(20)  NOT NULL CHECK (type IN ('processing', 'completed', 'failed')),
+    reason         TEXT,
+    created_at     TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_image_events_tenant ON image_events(tenant_id, id);
+CREATE INDEX idx_image_events_created_at ON image_events(created_at);
@@ -5,10 +5,44 @@
 db-migrate:
 	@echo "Running migrations..."
 	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/001_initial_schema.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/002_app_password_reset.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/003_user_password_reset.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/004_refresh_tokens.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/005_sessions.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/006_two_factor.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/007_member_invitations.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/008_ownership_transfers.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/009_tenant_domains.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/010_webhooks.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/011_image_jobs.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/012_image_variants.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/013_upload_limits.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/014_profile_images.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/015_image_visibility.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/016_image_content_hash.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/017_image_placeholders.up.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/018_image_events.up.sql
 	@echo "✓ Migrations completed"
 
 db-migrate-down:
 	@echo "Rolling back migrations..."
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/018_image_events.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/017_image_placeholders.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/016_image_content_hash.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/015_image_visibility.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/014_profile_images.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/013_upload_limits.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/012_image_variants.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/011_image_jobs.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/010_webhooks.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/009_tenant_domains.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/008_ownership_transfers.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/007_member_invitations.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/006_two_factor.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/005_sessions.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/004_refresh_tokens.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/003_user_password_reset.down.sql
+	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/002_app_password_reset.down.sql
 	@docker compose exec postgres psql -U saasuser -d saasdb -f /migrations/001_initial_schema.down.sql
 	@echo "✓ Rollback completed"
 
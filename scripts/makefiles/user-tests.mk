@@ -33,10 +33,10 @@ test-members-invite:
 		-d '{"email":"joao@minha-loja.com","password":"senha12345"}'); \
 	TOKEN=$$(echo "$$LOGIN" | grep -o '"token":"[^"]*' | cut -d'"' -f4); \
 	URL_CODE=$$(echo "$$LOGIN" | grep -o '"current_tenant_code":"[^"]*' | cut -d'"' -f4); \
-	curl -s -X POST http://localhost:8080/api/v1/$$URL_CODE/members/invite \
+	curl -s -X POST http://localhost:8080/api/v1/$$URL_CODE/members \
 		-H "Content-Type: application/json" \
 		-H "Authorization: Bearer $$TOKEN" \
-		-d '{"name":"Colaborador","email":"colab@minha-loja.com","password":"senha12345","role_slug":"member"}'
+		-d '{"name":"Colaborador","email":"colab@minha-loja.com","role":"member"}'
 	@echo ""
 
 # Test roles list